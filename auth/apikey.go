@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// hashPrefix starts the key hashes of a keys file
+const hashPrefix = "sha256:"
+
+// APIKeys authenticates requests by API keys, sent as "X-API-Key: <key>" or
+// "Authorization: Bearer <key>". Only SHA-256 hashes of the keys are kept.
+type APIKeys struct {
+	keys []apiKey
+}
+
+type apiKey struct {
+	id     string
+	hash   []byte
+	scopes []string
+}
+
+// LoadAPIKeys reads a keys file, see ParseAPIKeys
+func LoadAPIKeys(path string) (*APIKeys, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API keys file: %w", err)
+	}
+	keys, err := ParseAPIKeys(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return keys, nil
+}
+
+// ParseAPIKeys reads API keys, one per line: an ID, the key hash from HashKey and
+// comma-separated scopes, e.g. "ci-bot sha256:9f86d0... render,parse". Lines starting with # are comments.
+func ParseAPIKeys(content string) (*APIKeys, error) {
+	keys := &APIKeys{}
+	ids := make(map[string]bool)
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: expected \"<id> sha256:<hash> <scopes>\"", i+1)
+		}
+		id, hash, scopes := fields[0], fields[1], strings.Split(fields[2], ",")
+		if ids[id] {
+			return nil, fmt.Errorf("line %d: duplicate key ID %q", i+1, id)
+		}
+		ids[id] = true
+		sum, err := hex.DecodeString(strings.TrimPrefix(hash, hashPrefix))
+		if !strings.HasPrefix(hash, hashPrefix) || err != nil || len(sum) != sha256.Size {
+			return nil, fmt.Errorf("line %d: key hash must be %s followed by 64 hex digits", i+1, hashPrefix)
+		}
+		for _, scope := range scopes {
+			if !containsString(Scopes, scope) {
+				return nil, fmt.Errorf("line %d: unknown scope %q (known: %s)", i+1, scope, strings.Join(Scopes, ", "))
+			}
+		}
+		keys.keys = append(keys.keys, apiKey{id: id, hash: sum, scopes: scopes})
+	}
+	return keys, nil
+}
+
+// HashKey returns the form of a key stored in a keys file
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hashPrefix + hex.EncodeToString(sum[:])
+}
+
+// NewKey returns a random API key
+func NewKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	return "cvcl_" + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Authenticate identifies the caller by its API key
+func (k *APIKeys) Authenticate(r *http.Request) (*Principal, error) {
+	key := r.Header.Get("X-API-Key")
+	if token := bearerToken(r); key == "" && !isJWT(token) {
+		key = token
+	}
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	var match *apiKey
+	// Compare with every key so that timing does not reveal which one matched
+	for i := range k.keys {
+		if subtle.ConstantTimeCompare(sum[:], k.keys[i].hash) == 1 {
+			match = &k.keys[i]
+		}
+	}
+	if match == nil {
+		return nil, errors.New("invalid API key")
+	}
+	return &Principal{ID: match.id, Method: MethodAPIKey, Scopes: match.scopes}, nil
+}
@@ -0,0 +1,96 @@
+// Package auth authenticates API requests with hashed API keys or with JWTs signed by
+// the keys of a local JSON Web Key Set, and checks the scopes they grant.
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Scopes granted to API keys and tokens
+const (
+	ScopeRender = "render"
+	ScopeParse  = "parse"
+	// ScopeAdmin grants every scope
+	ScopeAdmin = "admin"
+)
+
+// Scopes lists the known scopes
+var Scopes = []string{ScopeRender, ScopeParse, ScopeAdmin}
+
+// ErrNoCredentials is returned by an Authenticator when a request carries no credentials it handles
+var ErrNoCredentials = errors.New("no credentials")
+
+// Authentication methods of a Principal
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+)
+
+// Principal is an authenticated caller
+type Principal struct {
+	// ID is the API key ID or the subject of the token
+	ID string
+	// Method is MethodAPIKey or MethodJWT
+	Method string
+	Scopes []string
+}
+
+// Allows reports whether the principal holds scope, or the admin scope
+func (p *Principal) Allows(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator identifies the caller of a request. It returns ErrNoCredentials when the
+// request carries no credentials it handles, and another error when they are invalid.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// Chain tries its authenticators in order until one handles the request's credentials
+type Chain []Authenticator
+
+// Authenticate returns the principal of the first authenticator handling the credentials
+func (c Chain) Authenticate(r *http.Request) (*Principal, error) {
+	for _, authenticator := range c {
+		principal, err := authenticator.Authenticate(r)
+		if !errors.Is(err, ErrNoCredentials) {
+			return principal, err
+		}
+	}
+	return nil, ErrNoCredentials
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header, or ""
+func bearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// isJWT reports whether a token has the three dot-separated parts of a JWT
+func isJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+type principalKey struct{}
+
+// NewContext returns a context carrying the principal
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal of a request context, or nil when it is unauthenticated
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func request(header, value string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/v1/render", nil)
+	if header != "" {
+		r.Header.Set(header, value)
+	}
+	return r
+}
+
+func TestAPIKeys(t *testing.T) {
+	keys, err := ParseAPIKeys("# Keys\nci-bot " + HashKey("secret-1") + " render,parse\nops " + HashKey("secret-2") + " admin\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := keys.Authenticate(request("X-API-Key", "secret-1"))
+	if err != nil || p.ID != "ci-bot" || p.Method != MethodAPIKey || !p.Allows(ScopeParse) || p.Allows(ScopeAdmin) {
+		t.Errorf("Unexpected principal %+v, %v", p, err)
+	}
+	p, err = keys.Authenticate(request("Authorization", "Bearer secret-2"))
+	if err != nil || p.ID != "ops" || !p.Allows(ScopeRender) {
+		t.Errorf("Admin keys should hold every scope: %+v, %v", p, err)
+	}
+	if _, err := keys.Authenticate(request("X-API-Key", "secret-3")); err == nil || errors.Is(err, ErrNoCredentials) {
+		t.Errorf("Expected an invalid key, got %v", err)
+	}
+	if _, err := keys.Authenticate(request("", "")); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("Expected no credentials, got %v", err)
+	}
+
+	for content, want := range map[string]string{
+		"ci-bot secret render\n":                                        "line 1: key hash must be sha256:",
+		"ci-bot " + HashKey("a") + " render,write\n":                    "line 1: unknown scope \"write\"",
+		"a " + HashKey("a") + " render\na " + HashKey("b") + " parse\n": "line 2: duplicate key ID",
+	} {
+		if _, err := ParseAPIKeys(content); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error %q, got %v", want, err)
+		}
+	}
+}
+
+// signToken returns a JWT signed with an RSA or EC private key
+func signToken(t *testing.T, key crypto.Signer, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	alg := "RS256"
+	if _, ok := key.(*ecdsa.PrivateKey); ok {
+		alg = "ES256"
+	}
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	var signature []byte
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		signature, _ = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestJWKS(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	encode := func(n *big.Int) string { return base64.RawURLEncoding.EncodeToString(n.Bytes()) }
+	document, _ := json.Marshal(map[string]interface{}{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": encode(rsaKey.N), "e": encode(big.NewInt(int64(rsaKey.E)))},
+		{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": encode(ecKey.X), "y": encode(ecKey.Y)},
+		{"kty": "RSA", "kid": "enc-1", "use": "enc", "n": "AQAB", "e": "AQAB"},
+	}})
+	jwks, err := ParseJWKS(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks.Issuer, jwks.Audience = "https://issuer.example.com", "cvcl-render"
+	now := time.Unix(1700000000, 0)
+	jwks.now = func() time.Time { return now }
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{"sub": "svc-1", "iss": jwks.Issuer, "aud": []string{"other", "cvcl-render"}, "exp": now.Add(time.Hour).Unix(), "scope": "render parse"}
+		for k, v := range overrides {
+			c[k] = v
+		}
+		return c
+	}
+	bearer := func(token string) *http.Request { return request("Authorization", "Bearer "+token) }
+
+	p, err := jwks.Authenticate(bearer(signToken(t, rsaKey, "rsa-1", claims(nil))))
+	if err != nil || p.ID != "svc-1" || p.Method != MethodJWT || !reflect.DeepEqual(p.Scopes, []string{"render", "parse"}) {
+		t.Errorf("Unexpected RS256 principal %+v, %v", p, err)
+	}
+	p, err = jwks.Authenticate(bearer(signToken(t, ecKey, "ec-1", claims(map[string]interface{}{"scope": nil, "scp": []string{"admin"}}))))
+	if err != nil || !p.Allows(ScopeParse) {
+		t.Errorf("Unexpected ES256 principal %+v, %v", p, err)
+	}
+
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	for name, token := range map[string]string{
+		"token expired":               signToken(t, rsaKey, "rsa-1", claims(map[string]interface{}{"exp": now.Add(-time.Hour).Unix()})),
+		"not valid yet":               signToken(t, rsaKey, "rsa-1", claims(map[string]interface{}{"nbf": now.Add(time.Hour).Unix()})),
+		"unexpected issuer":           signToken(t, rsaKey, "rsa-1", claims(map[string]interface{}{"iss": "https://evil.example.com"})),
+		"not meant for this audience": signToken(t, rsaKey, "rsa-1", claims(map[string]interface{}{"aud": "other"})),
+		"missing exp":                 signToken(t, rsaKey, "rsa-1", claims(map[string]interface{}{"exp": nil})),
+		"bad signature":               signToken(t, otherKey, "rsa-1", claims(nil)),
+		"unknown key \"enc-1\"":       signToken(t, rsaKey, "enc-1", claims(nil)),
+		"does not match the RSA key":  strings.Replace(signToken(t, rsaKey, "rsa-1", claims(nil)), "eyJhbGciOiJSUzI1NiIs", "eyJhbGciOiJFUzI1NiIs", 1),
+	} {
+		if _, err := jwks.Authenticate(bearer(token)); err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("Expected %q, got %v", name, err)
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	keys, _ := ParseAPIKeys("ci-bot " + HashKey("secret") + " render\n")
+	chain := Chain{keys, &JWKS{}}
+	if p, err := chain.Authenticate(request("X-API-Key", "secret")); err != nil || p.ID != "ci-bot" {
+		t.Errorf("Unexpected principal %+v, %v", p, err)
+	}
+	if _, err := chain.Authenticate(request("Authorization", "Bearer a.b.c")); err == nil || errors.Is(err, ErrNoCredentials) {
+		t.Errorf("Expected the JWT to be rejected, got %v", err)
+	}
+	if _, err := chain.Authenticate(request("", "")); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("Expected no credentials, got %v", err)
+	}
+}
@@ -0,0 +1,257 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// clockSkew is the leeway allowed when checking the exp and nbf claims
+const clockSkew = time.Minute
+
+// JWKS authenticates requests by JWT bearer tokens signed with the RS256 or ES256 keys
+// of a JSON Web Key Set. Tokens must have a sub and an exp claim; their scopes are read
+// from a space-separated scope claim or an scp list.
+type JWKS struct {
+	// Issuer and Audience, when set, must match the iss and aud claims
+	Issuer   string
+	Audience string
+
+	keys map[string]crypto.PublicKey
+	now  func() time.Time
+}
+
+// jsonWebKey is a key of a JWKS document
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA keys
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC keys
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// LoadJWKS reads a JWKS file
+func LoadJWKS(path string) (*JWKS, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+	jwks, err := ParseJWKS(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return jwks, nil
+}
+
+// ParseJWKS reads the signing keys of a JWKS document. Keys for other uses are skipped.
+func ParseJWKS(content []byte) (*JWKS, error) {
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+	jwks := &JWKS{keys: make(map[string]crypto.PublicKey), now: time.Now}
+	for i, key := range document.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		pub, err := key.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %d (%s): %w", i, key.Kid, err)
+		}
+		if _, ok := jwks.keys[key.Kid]; ok {
+			return nil, fmt.Errorf("key %d: duplicate kid %q", i, key.Kid)
+		}
+		jwks.keys[key.Kid] = pub
+	}
+	if len(jwks.keys) == 0 {
+		return nil, errors.New("JWKS has no signing keys")
+	}
+	return jwks, nil
+}
+
+// publicKey decodes an RSA or P-256 key
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid n: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() || e.Int64() > 1<<31 {
+			return nil, errors.New("invalid e")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, errX := decodeBigInt(k.X)
+		y, errY := decodeBigInt(k.Y)
+		if errX != nil || errY != nil || !elliptic.P256().IsOnCurve(x, y) {
+			return nil, errors.New("invalid EC point")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("not base64url")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// tokenClaims are the claims read from a JWT
+type tokenClaims struct {
+	Subject   string          `json:"sub"`
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *float64        `json:"exp"`
+	NotBefore *float64        `json:"nbf"`
+	Scope     string          `json:"scope"`
+	Scp       json.RawMessage `json:"scp"`
+}
+
+// Authenticate verifies the bearer token of a request
+func (j *JWKS) Authenticate(r *http.Request) (*Principal, error) {
+	token := bearerToken(r)
+	if !isJWT(token) {
+		return nil, ErrNoCredentials
+	}
+	claims, err := j.verify(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+	return &Principal{ID: claims.Subject, Method: MethodJWT, Scopes: claims.scopes()}, nil
+}
+
+// verify checks the signature and claims of a token
+func (j *JWKS) verify(token string) (*tokenClaims, error) {
+	parts := strings.Split(token, ".")
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed header: %w", err)
+	}
+	key, ok := j.keys[header.Kid]
+	if !ok && header.Kid == "" && len(j.keys) == 1 {
+		for _, only := range j.keys {
+			key, ok = only, true
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", header.Kid)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed signature")
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		if header.Alg != "RS256" {
+			return nil, fmt.Errorf("algorithm %q does not match the RSA key", header.Alg)
+		}
+		if rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature) != nil {
+			return nil, errors.New("bad signature")
+		}
+	case *ecdsa.PublicKey:
+		if header.Alg != "ES256" {
+			return nil, fmt.Errorf("algorithm %q does not match the EC key", header.Alg)
+		}
+		if len(signature) != 64 {
+			return nil, errors.New("bad signature")
+		}
+		sigR, sigS := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(pub, digest[:], sigR, sigS) {
+			return nil, errors.New("bad signature")
+		}
+	}
+
+	var claims tokenClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed claims: %w", err)
+	}
+	now := j.now()
+	switch {
+	case claims.Subject == "":
+		return nil, errors.New("missing sub claim")
+	case claims.ExpiresAt == nil:
+		return nil, errors.New("missing exp claim")
+	case now.After(unixTime(*claims.ExpiresAt).Add(clockSkew)):
+		return nil, errors.New("token expired")
+	case claims.NotBefore != nil && now.Add(clockSkew).Before(unixTime(*claims.NotBefore)):
+		return nil, errors.New("token not valid yet")
+	case j.Issuer != "" && claims.Issuer != j.Issuer:
+		return nil, fmt.Errorf("unexpected issuer %q", claims.Issuer)
+	case j.Audience != "" && !containsString(stringOrList(claims.Audience), j.Audience):
+		return nil, errors.New("token is not meant for this audience")
+	}
+	return &claims, nil
+}
+
+// scopes returns the scope claim, or the scp claim when scope is empty
+func (c tokenClaims) scopes() []string {
+	if c.Scope != "" {
+		return strings.Fields(c.Scope)
+	}
+	return stringOrList(c.Scp)
+}
+
+// stringOrList reads a claim holding a string or a list of strings
+func stringOrList(raw json.RawMessage) []string {
+	var list []string
+	if json.Unmarshal(raw, &list) == nil {
+		return list
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return strings.Fields(s)
+	}
+	return nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func unixTime(seconds float64) time.Time {
+	return time.Unix(int64(seconds), 0)
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
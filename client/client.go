@@ -0,0 +1,320 @@
+// Package client is a Go client for the cvcl-render HTTP API (/v1).
+//
+// Documents are sent as any value that encodes to the JSON documents accepted by the server,
+// e.g. json.RawMessage, a map or a struct with the same JSON fields. Rendered files are
+// streamed to an io.Writer. Requests answered with 429 or 503 are retried with backoff,
+// except when a daily render quota is used up.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults of a new client
+const (
+	DefaultMaxRetries = 3
+	DefaultRetryWait  = 500 * time.Millisecond
+	// maxRetryWait caps the wait between attempts, including waits asked for by Retry-After
+	maxRetryWait = 30 * time.Second
+)
+
+// Client calls a cvcl-render server
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	// APIKey, when set, is sent as a bearer token; a JWT may be used instead of a key
+	APIKey string
+	// MaxRetries is the number of retries of requests answered with 429 or 503
+	MaxRetries int
+	// RetryWait is the wait before the first retry; it doubles with every retry unless
+	// the server sends Retry-After
+	RetryWait time.Duration
+}
+
+// New returns a client for the server at baseURL, e.g. "http://localhost:8080"
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: http.DefaultClient,
+		MaxRetries: DefaultMaxRetries,
+		RetryWait:  DefaultRetryWait,
+	}
+}
+
+// FieldError is a problem with one field of a request
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is an error answered by the server
+type Error struct {
+	StatusCode int
+	// Code is the machine-readable error code, e.g. "validation_failed"
+	Code    string
+	Message string
+	Details []FieldError
+	// Warnings are decode warnings reported with the error
+	Warnings []string
+}
+
+func (e *Error) Error() string {
+	message := fmt.Sprintf("cvcl-render: %s (%d %s)", e.Message, e.StatusCode, e.Code)
+	for _, detail := range e.Details {
+		message += fmt.Sprintf("; %s: %s", detail.Field, detail.Message)
+	}
+	return message
+}
+
+// RenderOptions are the query parameters of the render endpoints
+type RenderOptions struct {
+	// Format is "pdf" (default) or "docx"
+	Format string
+	// Language selects per-language values of the document
+	Language string
+	// Strict rejects unknown fields; nil uses the server's decode mode
+	Strict *bool
+}
+
+// query returns the options as query parameters
+func (o *RenderOptions) query() url.Values {
+	query := url.Values{}
+	if o == nil {
+		return query
+	}
+	if o.Format != "" {
+		query.Set("format", o.Format)
+	}
+	if o.Language != "" {
+		query.Set("language", o.Language)
+	}
+	if o.Strict != nil {
+		query.Set("strict", strconv.FormatBool(*o.Strict))
+	}
+	return query
+}
+
+// RenderResult describes a rendered file written to the caller's writer
+type RenderResult struct {
+	FileName    string
+	ContentType string
+	// Size is the number of bytes written
+	Size     int64
+	Warnings []string
+	// FitReport is the JSON report of a resume fitted to max_pages, if any
+	FitReport json.RawMessage
+}
+
+// ParseResumeRequest is the request body of /v1/parse-resume
+type ParseResumeRequest struct {
+	// Source is a Typst or LaTeX resume
+	Source string `json:"source,omitempty"`
+	// FilePath is a Typst or LaTeX resume in the server's output directory, relative to it
+	FilePath string `json:"file_path,omitempty"`
+	// SourceFormat is typst (default), latex, moderncv or awesome-cv
+	SourceFormat string `json:"source_format,omitempty"`
+}
+
+// ParseResumeResponse is the response body of /v1/parse-resume
+type ParseResumeResponse struct {
+	// Resume is the parsed document in the form accepted by RenderResume
+	Resume json.RawMessage `json:"resume"`
+	// Class and Unrecognized are set for LaTeX imports
+	Class        string   `json:"class,omitempty"`
+	Unrecognized []string `json:"unrecognized,omitempty"`
+}
+
+// HealthResponse is the response body of /v1/health
+type HealthResponse struct {
+	Status string `json:"status"`
+}
+
+// Health checks that the server is up
+func (c *Client) Health(ctx context.Context) (*HealthResponse, error) {
+	var health HealthResponse
+	if err := c.doJSON(ctx, http.MethodGet, "/v1/health", nil, nil, &health); err != nil {
+		return nil, err
+	}
+	return &health, nil
+}
+
+// RenderCoverLetter renders a cover letter and streams the file to w
+func (c *Client) RenderCoverLetter(ctx context.Context, letter interface{}, opts *RenderOptions, w io.Writer) (*RenderResult, error) {
+	return c.render(ctx, "/v1/render", letter, opts, w)
+}
+
+// RenderResume renders a resume and streams the file to w
+func (c *Client) RenderResume(ctx context.Context, resume interface{}, opts *RenderOptions, w io.Writer) (*RenderResult, error) {
+	return c.render(ctx, "/v1/render-resume", resume, opts, w)
+}
+
+// ParseResume parses a Typst resume or imports a LaTeX one, given as source or as a file in the
+// server's output directory
+func (c *Client) ParseResume(ctx context.Context, req ParseResumeRequest) (*ParseResumeResponse, error) {
+	var parsed ParseResumeResponse
+	if err := c.doJSON(ctx, http.MethodPost, "/v1/parse-resume", nil, req, &parsed); err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+// render posts a document to a render endpoint and copies the file to w
+func (c *Client) render(ctx context.Context, path string, document interface{}, opts *RenderOptions, w io.Writer) (*RenderResult, error) {
+	resp, err := c.do(ctx, http.MethodPost, path, opts.query(), document)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	result := &RenderResult{ContentType: resp.Header.Get("Content-Type")}
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		result.FileName = params["filename"]
+	}
+	if warnings := resp.Header.Get("X-Warnings"); warnings != "" {
+		json.Unmarshal([]byte(warnings), &result.Warnings)
+	}
+	if report := resp.Header.Get("X-Fit-Report"); report != "" {
+		result.FitReport = json.RawMessage(report)
+	}
+	result.Size, err = io.Copy(w, resp.Body)
+	if err != nil {
+		return result, fmt.Errorf("failed to download %s: %w", path, err)
+	}
+	return result, nil
+}
+
+// doJSON sends a request and decodes the JSON response into out
+func (c *Client) doJSON(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	resp, err := c.do(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", path, err)
+	}
+	return nil
+}
+
+// do sends a request, retrying on 429 and 503, and returns the successful response.
+// Error responses are returned as *Error.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body interface{}) (*http.Response, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+	target := c.BaseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	wait := c.RetryWait
+	for attempt := 0; ; attempt++ {
+		var reader io.Reader
+		if payload != nil {
+			reader = bytes.NewReader(payload)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, target, reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		if payload != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if c.APIKey != "" {
+			req.Header.Set("Authorization", "Bearer "+c.APIKey)
+		}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to call %s: %w", path, err)
+		}
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return resp, nil
+		}
+
+		apiErr := readError(resp)
+		// A used up daily quota only resets the next day
+		retryable := (resp.StatusCode == http.StatusTooManyRequests && apiErr.Code != "quota_exceeded") || resp.StatusCode == http.StatusServiceUnavailable
+		if !retryable || attempt >= c.MaxRetries {
+			return nil, apiErr
+		}
+		delay := wait
+		if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			delay = after
+		}
+		if delay > maxRetryWait {
+			delay = maxRetryWait
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		wait *= 2
+	}
+}
+
+// readError reads the error envelope of a failed response
+func readError(resp *http.Response) *Error {
+	defer resp.Body.Close()
+	content, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	apiErr := &Error{StatusCode: resp.StatusCode}
+	var envelope struct {
+		Error struct {
+			Code    string       `json:"code"`
+			Message string       `json:"message"`
+			Details []FieldError `json:"details"`
+		} `json:"error"`
+		Warnings []string `json:"warnings"`
+	}
+	if err := json.Unmarshal(content, &envelope); err == nil && envelope.Error.Message != "" {
+		apiErr.Code = envelope.Error.Code
+		apiErr.Message = envelope.Error.Message
+		apiErr.Details = envelope.Error.Details
+		apiErr.Warnings = envelope.Warnings
+		return apiErr
+	}
+	apiErr.Message = strings.TrimSpace(string(content))
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+	return apiErr
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,720 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"time"
+
+	"cvcl-render/auth"
+	"cvcl-render/compile"
+	"cvcl-render/config"
+	"cvcl-render/draft"
+	"cvcl-render/keywords"
+	"cvcl-render/latex"
+	"cvcl-render/model"
+	"cvcl-render/render"
+	"cvcl-render/server"
+	"cvcl-render/typstparse"
+	"cvcl-render/usage"
+)
+
+func main() {
+	// Subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "import-latex":
+			runImportLaTeX(os.Args[2:])
+			return
+		case "parse-coverletter":
+			runParseCoverLetter(os.Args[2:])
+			return
+		case "translations":
+			runTranslations(os.Args[2:])
+			return
+		case "match-keywords":
+			runMatchKeywords(os.Args[2:])
+			return
+		case "draft-coverletter":
+			runDraftCoverLetter(os.Args[2:])
+			return
+		case "config":
+			runConfig(os.Args[2:])
+			return
+		case "auth":
+			runAuth(os.Args[2:])
+			return
+		}
+	}
+
+	// Define flags. Configurable flags are applied through flagSettings when given,
+	// overriding the configuration file and CVCL_* variables.
+	defaults := config.Default()
+	configPath := flag.String("config", os.Getenv(config.FileEnv), "Path to a YAML or TOML configuration file (defaults to $"+config.FileEnv+")")
+	flag.String("template", defaults.CoverLetterTemplate(), "Path to the Typst template file")
+	flag.String("resume-template", defaults.ResumeTemplate(), "Path to the Typst resume template file")
+	flag.String("templates-dir", "", "Directory holding coverletter.typ.template and resume.typ.template")
+	flag.String("output-dir", defaults.Server.OutputDir, "Output directory for the generated files")
+	flag.String("port", defaults.Server.Port, "Port to listen on for HTTP server")
+	cliMode := flag.Bool("cli", false, "Run in CLI mode instead of HTTP server")
+	jsonFile := flag.String("data", "", "Path to JSON file containing the data (CLI mode only)")
+	jsonString := flag.String("json", "", "JSON string containing the data (CLI mode only)")
+	flag.Bool("skip-pdf", defaults.Server.SkipPDF, "Skip PDF compilation and only output the rendered Typst file")
+	format := flag.String("format", "pdf", "Output format in CLI mode: pdf or docx")
+	flag.String("json-mode", defaults.Server.JSONMode, "Handling of unknown JSON fields: strict (reject) or lenient (warn)")
+	language := flag.String("language", "", "Language for per-language values, headings and dates in CLI mode (defaults to options.language)")
+	flag.String("typst", defaults.Typst.Binary, "Path to the typst executable")
+	flag.String("llm-url", defaults.LLM.URL, "Base URL of an OpenAI-compatible API for /draft-coverletter, e.g. https://api.openai.com/v1 (API key from CVCL_LLM_API_KEY or LLM_API_KEY)")
+	flag.String("llm-model", defaults.LLM.Model, "Model used for drafting cover letters")
+	flag.Duration("llm-timeout", defaults.Timeouts.LLM, "Timeout of a single drafting request")
+
+	flag.Parse()
+
+	// Settings of the flags that can also be configured
+	flagSettings := map[string]string{
+		"template":        "templates.coverletter",
+		"resume-template": "templates.resume",
+		"templates-dir":   "templates.dir",
+		"output-dir":      "server.output_dir",
+		"port":            "server.port",
+		"skip-pdf":        "server.skip_pdf",
+		"json-mode":       "server.json_mode",
+		"typst":           "typst.binary",
+		"llm-url":         "llm.url",
+		"llm-model":       "llm.model",
+		"llm-timeout":     "timeouts.llm",
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	flag.Visit(func(f *flag.Flag) {
+		if key, ok := flagSettings[f.Name]; ok && err == nil {
+			if err = cfg.Set(key, f.Value.String()); err != nil {
+				err = fmt.Errorf("-%s: %w", f.Name, err)
+			}
+		}
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	decodeMode, err := cfg.DecodeMode()
+	if err != nil {
+		log.Fatal(err)
+	}
+	compile.Configure(compile.Settings{
+		Binary:        cfg.Typst.Binary,
+		FontPaths:     cfg.Typst.FontPaths,
+		PackagePath:   cfg.Typst.PackagePath,
+		Timeout:       cfg.Timeouts.Compile,
+		MaxConcurrent: cfg.Limits.MaxCompiles,
+	})
+
+	// CLI mode
+	if *cliMode {
+		runCLI(cfg.CoverLetterTemplate(), cfg.Server.OutputDir, *jsonFile, *jsonString, *format, *language, cfg.Server.SkipPDF, decodeMode)
+	} else {
+		// HTTP server mode (default)
+		generator := newGenerator(cfg.LLM.URL, cfg.LLM.APIKey, cfg.LLM.Model, cfg.Timeouts.LLM)
+		runHTTPServer(cfg, decodeMode, generator)
+	}
+}
+
+// newGenerator returns the OpenAI-compatible generator for baseURL, or nil to use only the template drafts.
+// An empty apiKey is read from LLM_API_KEY.
+func newGenerator(baseURL, apiKey, modelName string, timeout time.Duration) draft.Generator {
+	if baseURL == "" {
+		return nil
+	}
+	if apiKey == "" {
+		apiKey = os.Getenv("LLM_API_KEY")
+	}
+	return draft.NewOpenAIGenerator(baseURL, apiKey, modelName, timeout)
+}
+
+// newAuthenticator returns an authenticator for the configured API keys and JWKS files,
+// or nil when neither is set
+func newAuthenticator(cfg config.Auth) (auth.Authenticator, error) {
+	var chain auth.Chain
+	if cfg.APIKeysFile != "" {
+		keys, err := auth.LoadAPIKeys(cfg.APIKeysFile)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, keys)
+	}
+	if cfg.JWKSFile != "" {
+		jwks, err := auth.LoadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		jwks.Issuer, jwks.Audience = cfg.JWTIssuer, cfg.JWTAudience
+		chain = append(chain, jwks)
+	}
+	if len(chain) == 0 {
+		return nil, nil
+	}
+	return chain, nil
+}
+
+// runCLI runs the program in CLI mode
+func runCLI(templatePath, outputDir, jsonFile, jsonString, format, language string, skipPDF bool, decodeMode model.DecodeMode) {
+	var data model.CoverLetterData
+
+	// Get template content
+	templateContent, err := render.CoverLetterTemplate(templatePath)
+	if err != nil {
+		log.Fatalf("Failed to read template: %v", err)
+	}
+
+	// Read data based on input
+	var jsonContent []byte
+	if jsonFile != "" {
+		jsonContent, err = os.ReadFile(jsonFile)
+		if err != nil {
+			log.Fatalf("Failed to read JSON file: %v", err)
+		}
+	} else if jsonString != "" {
+		jsonContent = []byte(jsonString)
+	} else {
+		log.Fatal("Please provide either -data or -json flag with the input data")
+	}
+
+	// Parse and validate data before rendering anything
+	warnings, err := model.DecodeLocalizedJSON(bytes.NewReader(jsonContent), &data, decodeMode, language)
+	for _, warning := range warnings {
+		log.Printf("Warning: %s", warning)
+	}
+	if err == nil {
+		err = data.Validate()
+	}
+	var validationErrs model.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		for _, fieldErr := range validationErrs {
+			log.Printf("Invalid field %s: %s", fieldErr.Field, fieldErr.Message)
+		}
+		log.Fatalf("Invalid cover letter data: %d problem(s) found", len(validationErrs))
+	}
+	if err != nil {
+		log.Fatalf("Error parsing JSON data: %v", err)
+	}
+
+	switch format {
+	case "docx":
+		docxFilePath, err := render.WriteCoverLetterDOCX(data, outputDir)
+		if err != nil {
+			log.Fatalf("Error rendering DOCX: %v", err)
+		}
+		fmt.Printf("DOCX file saved to: %s\n", docxFilePath)
+		return
+	case "pdf":
+	default:
+		log.Fatalf("Unsupported format: %s", format)
+	}
+
+	// Render and compile
+	typstFilePath, pdfFilePath, err := render.CompileCoverLetter(templateContent, data, outputDir, skipPDF)
+	if err != nil {
+		log.Fatalf("Error rendering/compiling: %v", err)
+	}
+
+	fmt.Printf("Rendered Typst file saved to: %s\n", typstFilePath)
+
+	// Print PDF path if compiled
+	if !skipPDF {
+		fmt.Printf("PDF compiled successfully: %s\n", pdfFilePath)
+	}
+}
+
+// runImportLaTeX runs the import-latex subcommand, converting a LaTeX CV into resume JSON
+func runImportLaTeX(args []string) {
+	flags := flag.NewFlagSet("import-latex", flag.ExitOnError)
+	class := flags.String("class", "", "LaTeX CV class: moderncv or awesome-cv (detected from \\documentclass if empty)")
+	output := flags.String("output", "", "Path to write the resume JSON (stdout if empty)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s import-latex [flags] <file.tex>\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	content, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		log.Fatalf("Failed to read LaTeX file: %v", err)
+	}
+
+	result, err := latex.ImportResume(string(content), *class)
+	if err != nil {
+		log.Fatalf("Failed to import resume: %v", err)
+	}
+	for _, name := range result.Unrecognized {
+		log.Printf("Warning: unrecognized %s", name)
+	}
+
+	jsonData, err := json.MarshalIndent(result.Resume, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to encode resume: %v", err)
+	}
+	if *output == "" {
+		fmt.Println(string(jsonData))
+		return
+	}
+	err = os.WriteFile(*output, jsonData, 0644)
+	if err != nil {
+		log.Fatalf("Failed to write resume JSON: %v", err)
+	}
+	fmt.Printf("Resume JSON saved to: %s\n", *output)
+}
+
+// runParseCoverLetter runs the parse-coverletter subcommand, converting a Typst cover letter into JSON
+func runParseCoverLetter(args []string) {
+	flags := flag.NewFlagSet("parse-coverletter", flag.ExitOnError)
+	output := flags.String("output", "", "Path to write the cover letter JSON (stdout if empty)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s parse-coverletter [flags] <file.typ>\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	content, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		log.Fatalf("Failed to read Typst file: %v", err)
+	}
+
+	coverLetter, err := typstparse.ParseCoverLetter(string(content))
+	if err != nil {
+		log.Fatalf("Failed to parse cover letter: %v", err)
+	}
+
+	jsonData, err := json.MarshalIndent(coverLetter, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to encode cover letter: %v", err)
+	}
+	if *output == "" {
+		fmt.Println(string(jsonData))
+		return
+	}
+	err = os.WriteFile(*output, jsonData, 0644)
+	if err != nil {
+		log.Fatalf("Failed to write cover letter JSON: %v", err)
+	}
+	fmt.Printf("Cover letter JSON saved to: %s\n", *output)
+}
+
+// runTranslations runs the translations subcommand, listing per-language fields that lack a translation
+func runTranslations(args []string) {
+	flags := flag.NewFlagSet("translations", flag.ExitOnError)
+	document := flags.String("document", "resume", "Document type: resume or coverletter")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s translations [flags] <file.json>\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	var typ reflect.Type
+	switch *document {
+	case "resume":
+		typ = reflect.TypeOf(model.ResumeData{})
+	case "coverletter":
+		typ = reflect.TypeOf(model.CoverLetterData{})
+	default:
+		log.Fatalf("Unsupported document type: %s", *document)
+	}
+
+	content, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		log.Fatalf("Failed to read JSON file: %v", err)
+	}
+
+	report, err := model.TranslationReport(content, typ)
+	if err != nil {
+		log.Fatalf("Failed to read per-language values: %v", err)
+	}
+
+	jsonData, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to encode report: %v", err)
+	}
+	fmt.Println(string(jsonData))
+}
+
+// runMatchKeywords runs the match-keywords subcommand, printing the keyword match report as JSON
+func runMatchKeywords(args []string) {
+	flags := flag.NewFlagSet("match-keywords", flag.ExitOnError)
+	jobFile := flags.String("job", "", "Path to the job description text")
+	resumeFile := flags.String("resume", "", "Path to the resume JSON")
+	coverLetterFile := flags.String("coverletter", "", "Path to the cover letter JSON")
+	language := flags.String("language", "", "Language for per-language values")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s match-keywords -job <job.txt> [-resume <resume.json>] [-coverletter <coverletter.json>]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if *jobFile == "" || (*resumeFile == "" && *coverLetterFile == "") {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	jobDescription, err := os.ReadFile(*jobFile)
+	if err != nil {
+		log.Fatalf("Failed to read job description: %v", err)
+	}
+
+	req := model.KeywordMatchRequest{JobDescription: string(jobDescription)}
+	decodeFile := func(path string, v interface{}) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatalf("Failed to read JSON file: %v", err)
+		}
+		warnings, err := model.DecodeLocalizedJSON(bytes.NewReader(content), v, model.DecodeLenient, *language)
+		for _, warning := range warnings {
+			log.Printf("Warning: %s: %s", path, warning)
+		}
+		if err != nil {
+			log.Fatalf("Error parsing %s: %v", path, err)
+		}
+	}
+	if *resumeFile != "" {
+		req.Resume = &model.ResumeData{}
+		decodeFile(*resumeFile, req.Resume)
+	}
+	if *coverLetterFile != "" {
+		req.CoverLetter = &model.CoverLetterData{}
+		decodeFile(*coverLetterFile, req.CoverLetter)
+	}
+
+	jsonData, err := json.MarshalIndent(keywords.Compare(req.JobDescription, req.Resume, req.CoverLetter), "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to encode report: %v", err)
+	}
+	fmt.Println(string(jsonData))
+}
+
+// runDraftCoverLetter runs the draft-coverletter subcommand, printing the drafted cover letter JSON
+func runDraftCoverLetter(args []string) {
+	flags := flag.NewFlagSet("draft-coverletter", flag.ExitOnError)
+	jobFile := flags.String("job", "", "Path to the job description text")
+	resumeFile := flags.String("resume", "", "Path to the resume JSON")
+	tone := flags.String("tone", "", "Tone of the letter: formal, friendly, enthusiastic or concise")
+	company := flags.String("company", "", "Name of the company")
+	position := flags.String("position", "", "Position applied for (defaults to the first resume position)")
+	addressee := flags.String("addressee", "", "Addressee of the letter")
+	language := flags.String("language", "", "Language of the letter and of per-language values")
+	defaults := config.Default()
+	configPath := flags.String("config", os.Getenv(config.FileEnv), "Path to a YAML or TOML configuration file (defaults to $"+config.FileEnv+")")
+	flags.String("llm-url", defaults.LLM.URL, "Base URL of an OpenAI-compatible API (API key from CVCL_LLM_API_KEY or LLM_API_KEY); template drafts only if empty")
+	flags.String("llm-model", defaults.LLM.Model, "Model used for drafting")
+	flags.Duration("llm-timeout", defaults.Timeouts.LLM, "Timeout of the drafting request")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s draft-coverletter -job <job.txt> -resume <resume.json> [-tone formal] [-company name]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if *jobFile == "" || *resumeFile == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	// The LLM flags override the configuration file and CVCL_* variables when given
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	flagSettings := map[string]string{"llm-url": "llm.url", "llm-model": "llm.model", "llm-timeout": "timeouts.llm"}
+	flags.Visit(func(f *flag.Flag) {
+		if key, ok := flagSettings[f.Name]; ok && err == nil {
+			if err = cfg.Set(key, f.Value.String()); err != nil {
+				err = fmt.Errorf("-%s: %w", f.Name, err)
+			}
+		}
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	jobDescription, err := os.ReadFile(*jobFile)
+	if err != nil {
+		log.Fatalf("Failed to read job description: %v", err)
+	}
+	content, err := os.ReadFile(*resumeFile)
+	if err != nil {
+		log.Fatalf("Failed to read JSON file: %v", err)
+	}
+
+	req := model.DraftRequest{
+		JobDescription: string(jobDescription),
+		Options:        model.DraftOptions{Tone: *tone, Company: *company, Position: *position, Addressee: *addressee, Language: *language},
+	}
+	warnings, err := model.DecodeLocalizedJSON(bytes.NewReader(content), &req.Resume, model.DecodeLenient, *language)
+	for _, warning := range warnings {
+		log.Printf("Warning: %s", warning)
+	}
+	if err == nil {
+		err = req.Validate()
+	}
+	var validationErrs model.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		for _, fieldErr := range validationErrs {
+			log.Printf("Invalid field %s: %s", fieldErr.Field, fieldErr.Message)
+		}
+		log.Fatalf("Invalid draft request: %d problem(s) found", len(validationErrs))
+	}
+	if err != nil {
+		log.Fatalf("Error parsing %s: %v", *resumeFile, err)
+	}
+
+	letter, generatorName, warnings, err := draft.CoverLetter(context.Background(), newGenerator(cfg.LLM.URL, cfg.LLM.APIKey, cfg.LLM.Model, cfg.Timeouts.LLM), req)
+	for _, warning := range warnings {
+		log.Printf("Warning: %s", warning)
+	}
+	if err != nil {
+		log.Fatalf("Drafting failed: %v", err)
+	}
+	log.Printf("Drafted with the %s generator", generatorName)
+
+	jsonData, err := json.MarshalIndent(letter, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to encode cover letter: %v", err)
+	}
+	fmt.Println(string(jsonData))
+}
+
+// runHTTPServer runs the program as an HTTP server
+func runHTTPServer(cfg config.Config, decodeMode model.DecodeMode, generator draft.Generator) {
+	// Refuse to start with the file, environment and flags merged into an invalid configuration
+	var validationErrs model.ValidationErrors
+	if err := cfg.Validate(); errors.As(err, &validationErrs) {
+		for _, fieldErr := range validationErrs {
+			log.Printf("Invalid setting %s: %s", fieldErr.Field, fieldErr.Message)
+		}
+		log.Fatalf("Invalid configuration: %d problem(s) found", len(validationErrs))
+	}
+
+	// Create output directory if it doesn't exist
+	err := os.MkdirAll(cfg.Server.OutputDir, 0755)
+	if err != nil {
+		log.Fatalf("Failed to create output directory: %v", err)
+	}
+
+	templatePath, resumeTemplatePath, outputDir, port := cfg.CoverLetterTemplate(), cfg.ResumeTemplate(), cfg.Server.OutputDir, cfg.Server.Port
+	// Readiness checks need typst only when compiling PDFs
+	health := &server.Health{TTL: cfg.Health.CacheTTL, Timeout: cfg.Health.Timeout}
+	if !cfg.Server.SkipPDF {
+		health.Checks = server.TypstChecks(cfg.Health.Packages, cfg.Health.Fonts, cfg.Health.Canary)
+	}
+
+	authenticator, err := newAuthenticator(cfg.Auth)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var auditLog io.Writer = os.Stderr
+	if cfg.Auth.AuditLog != "" {
+		file, err := os.OpenFile(cfg.Auth.AuditLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Fatalf("Failed to open audit log: %v", err)
+		}
+		defer file.Close()
+		auditLog = file
+	}
+
+	var limiter *usage.Limiter
+	if cfg.Limits.RequestsPerMinute > 0 {
+		limiter = usage.NewLimiter(cfg.Limits.RequestsPerMinute, cfg.Limits.Burst)
+	}
+	var quotas *usage.Quotas
+	if cfg.Limits.DailyRenders > 0 {
+		quotas, err = usage.OpenQuotas(filepath.Join(cfg.Storage.Dir, "usage.json"), cfg.Limits.DailyRenders)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	addr := ":" + port
+	srv, err := server.New(addr, server.Config{
+		TemplatePath:       templatePath,
+		ResumeTemplatePath: resumeTemplatePath,
+		OutputDir:          outputDir,
+		SkipPDF:            cfg.Server.SkipPDF,
+		DecodeMode:         decodeMode,
+		Generator:          generator,
+		MaxBodyBytes:       cfg.Limits.MaxBodyBytes,
+		ReadTimeout:        cfg.Timeouts.Read,
+		WriteTimeout:       cfg.Timeouts.Write,
+		IdleTimeout:        cfg.Timeouts.Idle,
+		Health:             health,
+		Authenticator:      authenticator,
+		AuditLog:           auditLog,
+		RateLimiter:        limiter,
+		Quotas:             quotas,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Start server
+	log.Printf("Starting HTTP server on http://localhost:%s", port)
+	log.Printf("Endpoints:")
+	log.Printf("  POST /render - Render cover letter from JSON (?format=docx for Word)")
+	log.Printf("  POST /render-resume - Render resume from JSON (?format=docx for Word)")
+	log.Printf("  POST /parse-resume - Parse resume from Typst or LaTeX source or a file in the output directory (source_format=typst|latex|moderncv|awesome-cv)")
+	log.Printf("  POST /parse-coverletter - Parse cover letter from Typst source or a file in the output directory")
+	log.Printf("  POST /preview - Render page images (?document=coverletter|resume&format=png|svg&ppi=&page=)")
+	log.Printf("  POST /match-keywords - Compare a job description with a resume and cover letter")
+	log.Printf("  POST /draft-coverletter - Draft cover letter JSON from a resume and a job description")
+	log.Printf("  POST /render-application - Render cover letter and resume with a shared author (?format=zip|multipart&merge=true)")
+	log.Printf("  GET /schema/{coverletter,resume}.json - JSON Schema of the input documents")
+	log.Printf("  GET /health - Health check")
+	log.Printf("  GET /health/live, /health/ready - Liveness and readiness probes; ready checks typst, installed packages and fonts and fails while draining")
+	log.Printf("  GET /openapi.json - OpenAPI 3.1 description of the API")
+	log.Printf("  GET /docs - Interactive API documentation")
+	log.Printf("  /v1/... - The endpoints above with typed JSON responses and an {error: {code, message, details}} envelope")
+	log.Printf("  Template: %s", templatePath)
+	log.Printf("  Resume Template: %s", resumeTemplatePath)
+	log.Printf("  Output directory: %s", outputDir)
+	log.Printf("  Typst: %s (at most %d compilations at once, timeout %s)", cfg.Typst.Binary, cfg.Limits.MaxCompiles, cfg.Timeouts.Compile)
+	log.Printf("  JSON decode mode: %s (override per request with ?strict=true|false)", decodeMode)
+	log.Printf("  Per-language values: select with ?language= on /render, /render-resume and /preview")
+	if authenticator != nil {
+		log.Printf("  Authentication: API keys or JWTs required on all endpoints but /health (scopes render, parse, admin)")
+	} else {
+		log.Printf("  Authentication: disabled (set auth.api_keys_file or auth.jwks_file to enable)")
+	}
+	if limiter != nil {
+		log.Printf("  Rate limit: %d requests a minute per client in bursts of %d on render and parse endpoints", limiter.PerMinute, limiter.Burst)
+	}
+	if quotas != nil {
+		log.Printf("  Render quota: %d a day per client, counted in %s", quotas.Limit, filepath.Join(cfg.Storage.Dir, "usage.json"))
+	}
+	if authenticator != nil {
+		log.Printf("  GET /v1/admin/usage, POST /v1/admin/usage/reset - Inspect and reset client usage (admin scope)")
+	}
+	if generator != nil {
+		log.Printf("  Cover letter drafts: %s generator, falling back to templates", generator.Name())
+	} else {
+		log.Printf("  Cover letter drafts: templates only (set -llm-url to use a language model)")
+	}
+
+	// Drain on SIGTERM or SIGINT: in-flight requests and typst processes may finish
+	served := make(chan error, 1)
+	go func() { served <- srv.ListenAndServe() }()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	select {
+	case err := <-served:
+		log.Fatalf("Server error: %v", err)
+	case sig := <-signals:
+		// Only orchestrators sending SIGTERM need time to stop routing requests here
+		drainDelay := cfg.Timeouts.DrainDelay
+		if sig == os.Interrupt {
+			drainDelay = 0
+		}
+		log.Printf("Received %s, draining for %s and waiting up to %s for requests to finish", sig, drainDelay, cfg.Timeouts.Shutdown)
+		ctx, cancel := context.WithTimeout(context.Background(), drainDelay+cfg.Timeouts.Shutdown)
+		defer cancel()
+		if err := srv.Shutdown(ctx, drainDelay); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Server stopped")
+	}
+}
+
+// runConfig checks or prints the effective configuration: defaults, the configuration file and CVCL_* variables
+func runConfig(args []string) {
+	flags := flag.NewFlagSet("config", flag.ExitOnError)
+	configPath := flags.String("config", os.Getenv(config.FileEnv), "Path to a YAML or TOML configuration file (defaults to $"+config.FileEnv+")")
+	format := flags.String("format", "yaml", "Output format of dump: yaml or toml")
+	showSecrets := flags.Bool("show-secrets", false, "Print secrets such as llm.api_key instead of masking them")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s config [flags] validate|dump\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	switch flags.Arg(0) {
+	case "validate":
+		var validationErrs model.ValidationErrors
+		if err := cfg.Validate(); errors.As(err, &validationErrs) {
+			for _, fieldErr := range validationErrs {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", fieldErr.Field, fieldErr.Message)
+			}
+			os.Exit(1)
+		}
+		fmt.Println("Configuration is valid")
+	case "dump":
+		if err := cfg.Dump(os.Stdout, *format, *showSecrets); err != nil {
+			log.Fatal(err)
+		}
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+}
+
+// runAuth runs the auth subcommand, which generates API keys
+func runAuth(args []string) {
+	flags := flag.NewFlagSet("auth", flag.ExitOnError)
+	id := flags.String("id", "", "ID of the new key, e.g. ci-bot")
+	scopes := flags.String("scopes", auth.ScopeRender, "Comma-separated scopes of the new key: "+strings.Join(auth.Scopes, ", "))
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s auth new-key -id ID [-scopes render,parse]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	if len(args) == 0 || args[0] != "new-key" {
+		flags.Usage()
+		os.Exit(2)
+	}
+	flags.Parse(args[1:])
+	if *id == "" || flags.NArg() != 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	key, err := auth.NewKey()
+	if err != nil {
+		log.Fatal(err)
+	}
+	line := fmt.Sprintf("%s %s %s", *id, auth.HashKey(key), *scopes)
+	// Reject unknown scopes before printing a line that would not load
+	if _, err := auth.ParseAPIKeys(line); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("API key (shown once, give it to the client):\n  %s\n", key)
+	fmt.Printf("Line for auth.api_keys_file:\n  %s\n", line)
+}
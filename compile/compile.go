@@ -0,0 +1,369 @@
+// Package compile runs the typst CLI on rendered Typst sources.
+package compile
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OutputFormat is an output format supported by typst compile
+type OutputFormat string
+
+const (
+	FormatPDF OutputFormat = "pdf"
+	FormatPNG OutputFormat = "png"
+	FormatSVG OutputFormat = "svg"
+)
+
+// TypstContentType is the MIME type of rendered Typst sources
+const TypstContentType = "text/plain; charset=utf-8"
+
+// DefaultPPI is the resolution used for PNG output when none is given
+const DefaultPPI = 144
+
+// ContentType returns the MIME type of a single output page
+func (f OutputFormat) ContentType() string {
+	switch f {
+	case FormatPNG:
+		return "image/png"
+	case FormatSVG:
+		return "image/svg+xml"
+	default:
+		return "application/pdf"
+	}
+}
+
+// ParseOutputFormat validates a format name, defaulting to PDF when empty
+func ParseOutputFormat(name string) (OutputFormat, error) {
+	switch OutputFormat(strings.ToLower(name)) {
+	case "", FormatPDF:
+		return FormatPDF, nil
+	case FormatPNG:
+		return FormatPNG, nil
+	case FormatSVG:
+		return FormatSVG, nil
+	}
+	return "", fmt.Errorf("unsupported output format: %s", name)
+}
+
+// Options controls how a Typst document is compiled
+type Options struct {
+	Format OutputFormat
+	// PPI is the resolution for PNG output, ignored for other formats
+	PPI int
+	// NoCache always runs typst, for example to check that compilation works
+	NoCache bool
+}
+
+// normalize fills in defaults so that equivalent options share a cache key
+func (o Options) normalize() Options {
+	if o.Format == "" {
+		o.Format = FormatPDF
+	}
+	if o.Format != FormatPNG {
+		o.PPI = 0
+	} else if o.PPI <= 0 {
+		o.PPI = DefaultPPI
+	}
+	return o
+}
+
+// Result holds the output of a compilation.
+// PDF output is a single document in Pages[0]; PNG and SVG output has one entry per page.
+type Result struct {
+	Format    OutputFormat
+	Pages     [][]byte
+	PageCount int
+}
+
+// Settings controls how the typst CLI is run
+type Settings struct {
+	// Binary is the typst executable, "typst" by default
+	Binary      string
+	FontPaths   []string
+	PackagePath string
+	// Timeout bounds a single compilation; zero means no timeout
+	Timeout time.Duration
+	// MaxConcurrent limits the typst processes running at once; zero means no limit
+	MaxConcurrent int
+}
+
+var (
+	settingsMu sync.RWMutex
+	settings   = Settings{Binary: "typst"}
+	// slots holds a token for every running typst process when MaxConcurrent is set
+	slots chan struct{}
+
+	runningMu sync.Mutex
+	running   int
+)
+
+// Configure sets how later compilations run typst
+func Configure(s Settings) {
+	if s.Binary == "" {
+		s.Binary = "typst"
+	}
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	settings = s
+	slots = nil
+	if s.MaxConcurrent > 0 {
+		slots = make(chan struct{}, s.MaxConcurrent)
+	}
+}
+
+// commandArgs returns the arguments of typst compile before the input and output paths
+func (s Settings) commandArgs() []string {
+	args := []string{"compile"}
+	for _, path := range s.FontPaths {
+		args = append(args, "--font-path", path)
+	}
+	if s.PackagePath != "" {
+		args = append(args, "--package-path", s.PackagePath)
+	}
+	return args
+}
+
+// Running returns the number of typst processes that are running or waiting for a slot
+func Running() int {
+	runningMu.Lock()
+	defer runningMu.Unlock()
+	return running
+}
+
+// Wait blocks until no typst process is running, or returns an error when ctx ends first
+func Wait(ctx context.Context) error {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		n := Running()
+		if n == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d typst processes still running: %w", n, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// run executes typst compile with the configured settings followed by args. It stops when ctx
+// ends, including while waiting for a free slot.
+func run(ctx context.Context, args ...string) error {
+	runningMu.Lock()
+	running++
+	runningMu.Unlock()
+	defer func() {
+		runningMu.Lock()
+		running--
+		runningMu.Unlock()
+	}()
+
+	settingsMu.RLock()
+	s, sem := settings, slots
+	settingsMu.RUnlock()
+	if sem != nil {
+		select {
+		case sem <- struct{}{}:
+			defer func() { <-sem }()
+		case <-ctx.Done():
+			return fmt.Errorf("failed to compile Typst file: %w", ctx.Err())
+		}
+	}
+
+	runCtx := ctx
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(runCtx, s.Binary, append(s.commandArgs(), args...)...)
+	stdoutStderr, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	} else if runCtx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", s.Timeout)
+	}
+	if err != nil {
+		// Keep the typst diagnostics for the caller
+		if output := strings.TrimSpace(string(stdoutStderr)); output != "" {
+			return fmt.Errorf("failed to compile Typst file: %w: %s", err, output)
+		}
+		return fmt.Errorf("failed to compile Typst file: %w", err)
+	}
+	return nil
+}
+
+// File compiles a Typst file into outputDir and returns the produced files in page order
+func File(typstFilePath string, outputDir string, baseName string, opts Options) ([]string, error) {
+	return compileFile(context.Background(), typstFilePath, outputDir, baseName, opts)
+}
+
+// compileFile is File stopping when ctx ends
+func compileFile(ctx context.Context, typstFilePath string, outputDir string, baseName string, opts Options) ([]string, error) {
+	opts = opts.normalize()
+
+	args := []string{"--format", string(opts.Format)}
+	if opts.Format == FormatPNG {
+		args = append(args, "--ppi", strconv.Itoa(opts.PPI))
+	}
+
+	var outputPath string
+	if opts.Format == FormatPDF {
+		outputPath = filepath.Join(outputDir, baseName+".pdf")
+	} else {
+		outputPath = filepath.Join(outputDir, fmt.Sprintf("%s-{p}.%s", baseName, opts.Format))
+	}
+	args = append(args, typstFilePath, outputPath)
+
+	if err := run(ctx, args...); err != nil {
+		return nil, err
+	}
+
+	if opts.Format == FormatPDF {
+		return []string{outputPath}, nil
+	}
+	return collectPageFiles(outputDir, baseName, string(opts.Format))
+}
+
+// ToPDF compiles a Typst file to a PDF file
+func ToPDF(typstFilePath string, pdfOutputPath string) error {
+	return run(context.Background(), typstFilePath, pdfOutputPath)
+}
+
+// collectPageFiles finds the per-page files written by typst and sorts them by page number
+func collectPageFiles(outputDir, baseName, extension string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(outputDir, baseName+"-*."+extension))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list compiled pages: %w", err)
+	}
+
+	pageNumber := func(path string) int {
+		name := strings.TrimSuffix(filepath.Base(path), "."+extension)
+		n, _ := strconv.Atoi(strings.TrimPrefix(name, baseName+"-"))
+		return n
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return pageNumber(matches[i]) < pageNumber(matches[j])
+	})
+
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("typst produced no %s pages", extension)
+	}
+	return matches, nil
+}
+
+// Source compiles rendered Typst source, reusing cached output for identical input
+func Source(source string, opts Options) (*Result, error) {
+	return SourceContext(context.Background(), source, opts)
+}
+
+// SourceContext is Source stopping the compilation when ctx ends
+func SourceContext(ctx context.Context, source string, opts Options) (*Result, error) {
+	opts = opts.normalize()
+	settingsMu.RLock()
+	key := compileCacheKey(source, opts, settings)
+	settingsMu.RUnlock()
+	if result, ok := defaultCompileCache.get(key); ok && !opts.NoCache {
+		return result, nil
+	}
+
+	workDir, err := os.MkdirTemp("", "cvcl-render-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create working directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	typstFilePath := filepath.Join(workDir, "document.typ")
+	err = os.WriteFile(typstFilePath, []byte(source), 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write Typst file: %w", err)
+	}
+
+	files, err := compileFile(ctx, typstFilePath, workDir, "document", opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Format: opts.Format}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read compiled output: %w", err)
+		}
+		result.Pages = append(result.Pages, content)
+	}
+	if opts.Format == FormatPDF {
+		if result.PageCount, err = CountPDFPages(result.Pages[0]); err != nil {
+			return nil, fmt.Errorf("failed to count PDF pages: %w", err)
+		}
+	} else {
+		result.PageCount = len(result.Pages)
+	}
+
+	if !opts.NoCache {
+		defaultCompileCache.put(key, result)
+	}
+	return result, nil
+}
+
+// compileCacheKey identifies a compilation by its source, options and the settings that change
+// its output, so that output compiled before Configure changed fonts or packages is not reused
+func compileCacheKey(source string, opts Options, s Settings) string {
+	hash := sha256.New()
+	hash.Write([]byte(source))
+	// NUL cannot occur in paths, so the fields cannot run into each other
+	fmt.Fprintf(hash, "\x00%s\x00%s\x00%s", s.Binary, strings.Join(s.FontPaths, "\x00"), s.PackagePath)
+	return fmt.Sprintf("%s:%s:%d", hex.EncodeToString(hash.Sum(nil)), opts.Format, opts.PPI)
+}
+
+// compileCache is a bounded in-memory cache of compilation results, evicting the oldest entry first
+type compileCache struct {
+	mu      sync.Mutex
+	max     int
+	order   []string
+	entries map[string]*Result
+}
+
+// defaultCompileCache is shared by PDF rendering and previews
+var defaultCompileCache = newCompileCache(64)
+
+func newCompileCache(max int) *compileCache {
+	return &compileCache{
+		max:     max,
+		entries: make(map[string]*Result),
+	}
+}
+
+func (c *compileCache) get(key string) (*Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result, ok := c.entries[key]
+	return result, ok
+}
+
+func (c *compileCache) put(key string, result *Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; ok {
+		c.entries[key] = result
+		return
+	}
+	for len(c.order) >= c.max && len(c.order) > 0 {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.order = append(c.order, key)
+	c.entries[key] = result
+}
@@ -0,0 +1,181 @@
+package compile
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCountPDFPages(t *testing.T) {
+	plain := "%PDF-1.7\n1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n" +
+		"2 0 obj << /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 >> endobj\n" +
+		"3 0 obj <</Type/Page/Parent 2 0 R>> endobj\n" +
+		"4 0 obj <</Type/Page/Parent 2 0 R>> endobj\n" +
+		"trailer << /Size 5 /Root 1 0 R >>\n%%EOF\n/Type /Page"
+	if n, err := CountPDFPages([]byte(plain)); n != 2 || err != nil {
+		t.Errorf("Expected 2 pages, got %d, %v", n, err)
+	}
+
+	// The catalog and page tree inside a compressed object stream, as written by many PDF tools
+	objects := "1 0 2 48 << /Type /Catalog /Pages 2 0 R >>\n<< /Type /Pages /Kids [3 0 R 4 0 R 5 0 R] /Count 3 >>"
+	var compressed bytes.Buffer
+	w := zlib.NewWriter(&compressed)
+	w.Write([]byte(objects))
+	w.Close()
+	streamed := fmt.Sprintf("%%PDF-1.7\n6 0 obj << /Type /ObjStm /N 2 /First 8 /Filter /FlateDecode /Length %d >> stream\n%sendstream endobj\n"+
+		"7 0 obj << /Type /XRef /Root 1 0 R >> stream\nendstream endobj\n", compressed.Len(), compressed.String())
+	if n, err := CountPDFPages([]byte(streamed)); n != 3 || err != nil {
+		t.Errorf("Expected 3 pages from an object stream, got %d, %v", n, err)
+	}
+
+	if _, err := CountPDFPages([]byte("3 0 obj <</Type/Page>> endobj")); err == nil {
+		t.Error("Expected an error without a document catalog")
+	}
+}
+
+func TestCollectPageFilesOrdersNumerically(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"document-10.png", "document-2.png", "document-1.png", "other-3.png"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := collectPageFiles(dir, "document", "png")
+	if err != nil {
+		t.Fatalf("Failed to collect pages: %v", err)
+	}
+	expected := []string{"document-1.png", "document-2.png", "document-10.png"}
+	if len(files) != len(expected) {
+		t.Fatalf("Expected %d files, got %v", len(expected), files)
+	}
+	for i, name := range expected {
+		if filepath.Base(files[i]) != name {
+			t.Errorf("Page %d: expected %s, got %s", i+1, name, filepath.Base(files[i]))
+		}
+	}
+}
+
+func TestCompileCacheEvictsOldest(t *testing.T) {
+	cache := newCompileCache(2)
+	cache.put("a", &Result{PageCount: 1})
+	cache.put("b", &Result{PageCount: 2})
+	cache.put("c", &Result{PageCount: 3})
+
+	if _, ok := cache.get("a"); ok {
+		t.Error("Expected oldest entry to be evicted")
+	}
+	if result, ok := cache.get("c"); !ok || result.PageCount != 3 {
+		t.Error("Expected newest entry to be cached")
+	}
+}
+
+func TestCompileCacheKeyNormalizesOptions(t *testing.T) {
+	source := "#set page(width: 10pt)"
+	s := Settings{Binary: "typst"}
+	pdf := compileCacheKey(source, Options{PPI: 300}.normalize(), s)
+	if pdf != compileCacheKey(source, Options{Format: FormatPDF}.normalize(), s) {
+		t.Error("Expected PPI to be ignored for PDF output")
+	}
+	png := compileCacheKey(source, Options{Format: FormatPNG}.normalize(), s)
+	if png != compileCacheKey(source, Options{Format: FormatPNG, PPI: DefaultPPI}.normalize(), s) {
+		t.Error("Expected default PPI to be applied for PNG output")
+	}
+	if png == pdf {
+		t.Error("Expected different formats to use different cache keys")
+	}
+	if pdf == compileCacheKey(source, Options{}.normalize(), Settings{Binary: "typst", FontPaths: []string{"fonts"}}) {
+		t.Error("Expected font paths to change the cache key")
+	}
+}
+
+func TestConfigureRunsTypstWithSettings(t *testing.T) {
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	binary := filepath.Join(dir, "fake-typst")
+	script := "#!/bin/sh\necho \"$@\" > " + argsFile + "\ncase \"$*\" in *slow*) exec sleep 5;; esac\nexit 0\n"
+	if err := os.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	Configure(Settings{Binary: binary, FontPaths: []string{"/fonts"}, PackagePath: "/packages", Timeout: 200 * time.Millisecond, MaxConcurrent: 1})
+	t.Cleanup(func() { Configure(Settings{}) })
+
+	if err := ToPDF("letter.typ", "letter.pdf"); err != nil {
+		t.Fatalf("ToPDF failed: %v", err)
+	}
+	args, _ := os.ReadFile(argsFile)
+	if got := strings.TrimSpace(string(args)); got != "compile --font-path /fonts --package-path /packages letter.typ letter.pdf" {
+		t.Errorf("Unexpected typst arguments %q", got)
+	}
+
+	Configure(Settings{Binary: binary, Timeout: 200 * time.Millisecond})
+	if err := ToPDF("slow.typ", "slow.pdf"); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Expected a timeout, got %v", err)
+	}
+}
+
+func TestCompileErrorsKeepDiagnostics(t *testing.T) {
+	binary := filepath.Join(t.TempDir(), "fake-typst")
+	script := "#!/bin/sh\necho 'error: unknown variable: foo' >&2\nexit 1\n"
+	if err := os.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	Configure(Settings{Binary: binary})
+	t.Cleanup(func() { Configure(Settings{}) })
+
+	_, err := Source("#foo", Options{NoCache: true})
+	if err == nil || !strings.Contains(err.Error(), "exit status 1: error: unknown variable: foo") {
+		t.Errorf("Expected the typst diagnostics in the error, got %v", err)
+	}
+}
+
+func TestSourceContextStopsCompilation(t *testing.T) {
+	binary := filepath.Join(t.TempDir(), "fake-typst")
+	if err := os.WriteFile(binary, []byte("#!/bin/sh\nexec sleep 5\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	Configure(Settings{Binary: binary})
+	t.Cleanup(func() { Configure(Settings{}) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := SourceContext(ctx, "Hello", Options{NoCache: true})
+	if !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > 2*time.Second {
+		t.Errorf("Expected the compilation to stop with the context, got %v after %s", err, time.Since(start))
+	}
+}
+
+func TestWaitForRunningProcesses(t *testing.T) {
+	binary := filepath.Join(t.TempDir(), "fake-typst")
+	if err := os.WriteFile(binary, []byte("#!/bin/sh\nexec sleep 0.3\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	Configure(Settings{Binary: binary})
+	t.Cleanup(func() { Configure(Settings{}) })
+
+	done := make(chan error, 1)
+	go func() { done <- ToPDF("letter.typ", "letter.pdf") }()
+	for Running() == 0 {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := Wait(ctx); err == nil || !strings.Contains(err.Error(), "1 typst processes still running") {
+		t.Errorf("Expected Wait to give up, got %v", err)
+	}
+	if err := Wait(context.Background()); err != nil || Running() != 0 {
+		t.Errorf("Wait returned %v with %d processes running", err, Running())
+	}
+	if err := <-done; err != nil {
+		t.Errorf("ToPDF failed: %v", err)
+	}
+}
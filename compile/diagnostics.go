@@ -0,0 +1,155 @@
+package compile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// output runs typst with args and returns its trimmed standard output
+func output(ctx context.Context, args ...string) (string, error) {
+	settingsMu.RLock()
+	s := settings
+	settingsMu.RUnlock()
+	cmd := exec.CommandContext(ctx, s.Binary, args...)
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("typst %s failed: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("failed to run typst %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Version returns the version reported by typst --version, e.g. "typst 0.11.0"
+func Version(ctx context.Context) (string, error) {
+	return output(ctx, "--version")
+}
+
+// ErrUnsupportedVersion is returned by RequireVersion when typst is older than required
+var ErrUnsupportedVersion = errors.New("unsupported typst version")
+
+// versionPattern matches the version number in typst --version output
+var versionPattern = regexp.MustCompile(`\d+(\.\d+)*`)
+
+// RequireVersion checks that typst is at least the minimum version, e.g. "0.14"
+func RequireVersion(ctx context.Context, minimum string) error {
+	version, err := Version(ctx)
+	if err != nil {
+		return err
+	}
+	found := parseVersion(versionPattern.FindString(version))
+	if found == nil {
+		return fmt.Errorf("failed to parse typst version %q", version)
+	}
+	for i, required := range parseVersion(minimum) {
+		part := 0
+		if i < len(found) {
+			part = found[i]
+		}
+		if part > required {
+			return nil
+		}
+		if part < required {
+			return fmt.Errorf("%w: %s", ErrUnsupportedVersion, version)
+		}
+	}
+	return nil
+}
+
+// parseVersion splits a version such as 0.14.1 into its numbers
+func parseVersion(version string) []int {
+	if version == "" {
+		return nil
+	}
+	var parts []int
+	for _, field := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return nil
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+// Fonts returns the font families typst finds, including the configured font paths
+func Fonts(ctx context.Context) ([]string, error) {
+	settingsMu.RLock()
+	args := []string{"fonts"}
+	for _, path := range settings.FontPaths {
+		args = append(args, "--font-path", path)
+	}
+	settingsMu.RUnlock()
+
+	out, err := output(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	var families []string
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			families = append(families, line)
+		}
+	}
+	return families, nil
+}
+
+// packageSpecPattern matches package specs such as @local/modern-cv:0.9.0
+var packageSpecPattern = regexp.MustCompile(`^@([a-z0-9_-]+)/([A-Za-z0-9_-]+):(\d+\.\d+\.\d+)$`)
+
+// FindPackage returns the directory typst resolves a package spec such as @local/modern-cv:0.9.0 to.
+// Local packages are looked up in the package path, other namespaces also in the download cache.
+func FindPackage(spec string) (string, error) {
+	m := packageSpecPattern.FindStringSubmatch(spec)
+	if m == nil {
+		return "", fmt.Errorf("invalid package spec %q, expected @namespace/name:version", spec)
+	}
+	relative := filepath.Join(m[1], m[2], m[3])
+
+	settingsMu.RLock()
+	packagePath := settings.PackagePath
+	settingsMu.RUnlock()
+	if packagePath == "" {
+		packagePath = os.Getenv("TYPST_PACKAGE_PATH")
+	}
+	if packagePath == "" {
+		packagePath = filepath.Join(dataDir(), "typst", "packages")
+	}
+	dirs := []string{filepath.Join(packagePath, relative)}
+	if m[1] != "local" {
+		if cacheDir, err := os.UserCacheDir(); err == nil {
+			dirs = append(dirs, filepath.Join(cacheDir, "typst", "packages", relative))
+		}
+	}
+
+	for _, dir := range dirs {
+		if _, err := os.Stat(filepath.Join(dir, "typst.toml")); err == nil {
+			return dir, nil
+		}
+	}
+	return "", fmt.Errorf("package %s not found in %s", spec, strings.Join(dirs, " or "))
+}
+
+// dataDir returns the platform data directory typst keeps local packages in
+func dataDir() string {
+	home, _ := os.UserHomeDir()
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(home, "Library", "Application Support")
+	case "windows":
+		return os.Getenv("APPDATA")
+	}
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return dir
+	}
+	return filepath.Join(home, ".local", "share")
+}
@@ -0,0 +1,54 @@
+package compile
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiagnostics(t *testing.T) {
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "fake-typst")
+	script := `#!/bin/sh
+case "$1" in
+--version) echo "typst 0.11.0 (2bf9f95d)";;
+fonts) echo "Roboto"; [ "$3" = /extra ] && echo "Source Sans Pro";;
+esac
+`
+	if err := os.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	packages := filepath.Join(dir, "packages")
+	if err := os.MkdirAll(filepath.Join(packages, "local", "modern-cv", "0.9.0"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(packages, "local", "modern-cv", "0.9.0", "typst.toml"), nil, 0644)
+	Configure(Settings{Binary: binary, FontPaths: []string{"/extra"}, PackagePath: packages})
+	t.Cleanup(func() { Configure(Settings{}) })
+
+	if version, err := Version(context.Background()); err != nil || version != "typst 0.11.0 (2bf9f95d)" {
+		t.Errorf("Version = %q, %v", version, err)
+	}
+	for minimum, supported := range map[string]bool{"0.9": true, "0.11": true, "0.11.0": true, "0.11.1": false, "0.14": false, "1.0": false} {
+		err := RequireVersion(context.Background(), minimum)
+		if supported != (err == nil) || (err != nil && !errors.Is(err, ErrUnsupportedVersion)) {
+			t.Errorf("RequireVersion(%s) = %v", minimum, err)
+		}
+	}
+	if fonts, err := Fonts(context.Background()); err != nil || !reflect.DeepEqual(fonts, []string{"Roboto", "Source Sans Pro"}) {
+		t.Errorf("Fonts = %v, %v", fonts, err)
+	}
+	if path, err := FindPackage("@local/modern-cv:0.9.0"); err != nil || path != filepath.Join(packages, "local", "modern-cv", "0.9.0") {
+		t.Errorf("FindPackage = %q, %v", path, err)
+	}
+	if _, err := FindPackage("@local/modern-cv:1.0.0"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected a missing package, got %v", err)
+	}
+	if _, err := FindPackage("modern-cv"); err == nil {
+		t.Error("Expected an invalid spec error")
+	}
+}
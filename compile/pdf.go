@@ -0,0 +1,111 @@
+package compile
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+var (
+	pdfRootPattern   = regexp.MustCompile(`/Root\s+(\d+)\s+\d+\s+R`)
+	pdfPagesPattern  = regexp.MustCompile(`/Pages\s+(\d+)\s+\d+\s+R`)
+	pdfCountPattern  = regexp.MustCompile(`/Count\s+(\d+)`)
+	pdfObjectPattern = regexp.MustCompile(`(?s)(?:^|[^0-9])(\d+)\s+\d+\s+obj\b(.*?)endobj`)
+	// pdfObjStmPattern matches the dictionary and the start of the data of an object stream
+	pdfObjStmPattern = regexp.MustCompile(`(?s)<<([^<>]*/Type\s*/ObjStm[^<>]*)>>\s*stream\r?\n`)
+	pdfIntPattern    = regexp.MustCompile(`/(N|First)\s+(\d+)`)
+)
+
+// CountPDFPages returns the page count of a PDF document: the /Count of the page tree root
+// referenced by the document catalog. Objects kept in compressed object streams are found too.
+func CountPDFPages(content []byte) (int, error) {
+	objects := pdfObjects(content)
+	roots := pdfRootPattern.FindAllSubmatch(content, -1)
+	if len(roots) == 0 {
+		return 0, errors.New("PDF has no document catalog")
+	}
+	// The last trailer wins in incrementally updated files
+	catalog, ok := objects[string(roots[len(roots)-1][1])]
+	if !ok {
+		return 0, errors.New("PDF document catalog not found")
+	}
+	pages := pdfPagesPattern.FindSubmatch(catalog)
+	if pages == nil {
+		return 0, errors.New("PDF document catalog has no page tree")
+	}
+	tree, ok := objects[string(pages[1])]
+	if !ok {
+		return 0, errors.New("PDF page tree not found")
+	}
+	count := pdfCountPattern.FindSubmatch(tree)
+	if count == nil {
+		return 0, errors.New("PDF page tree has no /Count")
+	}
+	n, err := strconv.Atoi(string(count[1]))
+	if err != nil || n == 0 {
+		return 0, fmt.Errorf("invalid PDF page count %q", count[1])
+	}
+	return n, nil
+}
+
+// pdfObjects maps object numbers to the bodies of the objects in a PDF document and in its
+// Flate-compressed object streams. Later definitions replace earlier ones.
+func pdfObjects(content []byte) map[string][]byte {
+	objects := make(map[string][]byte)
+	for _, match := range pdfObjectPattern.FindAllSubmatch(content, -1) {
+		objects[string(match[1])] = match[2]
+	}
+	for _, loc := range pdfObjStmPattern.FindAllSubmatchIndex(content, -1) {
+		dict := content[loc[2]:loc[3]]
+		data := content[loc[1]:]
+		if end := bytes.Index(data, []byte("endstream")); end >= 0 {
+			data = data[:end]
+		}
+		reader, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			continue
+		}
+		// A truncated stream still yields the objects decoded so far
+		decoded, _ := io.ReadAll(reader)
+		addStreamObjects(objects, dict, decoded)
+	}
+	return objects
+}
+
+// addStreamObjects reads the objects of a decoded object stream: /N pairs of object numbers and
+// offsets, followed by the objects starting at /First
+func addStreamObjects(objects map[string][]byte, dict, decoded []byte) {
+	var n, first int
+	for _, match := range pdfIntPattern.FindAllSubmatch(dict, -1) {
+		value, _ := strconv.Atoi(string(match[2]))
+		if string(match[1]) == "N" {
+			n = value
+		} else {
+			first = value
+		}
+	}
+	if first > len(decoded) {
+		return
+	}
+	header := bytes.Fields(decoded[:first])
+	if len(header) < 2*n {
+		return
+	}
+	for i := 0; i < n; i++ {
+		start, err := strconv.Atoi(string(header[2*i+1]))
+		if err != nil || first+start > len(decoded) {
+			return
+		}
+		end := len(decoded)
+		if i+1 < n {
+			if next, err := strconv.Atoi(string(header[2*i+3])); err == nil && first+next <= len(decoded) && next >= start {
+				end = first + next
+			}
+		}
+		objects[string(header[2*i])] = decoded[first+start : end]
+	}
+}
@@ -0,0 +1,442 @@
+// Package config holds the settings of the cvcl-render server and CLI.
+// Settings are layered with increasing precedence: built-in defaults, a YAML or TOML
+// configuration file, CVCL_* environment variables and command-line flags.
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cvcl-render/model"
+)
+
+// EnvPrefix starts the environment variable of every setting, e.g. CVCL_SERVER_PORT for server.port
+const EnvPrefix = "CVCL_"
+
+// FileEnv names the environment variable holding the configuration file path
+const FileEnv = EnvPrefix + "CONFIG"
+
+// Config holds every setting, grouped in sections.
+// The config tags give the section and key names used in files; see EnvName for variables.
+type Config struct {
+	Server    Server    `config:"server"`
+	Templates Templates `config:"templates"`
+	Typst     Typst     `config:"typst"`
+	Timeouts  Timeouts  `config:"timeouts"`
+	Limits    Limits    `config:"limits"`
+	Health    Health    `config:"health"`
+	LLM       LLM       `config:"llm"`
+	Auth      Auth      `config:"auth"`
+	Storage   Storage   `config:"storage"`
+}
+
+// Server configures the HTTP server and rendered output
+type Server struct {
+	Port      string `config:"port"`
+	OutputDir string `config:"output_dir"`
+	SkipPDF   bool   `config:"skip_pdf"`
+	// JSONMode is strict (reject unknown fields) or lenient (warn)
+	JSONMode string `config:"json_mode"`
+}
+
+// Templates locates the Typst templates. Relative per-document paths are resolved against Dir.
+// Templates that are not found on disk fall back to the embedded ones.
+type Templates struct {
+	Dir         string `config:"dir"`
+	CoverLetter string `config:"coverletter"`
+	Resume      string `config:"resume"`
+}
+
+// Typst configures the typst CLI
+type Typst struct {
+	Binary      string   `config:"binary"`
+	FontPaths   []string `config:"font_paths"`
+	PackagePath string   `config:"package_path"`
+}
+
+// Timeouts bounds connections and external calls; zero means no timeout
+type Timeouts struct {
+	Compile time.Duration `config:"compile"`
+	LLM     time.Duration `config:"llm"`
+	Read    time.Duration `config:"read"`
+	Write   time.Duration `config:"write"`
+	Idle    time.Duration `config:"idle"`
+	// Shutdown bounds the wait for in-flight requests and typst processes on SIGTERM or SIGINT
+	Shutdown time.Duration `config:"shutdown"`
+	// DrainDelay keeps serving after SIGTERM while /health/ready fails, so load balancers stop routing first
+	DrainDelay time.Duration `config:"drain_delay"`
+}
+
+// Limits bounds the resources used by requests; zero means unlimited
+type Limits struct {
+	// MaxCompiles is the number of typst processes that may run at once
+	MaxCompiles  int   `config:"max_compiles"`
+	MaxBodyBytes int64 `config:"max_body_bytes"`
+	// RequestsPerMinute limits the render and parse requests of each API key, or client IP without
+	// authentication, in bursts of up to Burst requests (defaults to RequestsPerMinute)
+	RequestsPerMinute int `config:"requests_per_minute"`
+	Burst             int `config:"burst"`
+	// DailyRenders limits the renders of each client per UTC day; counts are kept in storage.dir
+	DailyRenders int `config:"daily_renders"`
+}
+
+// Health configures the checks of /health/ready
+type Health struct {
+	// Packages and Fonts are required by the templates
+	Packages []string `config:"packages"`
+	Fonts    []string `config:"fonts"`
+	// Canary also compiles a tiny document importing the packages
+	Canary   bool          `config:"canary"`
+	CacheTTL time.Duration `config:"cache_ttl"`
+	Timeout  time.Duration `config:"timeout"`
+}
+
+// LLM configures the language model used to draft cover letters
+type LLM struct {
+	URL    string `config:"url"`
+	Model  string `config:"model"`
+	APIKey string `config:"api_key" secret:"true"`
+}
+
+// Auth configures API authentication
+type Auth struct {
+	// APIKeysFile lists hashed API keys; authentication is disabled when neither file is set
+	APIKeysFile string `config:"api_keys_file"`
+	JWKSFile    string `config:"jwks_file"`
+	// JWTIssuer and JWTAudience, when set, must match the iss and aud claims of tokens
+	JWTIssuer   string `config:"jwt_issuer"`
+	JWTAudience string `config:"jwt_audience"`
+	// AuditLog receives a JSON line per authenticated request; empty logs to stderr
+	AuditLog string `config:"audit_log"`
+}
+
+// Storage configures where persistent state is kept
+type Storage struct {
+	Dir string `config:"dir"`
+}
+
+// Default returns the built-in settings
+func Default() Config {
+	return Config{
+		Server: Server{Port: "8080", OutputDir: ".", JSONMode: string(model.DecodeLenient)},
+		Typst:  Typst{Binary: "typst"},
+		Timeouts: Timeouts{
+			Compile:    2 * time.Minute,
+			LLM:        60 * time.Second,
+			Read:       30 * time.Second,
+			Write:      5 * time.Minute,
+			Idle:       2 * time.Minute,
+			Shutdown:   3 * time.Minute,
+			DrainDelay: 5 * time.Second,
+		},
+		Limits: Limits{MaxCompiles: 4, MaxBodyBytes: 10 << 20},
+		Health: Health{
+			Packages: []string{"@local/modern-cv:0.9.0"},
+			Fonts:    []string{"Roboto", "Source Sans Pro"},
+			CacheTTL: 30 * time.Second,
+			Timeout:  10 * time.Second,
+		},
+		LLM:     LLM{Model: "gpt-4o-mini"},
+		Storage: Storage{Dir: "data"},
+	}
+}
+
+// Load returns the default settings overridden by the file at path, if any, and then by the environment
+func Load(path string) (Config, error) {
+	cfg := Default()
+	if path != "" {
+		if err := cfg.LoadFile(path); err != nil {
+			return cfg, err
+		}
+	}
+	if err := cfg.LoadEnv(os.LookupEnv); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// LoadFile applies the settings of a YAML (.yaml, .yml) or TOML (.toml) file
+func (c *Config) LoadFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	var assignments []assignment
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		assignments, err = parseYAML(string(content))
+	case ".toml":
+		assignments, err = parseTOML(string(content))
+	default:
+		return fmt.Errorf("unsupported config file format: %s (use .yaml, .yml or .toml)", path)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for _, a := range assignments {
+		if err := c.set(a.key, a.values, a.list); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, a.line, err)
+		}
+	}
+	return nil
+}
+
+// LoadEnv applies the CVCL_* variables returned by lookup, such as os.LookupEnv
+func (c *Config) LoadEnv(lookup func(string) (string, bool)) error {
+	for _, f := range c.fields() {
+		name := EnvName(f.key)
+		if value, ok := lookup(name); ok {
+			if err := c.Set(f.key, value); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Set assigns a setting from its text form, as given in an environment variable or a flag.
+// Lists are comma-separated.
+func (c *Config) Set(key, value string) error {
+	values := []string{value}
+	list := false
+	if f, ok := c.field(key); ok && f.value.Kind() == reflect.Slice {
+		values = splitList(value)
+		list = true
+	}
+	return c.set(key, values, list)
+}
+
+// EnvName returns the environment variable of a setting, e.g. CVCL_TYPST_FONT_PATHS for typst.font_paths
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// DecodeMode returns the parsed server.json_mode
+func (c Config) DecodeMode() (model.DecodeMode, error) {
+	return model.ParseDecodeMode(c.Server.JSONMode)
+}
+
+// CoverLetterTemplate returns the path of the cover letter template
+func (c Config) CoverLetterTemplate() string {
+	return c.Templates.resolve(c.Templates.CoverLetter, "coverletter.typ.template")
+}
+
+// ResumeTemplate returns the path of the resume template
+func (c Config) ResumeTemplate() string {
+	return c.Templates.resolve(c.Templates.Resume, "resume.typ.template")
+}
+
+// resolve joins a template path with the templates directory, using name when no path is set
+func (t Templates) resolve(path, name string) string {
+	if path == "" {
+		path = name
+		if t.Dir == "" {
+			return "templates/" + name
+		}
+	}
+	if t.Dir == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(t.Dir, path)
+}
+
+// Validate checks that the settings are usable, reporting problems by key
+func (c Config) Validate() error {
+	var errs model.ValidationErrors
+	add := func(key, format string, args ...interface{}) {
+		errs = append(errs, model.FieldError{Field: key, Message: fmt.Sprintf(format, args...)})
+	}
+	requireDir := func(key, path string) {
+		if info, err := os.Stat(path); err != nil {
+			add(key, "cannot be read: %v", err)
+		} else if !info.IsDir() {
+			add(key, "must be a directory: %s", path)
+		}
+	}
+	requireFile := func(key, path string) {
+		if _, err := os.Stat(path); err != nil {
+			add(key, "cannot be read: %v", err)
+		}
+	}
+
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		add("server.port", "must be a number between 1 and 65535")
+	}
+	if c.Server.OutputDir == "" {
+		add("server.output_dir", "is required")
+	}
+	if _, err := c.DecodeMode(); err != nil {
+		add("server.json_mode", "must be strict or lenient")
+	}
+	// Templates missing from the directory fall back to the embedded ones, explicit paths must exist
+	if c.Templates.Dir != "" {
+		requireDir("templates.dir", c.Templates.Dir)
+	}
+	if c.Templates.CoverLetter != "" {
+		requireFile("templates.coverletter", c.CoverLetterTemplate())
+	}
+	if c.Templates.Resume != "" {
+		requireFile("templates.resume", c.ResumeTemplate())
+	}
+	if c.Typst.Binary == "" {
+		add("typst.binary", "is required")
+	} else if !c.Server.SkipPDF {
+		if _, err := exec.LookPath(c.Typst.Binary); err != nil {
+			add("typst.binary", "not found: %v", err)
+		}
+	}
+	for i, path := range c.Typst.FontPaths {
+		requireDir(fmt.Sprintf("typst.font_paths[%d]", i), path)
+	}
+	if c.Typst.PackagePath != "" {
+		requireDir("typst.package_path", c.Typst.PackagePath)
+	}
+	for _, f := range c.fields() {
+		if f.value.Type() == durationType && f.value.Int() < 0 {
+			add(f.key, "must not be negative")
+		}
+	}
+	if c.Limits.MaxCompiles < 0 {
+		add("limits.max_compiles", "must not be negative")
+	}
+	if c.Limits.MaxBodyBytes < 0 {
+		add("limits.max_body_bytes", "must not be negative")
+	}
+	if c.Limits.RequestsPerMinute < 0 {
+		add("limits.requests_per_minute", "must not be negative")
+	}
+	if c.Limits.Burst < 0 {
+		add("limits.burst", "must not be negative")
+	}
+	if c.Limits.DailyRenders < 0 {
+		add("limits.daily_renders", "must not be negative")
+	}
+	if c.Limits.Burst > 0 && c.Limits.RequestsPerMinute == 0 {
+		add("limits.burst", "requires limits.requests_per_minute")
+	}
+	if c.Limits.DailyRenders > 0 && c.Storage.Dir == "" {
+		add("storage.dir", "is required when limits.daily_renders is set")
+	}
+	if c.LLM.URL != "" && c.LLM.Model == "" {
+		add("llm.model", "is required when llm.url is set")
+	}
+	if c.Auth.APIKeysFile != "" {
+		requireFile("auth.api_keys_file", c.Auth.APIKeysFile)
+	}
+	if c.Auth.JWKSFile != "" {
+		requireFile("auth.jwks_file", c.Auth.JWKSFile)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// field is a single setting with its key, such as server.port
+type field struct {
+	key    string
+	value  reflect.Value
+	secret bool
+}
+
+// fields lists the settings of c in declaration order
+func (c *Config) fields() []field {
+	var fields []field
+	sections := reflect.ValueOf(c).Elem()
+	for i := 0; i < sections.NumField(); i++ {
+		section := sections.Type().Field(i).Tag.Get("config")
+		values := sections.Field(i)
+		for j := 0; j < values.NumField(); j++ {
+			tag := values.Type().Field(j)
+			fields = append(fields, field{
+				key:    section + "." + tag.Tag.Get("config"),
+				value:  values.Field(j),
+				secret: tag.Tag.Get("secret") == "true",
+			})
+		}
+	}
+	return fields
+}
+
+func (c *Config) field(key string) (field, bool) {
+	for _, f := range c.fields() {
+		if f.key == key {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// set assigns parsed values to a setting; list tells whether they were written as a list
+func (c *Config) set(key string, values []string, list bool) error {
+	f, ok := c.field(key)
+	if !ok {
+		return fmt.Errorf("unknown setting %q (known: %s)", key, strings.Join(sortedSections(), ", "))
+	}
+	v := f.value
+	if v.Kind() == reflect.Slice {
+		v.Set(reflect.ValueOf(append([]string(nil), values...)))
+		return nil
+	}
+	if list || len(values) != 1 {
+		return fmt.Errorf("%s must be a single value, not a list", key)
+	}
+	value := values[0]
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%s must be a duration such as 30s or 2m: %q", key, value)
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(value)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s must be true or false: %q", key, value)
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int || v.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s must be an integer: %q", key, value)
+		}
+		v.SetInt(n)
+	default:
+		return fmt.Errorf("%s has an unsupported type %s", key, v.Type())
+	}
+	return nil
+}
+
+// sortedSections lists the section names, for error messages
+func sortedSections() []string {
+	var sections []string
+	typ := reflect.TypeOf(Config{})
+	for i := 0; i < typ.NumField(); i++ {
+		sections = append(sections, typ.Field(i).Tag.Get("config")+".*")
+	}
+	sort.Strings(sections)
+	return sections
+}
+
+// splitList splits a comma-separated list, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
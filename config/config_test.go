@@ -0,0 +1,172 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleYAML = `# Server settings
+server:
+  port: "9090"
+  skip_pdf: true
+
+templates:
+  dir: /etc/cvcl/templates
+  resume: custom-resume.typ.template # relative to dir
+
+typst:
+  font_paths:
+    - /fonts/a
+    - "/fonts/b # not a comment"
+timeouts:
+  compile: 45s
+`
+
+const sampleTOML = `# Server settings
+[server]
+port = "9090"
+skip_pdf = true
+
+[templates]
+dir = "/etc/cvcl/templates"
+resume = 'custom-resume.typ.template' # relative to dir
+
+[typst]
+font_paths = ["/fonts/a", "/fonts/b # not a comment"]
+
+[timeouts]
+compile = "45s"
+`
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestLoadFile(t *testing.T) {
+	want := Default()
+	want.Server.Port = "9090"
+	want.Server.SkipPDF = true
+	want.Templates = Templates{Dir: "/etc/cvcl/templates", Resume: "custom-resume.typ.template"}
+	want.Typst.FontPaths = []string{"/fonts/a", "/fonts/b # not a comment"}
+	want.Timeouts.Compile = 45 * time.Second
+
+	for _, name := range []string{"config.yaml", "config.toml"} {
+		content := sampleYAML
+		if strings.HasSuffix(name, ".toml") {
+			content = sampleTOML
+		}
+		cfg := Default()
+		if err := cfg.LoadFile(writeFile(t, name, content)); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(cfg, want) {
+			t.Errorf("%s:\ngot  %+v\nwant %+v", name, cfg, want)
+		}
+		if cfg.ResumeTemplate() != "/etc/cvcl/templates/custom-resume.typ.template" || cfg.CoverLetterTemplate() != "/etc/cvcl/templates/coverletter.typ.template" {
+			t.Errorf("%s: unexpected template paths %s, %s", name, cfg.CoverLetterTemplate(), cfg.ResumeTemplate())
+		}
+	}
+}
+
+func TestLoadFileReportsLine(t *testing.T) {
+	for content, want := range map[string]string{
+		"server:\n  port: 1\n  prot: 2\n":         ":3: unknown setting \"server.prot\"",
+		"[limits]\nmax_compiles = \"many\"\n":     ":2: limits.max_compiles must be an integer",
+		"server:\n  skip_pdf: [true]\n":           ":2: server.skip_pdf must be a single value",
+		"port: 8080\n":                            "line 1: expected a section",
+		"[server]\nport = \"8080\nskip_pdf = 1\n": "line 2: invalid quoted string",
+	} {
+		name := "config.yaml"
+		if strings.HasPrefix(content, "[") {
+			name = "config.toml"
+		}
+		cfg := Default()
+		err := cfg.LoadFile(writeFile(t, name, content))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error containing %q for %q, got %v", want, content, err)
+		}
+	}
+}
+
+func TestPrecedence(t *testing.T) {
+	cfg := Default()
+	if err := cfg.LoadFile(writeFile(t, "config.yaml", "server:\n  port: 9090\n  output_dir: /from/file\n")); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{"CVCL_SERVER_PORT": "7070", "CVCL_TYPST_FONT_PATHS": "/a, /b", "CVCL_LLM_API_KEY": "secret"}
+	err := cfg.LoadEnv(func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Set("server.port", "6060"); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Server.Port != "6060" || cfg.Server.OutputDir != "/from/file" || !reflect.DeepEqual(cfg.Typst.FontPaths, []string{"/a", "/b"}) {
+		t.Errorf("Unexpected settings %+v", cfg)
+	}
+
+	env = map[string]string{"CVCL_TIMEOUTS_LLM": "soon"}
+	if err := cfg.LoadEnv(func(name string) (string, bool) { value, ok := env[name]; return value, ok }); err == nil || !strings.HasPrefix(err.Error(), "CVCL_TIMEOUTS_LLM: ") {
+		t.Errorf("Expected an error naming the variable, got %v", err)
+	}
+}
+
+func TestDumpRoundTrip(t *testing.T) {
+	cfg := Default()
+	cfg.Typst.FontPaths = []string{`C:\Fonts`, `quoted "fonts"`}
+	cfg.LLM.APIKey = "secret"
+	for _, format := range []string{"yaml", "toml"} {
+		var b bytes.Buffer
+		if err := cfg.Dump(&b, format, false); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(b.String(), "secret") {
+			t.Errorf("%s dump shows the API key:\n%s", format, b.String())
+		}
+		loaded := Default()
+		if err := loaded.LoadFile(writeFile(t, "config."+format, b.String())); err != nil {
+			t.Fatalf("Failed to load %s dump: %v\n%s", format, err, b.String())
+		}
+		loaded.LLM.APIKey = cfg.LLM.APIKey
+		if !reflect.DeepEqual(loaded, cfg) {
+			t.Errorf("%s dump does not round-trip:\n%s", format, b.String())
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cfg := Default()
+	cfg.Server.SkipPDF = true
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Default settings are invalid: %v", err)
+	}
+
+	cfg.Server.Port = "http"
+	cfg.Server.JSONMode = "loose"
+	cfg.Templates.Dir = filepath.Join(t.TempDir(), "missing")
+	cfg.Templates.Resume = "resume.typ"
+	cfg.Limits.MaxCompiles = -1
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Expected validation errors")
+	}
+	for _, key := range []string{"server.port", "server.json_mode", "templates.dir", "templates.resume", "limits.max_compiles"} {
+		if !strings.Contains(err.Error(), key+":") {
+			t.Errorf("Expected an error for %s, got %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// assignment is a setting read from a configuration file
+type assignment struct {
+	key    string
+	values []string
+	list   bool
+	line   int
+}
+
+// parseYAML reads the subset of YAML used by configuration files: top-level sections holding
+// scalar keys, flow lists ([a, b]) and block lists ("- a" lines)
+func parseYAML(content string) ([]assignment, error) {
+	var assignments []assignment
+	section := ""
+	openList := -1
+	for i, raw := range strings.Split(content, "\n") {
+		line := strings.TrimRight(stripComment(raw), " \t\r")
+		text := strings.TrimSpace(line)
+		if text == "" || text == "---" {
+			continue
+		}
+		if strings.HasPrefix(line, "\t") {
+			return nil, fmt.Errorf("line %d: indent with spaces, not tabs", i+1)
+		}
+
+		if line[0] != ' ' {
+			name, rest, ok := strings.Cut(text, ":")
+			if !ok || strings.TrimSpace(rest) != "" {
+				return nil, fmt.Errorf("line %d: expected a section such as \"server:\"", i+1)
+			}
+			section = strings.TrimSpace(name)
+			openList = -1
+			continue
+		}
+		if section == "" {
+			return nil, fmt.Errorf("line %d: setting outside of a section", i+1)
+		}
+
+		if text == "-" || strings.HasPrefix(text, "- ") {
+			if openList < 0 {
+				return nil, fmt.Errorf("line %d: list item without a key", i+1)
+			}
+			item, err := parseScalar(strings.TrimSpace(text[1:]))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			assignments[openList].values = append(assignments[openList].values, item)
+			continue
+		}
+
+		name, rest, ok := strings.Cut(text, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", i+1)
+		}
+		a := assignment{key: section + "." + strings.TrimSpace(name), line: i + 1}
+		openList = -1
+		if rest = strings.TrimSpace(rest); rest == "" {
+			// A block list follows
+			a.list = true
+			openList = len(assignments)
+		} else {
+			var err error
+			if a.values, a.list, err = parseValue(rest); err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+		}
+		assignments = append(assignments, a)
+	}
+	return assignments, nil
+}
+
+// parseTOML reads the subset of TOML used by configuration files: [section] tables
+// holding "key = value" pairs with strings, numbers, booleans and single-line arrays
+func parseTOML(content string) ([]assignment, error) {
+	var assignments []assignment
+	section := ""
+	for i, raw := range strings.Split(content, "\n") {
+		text := strings.TrimSpace(stripComment(raw))
+		if text == "" {
+			continue
+		}
+		if strings.HasPrefix(text, "[") {
+			if !strings.HasSuffix(text, "]") || strings.HasPrefix(text, "[[") {
+				return nil, fmt.Errorf("line %d: expected a table such as [server]", i+1)
+			}
+			section = strings.TrimSpace(text[1 : len(text)-1])
+			continue
+		}
+		if section == "" {
+			return nil, fmt.Errorf("line %d: setting outside of a table", i+1)
+		}
+		name, rest, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key = value\"", i+1)
+		}
+		a := assignment{key: section + "." + strings.TrimSpace(name), line: i + 1}
+		var err error
+		if a.values, a.list, err = parseValue(strings.TrimSpace(rest)); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		assignments = append(assignments, a)
+	}
+	return assignments, nil
+}
+
+// parseValue reads a scalar or a [a, b] list
+func parseValue(text string) ([]string, bool, error) {
+	if !strings.HasPrefix(text, "[") {
+		value, err := parseScalar(text)
+		return []string{value}, false, err
+	}
+	if !strings.HasSuffix(text, "]") {
+		return nil, false, fmt.Errorf("unterminated list: %s", text)
+	}
+	var values []string
+	for _, item := range splitOutsideQuotes(text[1:len(text)-1], ',') {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		value, err := parseScalar(item)
+		if err != nil {
+			return nil, false, err
+		}
+		values = append(values, value)
+	}
+	return values, true, nil
+}
+
+// parseScalar unquotes a double- or single-quoted string; other values are taken as written
+func parseScalar(text string) (string, error) {
+	switch {
+	case strings.HasPrefix(text, `"`):
+		value, err := strconv.Unquote(text)
+		if err != nil {
+			return "", fmt.Errorf("invalid quoted string: %s", text)
+		}
+		return value, nil
+	case strings.HasPrefix(text, "'"):
+		if len(text) < 2 || !strings.HasSuffix(text, "'") {
+			return "", fmt.Errorf("invalid quoted string: %s", text)
+		}
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	}
+	return text, nil
+}
+
+// stripComment removes a # comment that is not inside a quoted string
+func stripComment(line string) string {
+	parts := splitOutsideQuotes(line, '#')
+	return parts[0]
+}
+
+// splitOutsideQuotes splits text at every sep that is not inside a quoted string
+func splitOutsideQuotes(text string, sep byte) []string {
+	var parts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == sep:
+			parts = append(parts, text[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, text[start:])
+}
+
+// Dump writes the settings as a YAML or TOML file that Load reads back.
+// Secrets are masked unless showSecrets is set.
+func (c Config) Dump(w io.Writer, format string, showSecrets bool) error {
+	var b strings.Builder
+	section := ""
+	for _, f := range c.fields() {
+		name, key, _ := strings.Cut(f.key, ".")
+		if name != section {
+			if section != "" {
+				b.WriteString("\n")
+			}
+			section = name
+			switch format {
+			case "yaml", "yml":
+				fmt.Fprintf(&b, "%s:\n", name)
+			case "toml":
+				fmt.Fprintf(&b, "[%s]\n", name)
+			default:
+				return fmt.Errorf("unsupported config format: %s (use yaml or toml)", format)
+			}
+		}
+		value := formatValue(f.value)
+		if f.secret && !showSecrets && f.value.String() != "" {
+			value = strconv.Quote("********")
+		}
+		if format == "toml" {
+			fmt.Fprintf(&b, "%s = %s\n", key, value)
+		} else {
+			fmt.Fprintf(&b, "  %s: %s\n", key, value)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatValue writes a setting in the syntax shared by YAML and TOML
+func formatValue(v reflect.Value) string {
+	switch {
+	case v.Type() == durationType:
+		return strconv.Quote(time.Duration(v.Int()).String())
+	case v.Kind() == reflect.String:
+		return strconv.Quote(v.String())
+	case v.Kind() == reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = strconv.Quote(v.Index(i).String())
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,346 @@
+// Package draft drafts cover letters from a resume and a job description, with a template
+// generator and an OpenAI-compatible one.
+package draft
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"cvcl-render/i18n"
+	"cvcl-render/keywords"
+	"cvcl-render/model"
+)
+
+// Generator drafts the body of a cover letter from a resume and a job description
+type Generator interface {
+	// Name identifies the generator in responses and logs
+	Name() string
+	// Draft returns the opening, about-me, why-me and why-company paragraphs
+	Draft(ctx context.Context, req model.DraftRequest) (model.CoverLetterData, error)
+}
+
+// draftHeader returns a cover letter with the contact details, position and addressee
+// taken from the request, to which generators add the body paragraphs
+func draftHeader(req model.DraftRequest) model.CoverLetterData {
+	letter := model.CoverLetterData{
+		Profile:   req.Resume.Author,
+		Position:  req.Options.Position,
+		Addressee: req.Options.Addressee,
+	}
+	if letter.Position == "" && len(req.Resume.Positions) > 0 {
+		letter.Position = req.Resume.Positions[0]
+	}
+	if letter.Addressee == "" {
+		letter.Addressee = "Hiring Manager"
+	}
+	if req.Options.Language != "" {
+		letter.Options = &model.RenderOptions{Language: req.Options.Language}
+	}
+	return letter
+}
+
+// CoverLetter drafts a cover letter with the generator, falling back to the template
+// generator when none is configured or when it fails or returns an invalid letter.
+// It returns the name of the generator that produced the letter and warnings about fallbacks.
+func CoverLetter(ctx context.Context, generator Generator, req model.DraftRequest) (model.CoverLetterData, string, []string, error) {
+	var warnings []string
+	if generator != nil {
+		letter, err := generator.Draft(ctx, req)
+		if err == nil {
+			err = letter.Validate()
+		}
+		if err == nil {
+			return letter, generator.Name(), nil, nil
+		}
+		if ctx.Err() != nil {
+			return model.CoverLetterData{}, "", nil, ctx.Err()
+		}
+		warnings = append(warnings, fmt.Sprintf("%s generator failed, using the template draft: %v", generator.Name(), err))
+	}
+
+	fallback := TemplateGenerator{}
+	letter, err := fallback.Draft(ctx, req)
+	if err != nil {
+		return model.CoverLetterData{}, "", warnings, err
+	}
+	if language := i18n.Normalize(req.Options.Language); language != "" && language != i18n.DefaultLanguage {
+		warnings = append(warnings, "the template draft is written in English; only the salutation and closing are localized")
+	}
+	return letter, fallback.Name(), warnings, nil
+}
+
+// draftPhrases are the sentences of a template draft in one tone. Placeholders in braces
+// are replaced with details from the resume and the job description.
+type draftPhrases struct {
+	opening    string
+	aboutRole  string
+	whySkills  string
+	whyGeneral string
+	whyCompany string
+}
+
+var templateTones = map[string]draftPhrases{
+	"formal": {
+		opening:    "I am writing to apply for the {position} position at {company}.",
+		aboutRole:  "In my most recent role as {role}, I have built a solid foundation for the responsibilities described in your posting.",
+		whySkills:  "My experience with {skills} matches the requirements of the position, and I am confident that I can contribute from the start.",
+		whyGeneral: "My background has prepared me well for the requirements of the position, and I am confident that I can contribute from the start.",
+		whyCompany: "I would welcome the opportunity to bring this experience to {company} and to discuss how I can support your team.",
+	},
+	"friendly": {
+		opening:    "I was happy to come across the {position} opening at {company} and would like to introduce myself.",
+		aboutRole:  "Most recently I worked as {role}, where I enjoyed working closely with my team on problems like the ones in your posting.",
+		whySkills:  "I work with {skills} every day, so I expect to feel at home in the role quickly.",
+		whyGeneral: "The work described in your posting is close to what I do every day, so I expect to feel at home in the role quickly.",
+		whyCompany: "I would love to bring this to {company}, and I look forward to talking with you.",
+	},
+	"enthusiastic": {
+		opening:    "I am excited to apply for the {position} position at {company}!",
+		aboutRole:  "As {role}, I have found the kind of work described in your posting to be exactly what motivates me.",
+		whySkills:  "I have hands-on experience with {skills}, and I can't wait to put it to work on your team.",
+		whyGeneral: "The challenges described in your posting are exactly the ones I want to take on next.",
+		whyCompany: "Joining {company} would be a great next step for me, and I would be thrilled to discuss how I can help.",
+	},
+	"concise": {
+		opening:    "I am applying for the {position} position at {company}.",
+		aboutRole:  "Most recently I worked as {role}.",
+		whySkills:  "I bring hands-on experience with {skills}.",
+		whyGeneral: "My experience fits the requirements of the position.",
+		whyCompany: "I look forward to discussing how I can contribute to {company}.",
+	},
+}
+
+// maxDraftSkills limits the number of matched keywords named in a template draft
+const maxDraftSkills = 4
+
+// TemplateGenerator drafts cover letters from fixed phrases without calling an external service.
+// The result only depends on the request: the skills named are the best job description
+// keywords found in the resume, and the role is the most recent work experience.
+type TemplateGenerator struct{}
+
+// Name identifies the template generator
+func (TemplateGenerator) Name() string { return "template" }
+
+// Draft fills the legacy paragraphs with the phrases of the requested tone
+func (TemplateGenerator) Draft(ctx context.Context, req model.DraftRequest) (model.CoverLetterData, error) {
+	phrases, ok := templateTones[req.Options.ToneOrDefault()]
+	if !ok {
+		return model.CoverLetterData{}, fmt.Errorf("unknown tone: %s", req.Options.Tone)
+	}
+	letter := draftHeader(req)
+
+	company := req.Options.Company
+	if company == "" {
+		company = "your company"
+	}
+	var skills []string
+	for _, k := range keywords.Compare(req.JobDescription, &req.Resume, nil).Keywords {
+		if len(k.FoundIn) > 0 && len(skills) < maxDraftSkills {
+			skills = append(skills, k.Keyword)
+		}
+	}
+	role := ""
+	if len(req.Resume.WorkExperience) > 0 {
+		work := append([]model.ResumeEntry(nil), req.Resume.WorkExperience...)
+		model.SortEntriesByDate(work)
+		role = work[0].Title
+	}
+
+	replacer := strings.NewReplacer(
+		"{position}", letter.Position,
+		"{company}", company,
+		"{role}", role,
+		"{skills}", joinList(skills),
+	)
+	letter.Opening = replacer.Replace(phrases.opening)
+	if summary := firstSentence(req.Resume.Summary); summary != "" {
+		letter.AboutMe = summary
+	}
+	if role != "" {
+		letter.AboutMe = strings.TrimSpace(letter.AboutMe + " " + replacer.Replace(phrases.aboutRole))
+	}
+	if len(skills) > 0 {
+		letter.WhyMe = replacer.Replace(phrases.whySkills)
+	} else {
+		letter.WhyMe = replacer.Replace(phrases.whyGeneral)
+	}
+	letter.WhyCompany = replacer.Replace(phrases.whyCompany)
+	return letter, nil
+}
+
+// joinList joins items as "a, b and c"
+func joinList(items []string) string {
+	if len(items) <= 1 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
+
+// firstSentence returns the first sentence of a text, or the whole text if it has no sentence end
+func firstSentence(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	for i, r := range text {
+		if (r == '.' || r == '!' || r == '?') && (i+1 == len(text) || text[i+1] == ' ') {
+			return text[:i+1]
+		}
+	}
+	return text
+}
+
+// OpenAIGenerator drafts cover letters with an OpenAI-compatible chat completions API
+type OpenAIGenerator struct {
+	// BaseURL is the API root, e.g. "https://api.openai.com/v1"
+	BaseURL string
+	APIKey  string
+	Model   string
+	Client  *http.Client
+}
+
+// NewOpenAIGenerator returns a generator for the API at baseURL with a request timeout
+func NewOpenAIGenerator(baseURL, apiKey, modelName string, timeout time.Duration) *OpenAIGenerator {
+	return &OpenAIGenerator{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		APIKey:  apiKey,
+		Model:   modelName,
+		Client:  &http.Client{Timeout: timeout},
+	}
+}
+
+// Name identifies the OpenAI-compatible generator
+func (g *OpenAIGenerator) Name() string { return "openai" }
+
+// chatMessage is a message of a chat completions request or response
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// chatRequest is the body of a chat completions request
+type chatRequest struct {
+	Model          string            `json:"model"`
+	Messages       []chatMessage     `json:"messages"`
+	Temperature    float64           `json:"temperature"`
+	ResponseFormat map[string]string `json:"response_format"`
+}
+
+// chatResponse is the part of a chat completions response used by the generator
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// draftParagraphs is the JSON object the model is asked to answer with
+type draftParagraphs struct {
+	Opening    string `json:"opening"`
+	AboutMe    string `json:"about_me"`
+	WhyMe      string `json:"why_me"`
+	WhyCompany string `json:"why_company"`
+}
+
+const draftSystemPrompt = `You write cover letters. Answer with a single JSON object with the string fields ` +
+	`"opening", "about_me", "why_me" and "why_company", one paragraph each, without salutation, ` +
+	`closing or signature. Only claim experience that appears in the resume.`
+
+// draftPrompt describes the application to the model
+func draftPrompt(req model.DraftRequest, position string) (string, error) {
+	resume, err := json.Marshal(req.Resume)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode resume: %w", err)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Position: %s\n", position)
+	if req.Options.Company != "" {
+		fmt.Fprintf(&b, "Company: %s\n", req.Options.Company)
+	}
+	fmt.Fprintf(&b, "Tone: %s\n", req.Options.ToneOrDefault())
+	fmt.Fprintf(&b, "Language: %s\n", i18n.Normalize(req.Options.Language))
+	fmt.Fprintf(&b, "\nJob description:\n%s\n", req.JobDescription)
+	fmt.Fprintf(&b, "\nResume (JSON):\n%s\n", resume)
+	return b.String(), nil
+}
+
+// Draft asks the model for the body paragraphs of the letter
+func (g *OpenAIGenerator) Draft(ctx context.Context, req model.DraftRequest) (model.CoverLetterData, error) {
+	letter := draftHeader(req)
+	prompt, err := draftPrompt(req, letter.Position)
+	if err != nil {
+		return model.CoverLetterData{}, err
+	}
+	body, err := json.Marshal(chatRequest{
+		Model: g.Model,
+		Messages: []chatMessage{
+			{Role: "system", Content: draftSystemPrompt},
+			{Role: "user", Content: prompt},
+		},
+		Temperature:    0.7,
+		ResponseFormat: map[string]string{"type": "json_object"},
+	})
+	if err != nil {
+		return model.CoverLetterData{}, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, g.BaseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return model.CoverLetterData{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if g.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+g.APIKey)
+	}
+	client := g.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return model.CoverLetterData{}, fmt.Errorf("failed to call completions API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var completion chatResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&completion); err != nil {
+		return model.CoverLetterData{}, fmt.Errorf("failed to decode completions response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if completion.Error != nil {
+			return model.CoverLetterData{}, fmt.Errorf("completions API returned status %d: %s", resp.StatusCode, completion.Error.Message)
+		}
+		return model.CoverLetterData{}, fmt.Errorf("completions API returned status %d", resp.StatusCode)
+	}
+	if len(completion.Choices) == 0 {
+		return model.CoverLetterData{}, fmt.Errorf("completions API returned no choices")
+	}
+
+	var paragraphs draftParagraphs
+	if err := json.Unmarshal([]byte(stripCodeFence(completion.Choices[0].Message.Content)), &paragraphs); err != nil {
+		return model.CoverLetterData{}, fmt.Errorf("failed to decode drafted paragraphs: %w", err)
+	}
+	letter.Opening = strings.TrimSpace(paragraphs.Opening)
+	letter.AboutMe = strings.TrimSpace(paragraphs.AboutMe)
+	letter.WhyMe = strings.TrimSpace(paragraphs.WhyMe)
+	letter.WhyCompany = strings.TrimSpace(paragraphs.WhyCompany)
+	return letter, nil
+}
+
+// stripCodeFence removes a Markdown code fence that some models put around JSON answers
+func stripCodeFence(content string) string {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "```") {
+		return content
+	}
+	content = strings.TrimPrefix(content, "```")
+	if newline := strings.Index(content, "\n"); newline != -1 {
+		content = content[newline+1:]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(content), "```"))
+}
@@ -0,0 +1,118 @@
+package draft
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"cvcl-render/examples"
+	"cvcl-render/model"
+)
+
+const sampleJobDescription = `Embedded Software Engineer
+
+We are looking for an embedded software engineer to build firmware for our sensor platform.
+You will write Rust and C for ARM Cortex-M microcontrollers, and work with our cloud team on
+Kubernetes deployments. Experience with RTOS kernels, CI/CD and low power design is a plus.
+Low power design experience and sensor platform knowledge are highly valued.`
+
+func draftRequest(t *testing.T) model.DraftRequest {
+	req := model.DraftRequest{JobDescription: sampleJobDescription, Options: model.DraftOptions{Company: "Acme Sensors"}}
+	if err := json.Unmarshal(examples.Resume, &req.Resume); err != nil {
+		t.Fatalf("Failed to parse example: %v", err)
+	}
+	req.Resume.Author = model.Profile{FirstName: "Jane", LastName: "Doe", Email: "jane@example.com"}
+	return req
+}
+
+func TestTemplateGeneratorDraft(t *testing.T) {
+	req := draftRequest(t)
+
+	letter, name, warnings, err := CoverLetter(context.Background(), nil, req)
+	if err != nil {
+		t.Fatalf("CoverLetter failed: %v", err)
+	}
+	if name != "template" || len(warnings) != 0 {
+		t.Errorf("Unexpected generator %q or warnings %v", name, warnings)
+	}
+	if err := letter.Validate(); err != nil {
+		t.Errorf("Drafted letter is invalid: %v", err)
+	}
+	if letter.FirstName != req.Resume.Author.FirstName || letter.Position != req.Resume.Positions[0] {
+		t.Errorf("Header not taken from the resume: %+v", letter)
+	}
+	if !strings.Contains(letter.Opening, "Acme Sensors") || !strings.Contains(letter.WhyMe, "Embedded, ARM") {
+		t.Errorf("Draft should mention the company and matched skills:\n%s\n%s", letter.Opening, letter.WhyMe)
+	}
+
+	again, _, _, _ := CoverLetter(context.Background(), nil, req)
+	if !reflect.DeepEqual(again, letter) {
+		t.Error("Template drafts should be deterministic")
+	}
+
+	req.Options.Tone = "concise"
+	concise, _, _, _ := CoverLetter(context.Background(), nil, req)
+	if concise.Opening == letter.Opening {
+		t.Error("The tone should change the wording")
+	}
+}
+
+func TestOpenAIGeneratorDraft(t *testing.T) {
+	var received chatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Unexpected request %s with auth %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+		answer := "```json\n" + `{"opening": "Hello.", "about_me": "About.", "why_me": "Why me.", "why_company": "Why you."}` + "\n```"
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": answer}}},
+		})
+	}))
+	defer server.Close()
+
+	req := draftRequest(t)
+	req.Options.Tone = "friendly"
+	generator := NewOpenAIGenerator(server.URL+"/v1/", "secret", "test-model", 5*time.Second)
+	letter, name, warnings, err := CoverLetter(context.Background(), generator, req)
+	if err != nil {
+		t.Fatalf("CoverLetter failed: %v", err)
+	}
+	if name != "openai" || len(warnings) != 0 {
+		t.Errorf("Unexpected generator %q or warnings %v", name, warnings)
+	}
+	if letter.Opening != "Hello." || letter.WhyCompany != "Why you." || letter.Email != req.Resume.Author.Email {
+		t.Errorf("Unexpected letter: %+v", letter)
+	}
+	if received.Model != "test-model" || len(received.Messages) != 2 {
+		t.Fatalf("Unexpected request: %+v", received)
+	}
+	if prompt := received.Messages[1].Content; !strings.Contains(prompt, "Tone: friendly") || !strings.Contains(prompt, "Company: Acme Sensors") {
+		t.Errorf("Prompt is missing the options:\n%s", prompt)
+	}
+}
+
+func TestCoverLetterFallsBack(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]string{"message": "rate limited"}})
+	}))
+	defer server.Close()
+
+	req := draftRequest(t)
+	letter, name, warnings, err := CoverLetter(context.Background(), NewOpenAIGenerator(server.URL, "", "m", time.Second), req)
+	if err != nil {
+		t.Fatalf("CoverLetter failed: %v", err)
+	}
+	if name != "template" || letter.Opening == "" {
+		t.Errorf("Expected a template draft, got %q: %+v", name, letter)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "rate limited") {
+		t.Errorf("Expected a warning with the API error, got %v", warnings)
+	}
+}
@@ -0,0 +1,14 @@
+// Package examples embeds the example cover letter and resume documents.
+package examples
+
+import _ "embed"
+
+// CoverLetter is an example cover letter document
+//
+//go:embed coverletter.json
+var CoverLetter []byte
+
+// Resume is an example resume document
+//
+//go:embed resume.json
+var Resume []byte
@@ -0,0 +1,180 @@
+// Package i18n holds the message catalog with the fixed texts of the templates in every supported language.
+package i18n
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguage is used when no language is requested or the requested one is not in the catalog
+const DefaultLanguage = "en"
+
+// Messages holds the fixed texts of the resume and cover letter templates in one language
+type Messages struct {
+	Language string
+	// Section headings of the resume
+	Summary        string
+	Education      string
+	WorkExperience string
+	Projects       string
+	Skills         string
+	Interests      string
+	// Salutation is the default greeting of a cover letter; {addressee} is replaced by the addressee
+	Salutation string
+	// Closing is the default complimentary close of a cover letter
+	Closing string
+	// JobApplication is the cover letter heading used by the DOCX export; {position} is replaced by the position
+	JobApplication string
+
+	// Dates holds the words used to format dates
+	Dates DateLocale
+}
+
+// DateLocale holds the words used to format dates in one language
+type DateLocale struct {
+	Months    [12]string
+	Ongoing   string
+	Separator string
+	// YearFirst formats dates as "2020年6月" instead of "Jun. 2020"
+	YearFirst bool
+}
+
+// FormatYearMonth formats a single point in time; month is 0 when only the year is known
+func (l DateLocale) FormatYearMonth(year, month int) string {
+	if month < 1 || month > 12 {
+		if l.YearFirst {
+			return fmt.Sprintf("%d年", year)
+		}
+		return strconv.Itoa(year)
+	}
+	if l.YearFirst {
+		return fmt.Sprintf("%d年%d月", year, month)
+	}
+	return fmt.Sprintf("%s %d", l.Months[month-1], year)
+}
+
+// messageCatalog maps language codes to their messages
+var messageCatalog = map[string]Messages{
+	"en": {
+		Language:       "en",
+		Summary:        "Summary",
+		Education:      "Education",
+		WorkExperience: "Working Experience",
+		Projects:       "Projects",
+		Skills:         "Skills",
+		Interests:      "Interests",
+		Salutation:     "Dear {addressee},",
+		Closing:        "Sincerely,",
+		JobApplication: "Job Application for {position}",
+		Dates: DateLocale{
+			Months:    [12]string{"Jan.", "Feb.", "Mar.", "Apr.", "May", "Jun.", "Jul.", "Aug.", "Sept.", "Oct.", "Nov.", "Dec."},
+			Ongoing:   "Present",
+			Separator: " - ",
+		},
+	},
+	"sv": {
+		Language:       "sv",
+		Summary:        "Sammanfattning",
+		Education:      "Utbildning",
+		WorkExperience: "Arbetslivserfarenhet",
+		Projects:       "Projekt",
+		Skills:         "Färdigheter",
+		Interests:      "Intressen",
+		Salutation:     "Hej {addressee},",
+		Closing:        "Med vänliga hälsningar,",
+		JobApplication: "Ansökan till tjänsten som {position}",
+		Dates: DateLocale{
+			Months:    [12]string{"jan.", "feb.", "mars", "apr.", "maj", "juni", "juli", "aug.", "sep.", "okt.", "nov.", "dec."},
+			Ongoing:   "nu",
+			Separator: " - ",
+		},
+	},
+	"de": {
+		Language:       "de",
+		Summary:        "Zusammenfassung",
+		Education:      "Ausbildung",
+		WorkExperience: "Berufserfahrung",
+		Projects:       "Projekte",
+		Skills:         "Kenntnisse",
+		Interests:      "Interessen",
+		Salutation:     "Sehr geehrte Damen und Herren,",
+		Closing:        "Mit freundlichen Grüßen",
+		JobApplication: "Bewerbung als {position}",
+		Dates: DateLocale{
+			Months:    [12]string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sept.", "Okt.", "Nov.", "Dez."},
+			Ongoing:   "heute",
+			Separator: " - ",
+		},
+	},
+	"zh": {
+		Language:       "zh",
+		Summary:        "个人简介",
+		Education:      "教育背景",
+		WorkExperience: "工作经历",
+		Projects:       "项目经历",
+		Skills:         "专业技能",
+		Interests:      "兴趣爱好",
+		Salutation:     "尊敬的{addressee}：",
+		Closing:        "此致敬礼",
+		JobApplication: "应聘{position}",
+		Dates: DateLocale{
+			Ongoing:   "至今",
+			Separator: " - ",
+			YearFirst: true,
+		},
+	},
+}
+
+// Normalize reduces a language tag such as "sv-SE" or "zh_CN" to its lower-case language code
+func Normalize(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if i := strings.IndexAny(language, "-_"); i != -1 {
+		language = language[:i]
+	}
+	return language
+}
+
+// Get returns the messages for a language, falling back to English
+func Get(language string) Messages {
+	if messages, ok := messageCatalog[Normalize(language)]; ok {
+		return messages
+	}
+	return messageCatalog[DefaultLanguage]
+}
+
+// SalutationFor returns the default salutation addressed to the given recipient
+func (m Messages) SalutationFor(addressee string) string {
+	return strings.ReplaceAll(m.Salutation, "{addressee}", addressee)
+}
+
+// JobApplicationFor returns the cover letter heading for a position
+func (m Messages) JobApplicationFor(position string) string {
+	return strings.ReplaceAll(m.JobApplication, "{position}", position)
+}
+
+var typstHeadingPattern = regexp.MustCompile(`(?m)^=[ \t]+(.+?)[ \t]*$`)
+
+// DetectResume picks the catalog entry whose section headings best match those in a Typst resume
+func DetectResume(content string) Messages {
+	headings := make(map[string]bool)
+	for _, match := range typstHeadingPattern.FindAllStringSubmatch(content, -1) {
+		headings[match[1]] = true
+	}
+
+	best, bestScore := messageCatalog[DefaultLanguage], 0
+	for _, language := range []string{"en", "sv", "de", "zh"} {
+		messages := messageCatalog[language]
+		score := 0
+		for _, heading := range []string{messages.Summary, messages.Education, messages.WorkExperience, messages.Projects, messages.Skills, messages.Interests} {
+			if headings[heading] {
+				score++
+			}
+		}
+		if score > bestScore {
+			best, bestScore = messages, score
+		}
+	}
+	return best
+}
@@ -0,0 +1,12 @@
+package i18n
+
+import "testing"
+
+func TestGetFallsBackToEnglish(t *testing.T) {
+	if got := Get("sv-SE").Language; got != "sv" {
+		t.Errorf("Get(sv-SE) = %q, want sv", got)
+	}
+	if got := Get("fr").Language; got != DefaultLanguage {
+		t.Errorf("Get(fr) = %q, want %s", got, DefaultLanguage)
+	}
+}
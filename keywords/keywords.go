@@ -0,0 +1,397 @@
+// Package keywords compares the keywords of a job posting with a resume and cover letter.
+package keywords
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"cvcl-render/model"
+)
+
+// Match is a keyword of a job posting and where the application mentions it
+type Match struct {
+	Keyword string `json:"keyword"`
+	// Occurrences counts the mentions in the job description
+	Occurrences int `json:"occurrences"`
+	// Technical is set for terms from the technology dictionary
+	Technical bool `json:"technical"`
+	// FoundIn lists the parts of the application mentioning the keyword
+	FoundIn []string `json:"found_in,omitempty"`
+}
+
+// Report compares a job posting with a resume and cover letter
+type Report struct {
+	// Coverage is the fraction of keywords found in the application, from 0 to 1
+	Coverage float64  `json:"coverage"`
+	Keywords []Match  `json:"keywords"`
+	Missing  []string `json:"missing"`
+	// SuggestedStrong lists skills that the posting asks for but are not marked strong
+	SuggestedStrong []string `json:"suggested_strong"`
+}
+
+// maxPhraseKeywords limits the number of n-gram keywords taken from a job description
+const maxPhraseKeywords = 20
+
+// techTerms maps the display name of technology terms to their lower-case spellings
+var techTerms = map[string][]string{
+	"C":                      {"c"},
+	"C++":                    {"c++", "cpp"},
+	"C#":                     {"c#", "csharp"},
+	"Go":                     {"go", "golang"},
+	"Rust":                   {"rust"},
+	"Python":                 {"python"},
+	"Java":                   {"java"},
+	"Kotlin":                 {"kotlin"},
+	"Scala":                  {"scala"},
+	"Erlang":                 {"erlang"},
+	"Elixir":                 {"elixir"},
+	"Haskell":                {"haskell"},
+	"Ruby":                   {"ruby"},
+	"PHP":                    {"php"},
+	"Swift":                  {"swift"},
+	"Lua":                    {"lua"},
+	"JavaScript":             {"javascript", "js", "ecmascript"},
+	"TypeScript":             {"typescript", "ts"},
+	"HTML":                   {"html", "html5"},
+	"CSS":                    {"css", "css3"},
+	"SQL":                    {"sql"},
+	"WebAssembly":            {"webassembly", "wasm"},
+	"Verilog":                {"verilog", "systemverilog"},
+	"VHDL":                   {"vhdl"},
+	"Assembly":               {"assembly", "asm"},
+	"React":                  {"react", "react.js", "reactjs"},
+	"Next.js":                {"next.js", "nextjs"},
+	"Vue":                    {"vue", "vue.js", "vuejs"},
+	"Angular":                {"angular"},
+	"Svelte":                 {"svelte"},
+	"Node.js":                {"node.js", "nodejs"},
+	"TailwindCSS":            {"tailwindcss", "tailwind"},
+	"GraphQL":                {"graphql"},
+	"REST":                   {"restful", "rest api", "rest apis"},
+	"gRPC":                   {"grpc"},
+	"PostgreSQL":             {"postgresql", "postgres"},
+	"MySQL":                  {"mysql"},
+	"SQLite":                 {"sqlite"},
+	"MongoDB":                {"mongodb", "mongo"},
+	"Redis":                  {"redis"},
+	"Kafka":                  {"kafka"},
+	"Elasticsearch":          {"elasticsearch"},
+	"Docker":                 {"docker"},
+	"Kubernetes":             {"kubernetes", "k8s"},
+	"Terraform":              {"terraform"},
+	"Ansible":                {"ansible"},
+	"AWS":                    {"aws", "amazon web services"},
+	"GCP":                    {"gcp", "google cloud"},
+	"Azure":                  {"azure"},
+	"Cloudflare":             {"cloudflare"},
+	"Linux":                  {"linux"},
+	"Git":                    {"git"},
+	"CI/CD":                  {"ci/cd", "continuous integration", "continuous delivery"},
+	"Microservices":          {"microservices", "micro services", "microservice"},
+	"Serverless":             {"serverless"},
+	"Distributed systems":    {"distributed systems", "distributed system"},
+	"Databases":              {"databases", "database", "dbms"},
+	"Compilers":              {"compilers", "compiler"},
+	"Operating systems":      {"operating systems", "operating system", "os kernel"},
+	"RTOS":                   {"rtos", "real-time operating system", "freertos"},
+	"Embedded":               {"embedded", "embedded systems", "firmware"},
+	"Microcontrollers":       {"microcontrollers", "microcontroller", "mcu", "stm32"},
+	"RISC-V":                 {"risc-v", "riscv"},
+	"ARM":                    {"armv7", "armv8", "aarch64", "cortex-m"},
+	"FPGA":                   {"fpga"},
+	"PCB design":             {"pcb", "pcb design"},
+	"Machine learning":       {"machine learning", "ml"},
+	"LLM":                    {"llm", "llms", "large language models"},
+	"Testing":                {"testing", "unit testing", "test automation"},
+	"Agile":                  {"agile", "scrum"},
+	"OAuth":                  {"oauth", "oauth2"},
+	"Security":               {"security"},
+	"Concurrency":            {"concurrency", "concurrent programming"},
+	"Formal methods":         {"formal methods", "formal verification"},
+	"Progressive Web Apps":   {"pwa", "progressive web app", "progressive web apps"},
+	"Real-time systems":      {"real-time systems", "real time systems", "real-time"},
+	"Transaction processing": {"transactions", "transaction processing"},
+}
+
+// keywordStopwords are ignored when extracting n-grams from job descriptions
+var keywordStopwords = toSet(strings.Fields(`
+a about above across after again against all also am an and any are as at be because been before being
+below between both but by can could did do does doing down during each either etc few for from further
+had has have having he her here hers him his how i if in into is it its itself just least less let like
+made make many may me might more most much must my no nor not now of off often on once one only or other
+our ours out over own per please plus same shall she should so some such than that the their theirs them
+then there these they this those through to too under until up upon us very via want was we well were
+what when where which while who whom why will with within without would yet you your yours
+ability able across advantage apply applicant applicants bonus candidate candidates company day
+degree environment equivalent etc experience experienced familiarity familiar good great help ideal
+including job join knowledge looking new nice opportunity plus position preferred proficiency
+proficient required requirements responsibilities role skills strong team teams understanding work
+working year years
+`))
+
+func toSet(words []string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[word] = true
+	}
+	return set
+}
+
+var (
+	keywordTokenPattern = regexp.MustCompile(`[\pL\pN][\pL\pN+#]*(?:[.-][\pL\pN+#]+)*`)
+	typstLinkURLPattern = regexp.MustCompile(`#link\("[^"]*"\)`)
+	numberPattern       = regexp.MustCompile(`^[\pN.,/-]+$`)
+)
+
+// keywordTokens splits text into lower-case tokens, keeping terms such as "c++", "node.js" and "risc-v" whole
+func keywordTokens(text string) []string {
+	text = typstLinkURLPattern.ReplaceAllString(text, " ")
+	return keywordTokenPattern.FindAllString(strings.ToLower(text), -1)
+}
+
+// countSequence counts the occurrences of a token sequence in tokens
+func countSequence(tokens, sequence []string) int {
+	count := 0
+	for i := 0; i+len(sequence) <= len(tokens); i++ {
+		match := true
+		for j, token := range sequence {
+			if tokens[i+j] != token {
+				match = false
+				break
+			}
+		}
+		if match {
+			count++
+		}
+	}
+	return count
+}
+
+// countMentions counts the non-overlapping mentions of any of the spellings in tokens
+func countMentions(tokens []string, spellings [][]string) int {
+	count := 0
+	for i := 0; i < len(tokens); {
+		length := 0
+		for _, spelling := range spellings {
+			if len(spelling) > length && i+len(spelling) <= len(tokens) && countSequence(tokens[i:i+len(spelling)], spelling) == 1 {
+				length = len(spelling)
+			}
+		}
+		if length == 0 {
+			i++
+			continue
+		}
+		count++
+		i += length
+	}
+	return count
+}
+
+// keyword is an extracted keyword with all the spellings that count as a mention
+type keyword struct {
+	Match
+	spellings [][]string
+	score     int
+}
+
+// mentions reports whether any spelling of the keyword occurs in tokens
+func (k keyword) mentions(tokens []string) bool {
+	return countMentions(tokens, k.spellings) > 0
+}
+
+// extractKeywords finds the technology terms and frequent phrases of a job description
+func extractKeywords(jobDescription string) []keyword {
+	tokens := keywordTokens(jobDescription)
+
+	var keywords []keyword
+	covered := make(map[string]bool)
+	names := make([]string, 0, len(techTerms))
+	for name := range techTerms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		k := keyword{Match: Match{Keyword: name, Technical: true}}
+		for _, alias := range techTerms[name] {
+			k.spellings = append(k.spellings, keywordTokens(alias))
+		}
+		k.Occurrences = countMentions(tokens, k.spellings)
+		if k.Occurrences == 0 {
+			continue
+		}
+		for _, spelling := range k.spellings {
+			for _, token := range spelling {
+				covered[token] = true
+			}
+		}
+		// Technology terms always rank above plain phrases
+		k.score = 1000 + k.Occurrences
+		keywords = append(keywords, k)
+	}
+
+	// Count the n-grams without stopwords, numbers or words of the technology terms found
+	counts := make(map[string]int)
+	for n := 1; n <= 3; n++ {
+		for i := 0; i+n <= len(tokens); i++ {
+			gram := tokens[i : i+n]
+			valid := true
+			for _, token := range gram {
+				if keywordStopwords[token] || covered[token] || numberPattern.MatchString(token) || len([]rune(token)) < 2 {
+					valid = false
+					break
+				}
+			}
+			if valid {
+				counts[strings.Join(gram, " ")]++
+			}
+		}
+	}
+
+	var phrases []keyword
+	for phrase, count := range counts {
+		if count < 2 {
+			continue
+		}
+		spelling := strings.Fields(phrase)
+		phrases = append(phrases, keyword{
+			Match:     Match{Keyword: phrase, Occurrences: count},
+			spellings: [][]string{spelling},
+			score:     count * len(spelling),
+		})
+	}
+	sort.Slice(phrases, func(a, b int) bool {
+		if phrases[a].score != phrases[b].score {
+			return phrases[a].score > phrases[b].score
+		}
+		return phrases[a].Keyword < phrases[b].Keyword
+	})
+
+	// Drop phrases that only occur as part of a longer phrase already chosen
+	var chosen []keyword
+	for _, phrase := range phrases {
+		if len(chosen) == maxPhraseKeywords {
+			break
+		}
+		subsumed := false
+		for _, longer := range chosen {
+			if len(longer.spellings[0]) > len(phrase.spellings[0]) &&
+				countSequence(longer.spellings[0], phrase.spellings[0]) > 0 && longer.Occurrences >= phrase.Occurrences {
+				subsumed = true
+				break
+			}
+		}
+		if !subsumed {
+			chosen = append(chosen, phrase)
+		}
+	}
+	keywords = append(keywords, chosen...)
+
+	sort.SliceStable(keywords, func(a, b int) bool {
+		if keywords[a].score != keywords[b].score {
+			return keywords[a].score > keywords[b].score
+		}
+		return keywords[a].Keyword < keywords[b].Keyword
+	})
+	return keywords
+}
+
+// applicationSections returns the tokens of every part of an application, keyed by section name
+func applicationSections(resume *model.ResumeData, coverLetter *model.CoverLetterData) ([]string, map[string][]string) {
+	sections := make(map[string][]string)
+	add := func(name string, texts ...string) {
+		for _, text := range texts {
+			sections[name] = append(sections[name], keywordTokens(text)...)
+			// Separate texts so that phrases do not match across them
+			sections[name] = append(sections[name], "")
+		}
+	}
+
+	if resume != nil {
+		add("positions", resume.Positions...)
+		add("summary", resume.Summary)
+		for _, section := range []struct {
+			name    string
+			entries []model.ResumeEntry
+		}{
+			{"education", resume.Education},
+			{"work_experience", resume.WorkExperience},
+			{"projects", resume.Projects},
+		} {
+			for _, entry := range section.entries {
+				add(section.name, entry.Title, entry.Description, entry.Content)
+				for _, bullet := range entry.Bullets {
+					add(section.name, bullet.Text)
+				}
+			}
+		}
+		for _, category := range resume.Skills {
+			for _, skill := range category.Skills {
+				add("skills", skill.Name)
+			}
+		}
+		for _, interest := range resume.Interests {
+			add("interests", interest.Category, interest.Description)
+		}
+	}
+	if coverLetter != nil {
+		for _, paragraph := range coverLetter.ContentParagraphs() {
+			add("cover_letter", paragraph.Heading, paragraph.Text)
+		}
+	}
+
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, sections
+}
+
+// Compare compares a job description with a resume and an optional cover letter.
+// The result only depends on its inputs: keywords are extracted with a fixed dictionary
+// and frequency rules, and all lists are sorted deterministically.
+func Compare(jobDescription string, resume *model.ResumeData, coverLetter *model.CoverLetterData) Report {
+	keywords := extractKeywords(jobDescription)
+	names, sections := applicationSections(resume, coverLetter)
+
+	report := Report{Keywords: []Match{}, Missing: []string{}, SuggestedStrong: []string{}}
+	found := 0
+	for _, k := range keywords {
+		for _, name := range names {
+			if k.mentions(sections[name]) {
+				k.FoundIn = append(k.FoundIn, name)
+			}
+		}
+		if len(k.FoundIn) > 0 {
+			found++
+		} else {
+			report.Missing = append(report.Missing, k.Keyword)
+		}
+		report.Keywords = append(report.Keywords, k.Match)
+	}
+	if len(keywords) > 0 {
+		report.Coverage = math.Round(float64(found)/float64(len(keywords))*100) / 100
+	}
+
+	if resume != nil {
+		suggested := make(map[string]bool)
+		for _, category := range resume.Skills {
+			for _, skill := range category.Skills {
+				if skill.Strong || suggested[skill.Name] {
+					continue
+				}
+				tokens := keywordTokens(skill.Name)
+				for _, k := range keywords {
+					if k.mentions(tokens) {
+						suggested[skill.Name] = true
+						report.SuggestedStrong = append(report.SuggestedStrong, skill.Name)
+						break
+					}
+				}
+			}
+		}
+	}
+	return report
+}
@@ -0,0 +1,102 @@
+package keywords
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"cvcl-render/examples"
+	"cvcl-render/model"
+)
+
+const sampleJobDescription = `Embedded Software Engineer
+
+We are looking for an embedded software engineer to build firmware for our sensor platform.
+You will write Rust and C for ARM Cortex-M microcontrollers, and work with our cloud team on
+Kubernetes deployments. Experience with RTOS kernels, CI/CD and low power design is a plus.
+Low power design experience and sensor platform knowledge are highly valued.`
+
+func TestExtractKeywords(t *testing.T) {
+	keywords := extractKeywords(sampleJobDescription)
+	byName := map[string]keyword{}
+	for _, k := range keywords {
+		byName[k.Keyword] = k
+	}
+	for _, want := range []string{"Embedded", "Rust", "C", "ARM", "Microcontrollers", "Kubernetes", "RTOS", "CI/CD"} {
+		if k, ok := byName[want]; !ok || !k.Technical {
+			t.Errorf("Missing technology keyword %q in %v", want, keywords)
+		}
+	}
+	for _, want := range []string{"low power design", "sensor platform"} {
+		if k, ok := byName[want]; !ok || k.Occurrences != 2 {
+			t.Errorf("Missing phrase %q in %+v", want, keywords)
+		}
+	}
+	// Parts of a chosen phrase are not reported separately
+	if _, ok := byName["power design"]; ok {
+		t.Error("Sub-phrase should be dropped")
+	}
+	if k := byName["Embedded"]; k.Occurrences != 3 {
+		t.Errorf("Embedded occurrences = %d, want 3", k.Occurrences)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	var resume model.ResumeData
+	if err := json.Unmarshal(examples.Resume, &resume); err != nil {
+		t.Fatalf("Failed to parse example: %v", err)
+	}
+	coverLetter := &model.CoverLetterData{Paragraphs: []model.Paragraph{{Text: "I have designed a low power design for a sensor platform."}}}
+
+	withoutLetter := Compare(sampleJobDescription, &resume, nil)
+	if want := []string{"low power design", "sensor platform"}; !reflect.DeepEqual(withoutLetter.Missing, want) {
+		t.Errorf("Missing = %v, want %v", withoutLetter.Missing, want)
+	}
+	if withoutLetter.Coverage >= 1 {
+		t.Errorf("Unexpected coverage %v", withoutLetter.Coverage)
+	}
+
+	report := Compare(sampleJobDescription, &resume, coverLetter)
+	if report.Coverage != 1 || len(report.Missing) != 0 {
+		t.Errorf("Cover letter should cover the remaining keywords: %+v", report)
+	}
+	found := map[string][]string{}
+	for _, k := range report.Keywords {
+		found[k.Keyword] = k.FoundIn
+	}
+	if in := found["Rust"]; !contains(in, "skills") {
+		t.Errorf("Rust should be found in skills, got %v", in)
+	}
+	if in := found["low power design"]; !reflect.DeepEqual(in, []string{"cover_letter"}) {
+		t.Errorf("Phrase should be found in the cover letter, got %v", in)
+	}
+	if !contains(report.SuggestedStrong, "Kubernetes") {
+		t.Errorf("Kubernetes should be suggested as strong: %v", report.SuggestedStrong)
+	}
+
+	again := Compare(sampleJobDescription, &resume, coverLetter)
+	if !reflect.DeepEqual(report, again) {
+		t.Error("Keyword matching is not deterministic")
+	}
+}
+
+func TestCompareSuggestsStrongSkills(t *testing.T) {
+	resume := &model.ResumeData{Skills: []model.SkillCategory{{Name: "Languages", Skills: []model.SkillItem{
+		{Name: "Rust", Strong: true},
+		{Name: "C/C++"},
+		{Name: "Kotlin"},
+	}}}}
+	report := Compare("Strong C++ skills required. C++ and Rust.", resume, nil)
+	if !reflect.DeepEqual(report.SuggestedStrong, []string{"C/C++"}) {
+		t.Errorf("SuggestedStrong = %v, want [C/C++]", report.SuggestedStrong)
+	}
+}
+
+func contains(values []string, want string) bool {
+	for _, value := range values {
+		if value == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,666 @@
+// Package latex imports moderncv and awesome-cv resumes written in LaTeX.
+package latex
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"cvcl-render/model"
+	"cvcl-render/typstparse"
+)
+
+// ImportResult holds a resume imported from a LaTeX CV and the macros that were not understood
+type ImportResult struct {
+	Resume       model.ResumeData `json:"resume"`
+	Class        string           `json:"class"`
+	Unrecognized []string         `json:"unrecognized,omitempty"`
+}
+
+// Supported LaTeX CV document classes
+const (
+	ClassModernCV  = "moderncv"
+	ClassAwesomeCV = "awesome-cv"
+)
+
+// latexIgnoredMacros are layout and preamble macros that carry no resume content
+var latexIgnoredMacros = map[string]bool{
+	"documentclass": true, "usepackage": true, "moderncvstyle": true, "moderncvcolor": true,
+	"moderncvtheme": true, "geometry": true, "makecvtitle": true, "makecvheader": true,
+	"makecvfooter": true, "makelettertitle": true, "setlength": true, "recomputelengths": true,
+	"nopagenumbers": true, "colorlet": true, "setbool": true, "fontdir": true, "definecolor": true,
+	"renewcommand": true, "newcommand": true, "pagestyle": true, "thispagestyle": true,
+	"vspace": true, "hspace": true, "newpage": true, "clearpage": true, "pagebreak": true,
+	"centering": true, "hfill": true, "vfill": true, "noindent": true, "medskip": true,
+	"smallskip": true, "bigskip": true, "par": true, "photo": true, "quote": true,
+	"acvHeaderSocialSep": true, "end": true, "\\": true, "newline": true, "linebreak": true,
+	"sethyphenation": true, "hyphenation": true, "bfseries": true, "itshape": true,
+	"normalfont": true, "color": true,
+}
+
+// latexTransparentEnvironments only wrap other content and are otherwise ignored
+var latexTransparentEnvironments = map[string]bool{
+	"document": true, "cventries": true, "cvhonors": true, "cvskills": true,
+	"center": true, "minipage": true, "flushleft": true, "flushright": true,
+}
+
+// latexImporter keeps the state of an import in progress
+type latexImporter struct {
+	class        string
+	resume       model.ResumeData
+	section      string
+	sectionName  string
+	unrecognized map[string]bool
+}
+
+// ImportResume imports a moderncv or awesome-cv source into model.ResumeData.
+// If class is empty it is detected from \documentclass.
+func ImportResume(content string, class string) (*ImportResult, error) {
+	content = stripLaTeXComments(content)
+
+	if class == "" {
+		class = detectLaTeXClass(content)
+		if class == "" {
+			return nil, fmt.Errorf("unsupported LaTeX document class, expected moderncv or awesome-cv")
+		}
+	}
+	if class != ClassModernCV && class != ClassAwesomeCV {
+		return nil, fmt.Errorf("unsupported LaTeX document class: %s", class)
+	}
+
+	imp := &latexImporter{
+		class:        class,
+		unrecognized: make(map[string]bool),
+	}
+	imp.run(content)
+
+	result := &ImportResult{
+		Resume: imp.resume,
+		Class:  class,
+	}
+	for name := range imp.unrecognized {
+		result.Unrecognized = append(result.Unrecognized, name)
+	}
+	sort.Strings(result.Unrecognized)
+	return result, nil
+}
+
+var latexDocumentClassPattern = regexp.MustCompile(`\\documentclass\s*(?:\[[^\]]*\])?\s*\{([^}]*)\}`)
+
+// detectLaTeXClass returns the CV class named in \documentclass, or empty if unsupported
+func detectLaTeXClass(content string) string {
+	match := latexDocumentClassPattern.FindStringSubmatch(content)
+	if len(match) < 2 {
+		return ""
+	}
+	switch strings.TrimSpace(match[1]) {
+	case "moderncv":
+		return ClassModernCV
+	case "awesome-cv":
+		return ClassAwesomeCV
+	}
+	return ""
+}
+
+// stripLaTeXComments removes % comments while keeping escaped \%
+func stripLaTeXComments(content string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		for j := 0; j < len(line); j++ {
+			if line[j] == '\\' {
+				j++
+				continue
+			}
+			if line[j] == '%' {
+				lines[i] = line[:j]
+				break
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// readLaTeXGroup reads a balanced group starting at src[p] == open and returns its content
+// and the position after the closing delimiter
+func readLaTeXGroup(src string, p int, open, close byte) (string, int, bool) {
+	depth := 0
+	for i := p; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return src[p+1 : i], i + 1, true
+			}
+		}
+	}
+	return "", p, false
+}
+
+// readLaTeXMacroName reads the macro name after the backslash at src[p]
+func readLaTeXMacroName(src string, p int) (string, int) {
+	i := p + 1
+	if i >= len(src) {
+		return "", i
+	}
+	if !isLaTeXLetter(src[i]) {
+		return src[i : i+1], i + 1
+	}
+	for i < len(src) && isLaTeXLetter(src[i]) {
+		i++
+	}
+	name := src[p+1 : i]
+	if i < len(src) && src[i] == '*' {
+		i++
+	}
+	return name, i
+}
+
+func isLaTeXLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '@'
+}
+
+// readLaTeXArgs reads the optional [...] and mandatory {...} arguments following a macro
+func readLaTeXArgs(src string, p int) (opts []string, args []string, end int) {
+	end = p
+	for {
+		i := end
+		for i < len(src) && (src[i] == ' ' || src[i] == '\t' || src[i] == '\n' || src[i] == '\r') {
+			i++
+		}
+		if i >= len(src) {
+			return
+		}
+		switch src[i] {
+		case '[':
+			opt, next, ok := readLaTeXGroup(src, i, '[', ']')
+			if !ok {
+				return
+			}
+			opts = append(opts, opt)
+			end = next
+		case '{':
+			arg, next, ok := readLaTeXGroup(src, i, '{', '}')
+			if !ok {
+				return
+			}
+			args = append(args, arg)
+			end = next
+		default:
+			return
+		}
+	}
+}
+
+// latexArg returns the i-th argument or an empty string
+func latexArg(args []string, i int) string {
+	if i < len(args) {
+		return args[i]
+	}
+	return ""
+}
+
+// latexInlineMacros format running text and are converted together with it
+var latexInlineMacros = map[string]bool{
+	"href": true, "url": true, "textbf": true, "emph": true, "textit": true, "textsc": true,
+	"textup": true, "textrm": true, "textsf": true, "texttt": true, "mbox": true, "text": true,
+	"textnormal": true, "item": true,
+}
+
+// run walks the top level of the document and dispatches macros.
+// Running text, including inline formatting macros, is collected and handled as a whole.
+func (imp *latexImporter) run(src string) {
+	textStart := -1
+	flushText := func(end int) {
+		if textStart != -1 {
+			imp.text(src[textStart:end])
+			textStart = -1
+		}
+	}
+
+	p := 0
+	for p < len(src) {
+		if src[p] != '\\' {
+			if textStart == -1 {
+				textStart = p
+			}
+			p++
+			continue
+		}
+
+		name, next := readLaTeXMacroName(src, p)
+		if _, symbol := latexSymbols[name]; symbol || latexInlineMacros[name] {
+			if textStart == -1 {
+				textStart = p
+			}
+			if latexInlineMacros[name] {
+				_, _, next = readLaTeXArgs(src, next)
+			}
+			p = next
+			continue
+		}
+
+		flushText(p)
+		if name == "begin" {
+			_, args, end := readLaTeXArgs(src, next)
+			env := latexArg(args, 0)
+			if latexTransparentEnvironments[env] {
+				p = end
+				continue
+			}
+			inner, after := readLaTeXEnvironment(src, end, env)
+			imp.environment(env, inner)
+			p = after
+			continue
+		}
+		opts, args, end := readLaTeXArgs(src, next)
+		imp.macro(name, opts, args)
+		p = end
+	}
+	flushText(len(src))
+}
+
+// readLaTeXEnvironment returns the body of an environment and the position after its \end
+func readLaTeXEnvironment(src string, p int, env string) (string, int) {
+	endMarker := `\end{` + env + `}`
+	idx := strings.Index(src[p:], endMarker)
+	if idx == -1 {
+		return src[p:], len(src)
+	}
+	return src[p : p+idx], p + idx + len(endMarker)
+}
+
+// environment handles a top-level environment with content
+func (imp *latexImporter) environment(env string, inner string) {
+	switch env {
+	case "cvparagraph", "itemize", "cvitems", "enumerate":
+		if env != "cvparagraph" {
+			inner = `\begin{itemize}` + inner + `\end{itemize}`
+		}
+		imp.text(inner)
+	default:
+		imp.unrecognized[`\begin{`+env+`}`] = true
+	}
+}
+
+// text handles running text outside of any entry macro
+func (imp *latexImporter) text(raw string) {
+	converted := imp.convert(raw)
+	if converted == "" {
+		return
+	}
+	if imp.section == "summary" {
+		if imp.resume.Summary != "" {
+			imp.resume.Summary += "\n"
+		}
+		imp.resume.Summary += converted
+	}
+}
+
+// latexSectionKind maps a section title to a model.ResumeData section
+func latexSectionKind(title string) string {
+	t := strings.ToLower(title)
+	switch {
+	case strings.Contains(t, "summary"), strings.Contains(t, "profile"), strings.Contains(t, "about"), strings.Contains(t, "objective"):
+		return "summary"
+	case strings.Contains(t, "education"), strings.Contains(t, "academic"):
+		return "education"
+	case strings.Contains(t, "experience"), strings.Contains(t, "employment"), strings.Contains(t, "work"), strings.Contains(t, "career"):
+		return "work"
+	case strings.Contains(t, "project"):
+		return "projects"
+	case strings.Contains(t, "skill"), strings.Contains(t, "language"), strings.Contains(t, "competenc"):
+		return "skills"
+	case strings.Contains(t, "interest"), strings.Contains(t, "hobb"):
+		return "interests"
+	}
+	return "other"
+}
+
+// macro handles a top-level macro with its arguments
+func (imp *latexImporter) macro(name string, opts []string, args []string) {
+	author := &imp.resume.Author
+	switch name {
+	case "name":
+		author.FirstName = imp.convert(latexArg(args, 0))
+		author.LastName = imp.convert(latexArg(args, 1))
+	case "firstname":
+		author.FirstName = imp.convert(latexArg(args, 0))
+	case "familyname", "lastname":
+		author.LastName = imp.convert(latexArg(args, 0))
+	case "email":
+		author.Email = imp.plain(latexArg(args, 0))
+	case "phone", "mobile":
+		author.Phone = imp.plain(latexArg(args, 0))
+	case "homepage":
+		author.Homepage = imp.plain(latexArg(args, 0))
+	case "github":
+		author.GitHub = imp.plain(latexArg(args, 0))
+	case "linkedin":
+		author.LinkedIn = imp.plain(latexArg(args, 0))
+	case "twitter":
+		author.Twitter = imp.plain(latexArg(args, 0))
+	case "dateofbirth", "born":
+		author.Birth = imp.plain(latexArg(args, 0))
+	case "social":
+		imp.social(latexArg(opts, 0), imp.plain(latexArg(args, 0)))
+	case "title", "position":
+		imp.positions(latexArg(args, 0))
+	case "section", "cvsection", "subsection", "cvsubsection":
+		title := imp.plain(latexArg(args, 0))
+		imp.sectionName = title
+		imp.section = latexSectionKind(title)
+		if imp.section == "other" {
+			imp.unrecognized[fmt.Sprintf("section %q", title)] = true
+		}
+	case "cventry":
+		imp.cventry(args)
+	case "cvitem", "cvitemwithcomment":
+		imp.cvitem(latexArg(args, 0), latexArg(args, 1))
+	case "cvskill":
+		imp.cvitem(latexArg(args, 0), latexArg(args, 1))
+	case "cvdoubleitem", "cvcomputer":
+		imp.cvitem(latexArg(args, 0), latexArg(args, 1))
+		imp.cvitem(latexArg(args, 2), latexArg(args, 3))
+	case "cvlistitem":
+		imp.cvitem("", latexArg(args, 0))
+	case "cvlistdoubleitem":
+		imp.cvitem("", latexArg(args, 0))
+		imp.cvitem("", latexArg(args, 1))
+	case "cvhonor":
+		// \cvhonor{award}{event}{location}{date}
+		imp.addEntry(model.ResumeEntry{
+			Title:       imp.convert(latexArg(args, 0)),
+			Description: imp.convert(latexArg(args, 1)),
+			Location:    imp.plain(latexArg(args, 2)),
+			Date:        imp.plain(latexArg(args, 3)),
+		})
+	case "address":
+		var lines []string
+		for _, arg := range args {
+			if line := imp.plain(arg); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		author.Address = strings.Join(lines, ", ")
+	case "extrainfo":
+		// Not representable in model.ResumeData
+		imp.unrecognized[`\`+name] = true
+	default:
+		if !latexIgnoredMacros[name] {
+			imp.unrecognized[`\`+name] = true
+		}
+	}
+}
+
+// latexSocialLinks maps the moderncv \social types that become profile links to their URL prefixes
+var latexSocialLinks = map[string]string{
+	"bitbucket":     "https://bitbucket.org/",
+	"gitlab":        "https://gitlab.com/",
+	"orcid":         "https://orcid.org/",
+	"researchgate":  "https://www.researchgate.net/profile/",
+	"stackoverflow": "https://stackoverflow.com/users/",
+}
+
+// social handles moderncv's \social[type]{value}
+func (imp *latexImporter) social(kind string, value string) {
+	author := &imp.resume.Author
+	switch kind {
+	case "github":
+		author.GitHub = value
+	case "linkedin":
+		author.LinkedIn = value
+	case "twitter":
+		author.Twitter = value
+	default:
+		prefix, ok := latexSocialLinks[kind]
+		if !ok {
+			imp.unrecognized[`\social[`+kind+`]`] = true
+			return
+		}
+		author.Links = append(author.Links, model.ProfileLink{Type: kind, URL: prefix + value, Label: value})
+	}
+}
+
+var latexPositionSeparator = regexp.MustCompile(`\{?\\enskip\\cdotp\\enskip\}?|\\cdotp|\\textbar|\|`)
+
+// positions splits a \title or \position value into the positions list
+func (imp *latexImporter) positions(raw string) {
+	for _, part := range latexPositionSeparator.Split(raw, -1) {
+		if position := imp.plain(part); position != "" {
+			imp.resume.Positions = append(imp.resume.Positions, position)
+		}
+	}
+}
+
+// cventry handles \cventry for both classes.
+// moderncv: {years}{title}{institution}{city}{grade}{description}
+// awesome-cv: {title}{organization}{location}{date}{description}
+func (imp *latexImporter) cventry(args []string) {
+	var title, organization, location, date, content string
+	if imp.class == ClassModernCV {
+		date, title, organization, location = latexArg(args, 0), latexArg(args, 1), latexArg(args, 2), latexArg(args, 3)
+		grade := imp.convert(latexArg(args, 4))
+		content = imp.convert(latexArg(args, 5))
+		if grade != "" {
+			content = strings.TrimSpace(grade + "\n" + content)
+		}
+	} else {
+		title, organization, location, date = latexArg(args, 0), latexArg(args, 1), latexArg(args, 2), latexArg(args, 3)
+		content = imp.convert(latexArg(args, 4))
+	}
+
+	entry := model.ResumeEntry{
+		Title:       imp.convert(title),
+		Description: imp.convert(organization),
+		Location:    imp.plain(location),
+		Date:        imp.plain(date),
+		Content:     content,
+	}
+	// Education entries are titled by the institution, as in the Typst resume
+	if imp.section == "education" && entry.Description != "" {
+		entry.Title, entry.Description = entry.Description, entry.Title
+	}
+	imp.addEntry(entry)
+}
+
+// addEntry appends an entry to the current section
+func (imp *latexImporter) addEntry(entry model.ResumeEntry) {
+	switch imp.section {
+	case "education":
+		imp.resume.Education = append(imp.resume.Education, entry)
+	case "work":
+		imp.resume.WorkExperience = append(imp.resume.WorkExperience, entry)
+	case "projects", "":
+		imp.resume.Projects = append(imp.resume.Projects, entry)
+	case "interests":
+		imp.resume.Interests = append(imp.resume.Interests, model.InterestItem{Category: entry.Title, Description: entry.Description})
+	default:
+		imp.unrecognized[fmt.Sprintf("entry %q in section %q", entry.Title, imp.sectionName)] = true
+	}
+}
+
+// cvitem handles a label/text pair according to the current section
+func (imp *latexImporter) cvitem(label string, text string) {
+	label = imp.plain(label)
+	switch imp.section {
+	case "skills":
+		imp.resume.Skills = append(imp.resume.Skills, model.SkillCategory{
+			Name:   label,
+			Skills: imp.skillItems(text),
+		})
+	case "interests":
+		imp.resume.Interests = append(imp.resume.Interests, model.InterestItem{
+			Category:    label,
+			Description: imp.convert(text),
+		})
+	case "summary":
+		imp.text(text)
+	default:
+		imp.addEntry(model.ResumeEntry{Title: label, Content: imp.convert(text)})
+	}
+}
+
+// skillItems splits a comma separated skill list, marking \textbf items as strong
+func (imp *latexImporter) skillItems(raw string) []model.SkillItem {
+	var items []model.SkillItem
+	var current strings.Builder
+	depth := 0
+	flush := func() {
+		name := imp.convert(current.String())
+		current.Reset()
+		if name == "" {
+			return
+		}
+		strong := strings.HasPrefix(name, "*") && strings.HasSuffix(name, "*") && len(name) > 2
+		if strong {
+			name = name[1 : len(name)-1]
+		}
+		items = append(items, model.SkillItem{Name: name, Strong: strong})
+	}
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case c == '\\' && i+1 < len(raw):
+			current.WriteByte(c)
+			current.WriteByte(raw[i+1])
+			i++
+			continue
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+		case (c == ',' || c == ';') && depth == 0:
+			flush()
+			continue
+		}
+		current.WriteByte(c)
+	}
+	flush()
+	return items
+}
+
+// plain converts LaTeX to text without any Typst markup
+func (imp *latexImporter) plain(raw string) string {
+	text := typstparse.StripInline(imp.convert(raw))
+	return typstparse.Unescape(strings.Join(strings.Fields(text), " "))
+}
+
+// convert translates LaTeX markup into the Typst markup stored in model.ResumeData fields
+func (imp *latexImporter) convert(raw string) string {
+	var out strings.Builder
+	imp.convertInto(&out, raw)
+
+	var lines []string
+	for _, line := range strings.Split(out.String(), "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// latexSymbols maps argument-less macros to their text
+var latexSymbols = map[string]string{
+	"&": "&", "%": "%", "$": `\$`, "#": `\#`, "_": `\_`, "{": "{", "}": "}",
+	" ": " ", ",": " ", "enskip": " ", "quad": " ", "qquad": " ", "cdotp": "·", "textbullet": "•",
+	"textendash": "–", "textemdash": "—", "LaTeX": "LaTeX", "TeX": "TeX", "ldots": "…", "dots": "…",
+	"today": "", "hfill": " ", "newline": "\n", "\\": "\n", "linebreak": "\n", "par": "\n",
+}
+
+// convertInto writes the Typst translation of raw into out
+func (imp *latexImporter) convertInto(out *strings.Builder, raw string) {
+	for p := 0; p < len(raw); {
+		c := raw[p]
+		switch {
+		case c == '\\':
+			name, next := readLaTeXMacroName(raw, p)
+			if symbol, ok := latexSymbols[name]; ok {
+				out.WriteString(symbol)
+				p = next
+				if name == "\\" {
+					// Skip an optional spacing argument such as \\[2pt]
+					if next < len(raw) && raw[next] == '[' {
+						if _, after, ok := readLaTeXGroup(raw, next, '[', ']'); ok {
+							p = after
+						}
+					}
+				}
+				continue
+			}
+			if name == "item" {
+				out.WriteString("\n- ")
+				p = next
+				continue
+			}
+			if name == "begin" || name == "end" {
+				_, _, end := readLaTeXArgs(raw, next)
+				out.WriteString("\n")
+				p = end
+				continue
+			}
+			_, args, end := readLaTeXArgs(raw, next)
+			p = end
+			switch name {
+			case "href":
+				out.WriteString(`#link("` + strings.ReplaceAll(latexArg(args, 0), `"`, `\"`) + `")[`)
+				imp.convertInto(out, latexArg(args, 1))
+				out.WriteString("]")
+			case "url":
+				out.WriteString(`#link("` + strings.ReplaceAll(latexArg(args, 0), `"`, `\"`) + `")`)
+			case "textbf":
+				out.WriteString("*")
+				imp.convertInto(out, latexArg(args, 0))
+				out.WriteString("*")
+			case "emph", "textit":
+				out.WriteString("_")
+				imp.convertInto(out, latexArg(args, 0))
+				out.WriteString("_")
+			case "textsc", "textup", "textrm", "textsf", "texttt", "mbox", "text", "small", "footnotesize", "scriptsize", "large", "Large", "textnormal":
+				for _, arg := range args {
+					imp.convertInto(out, arg)
+				}
+			default:
+				if !latexIgnoredMacros[name] {
+					imp.unrecognized[`\`+name] = true
+				}
+				for _, arg := range args {
+					imp.convertInto(out, arg)
+				}
+			}
+		case c == '{' || c == '}':
+			p++
+		case c == '~':
+			out.WriteString(" ")
+			p++
+		case strings.HasPrefix(raw[p:], "---"):
+			out.WriteString("—")
+			p += 3
+		case strings.HasPrefix(raw[p:], "--"):
+			out.WriteString("–")
+			p += 2
+		case strings.HasPrefix(raw[p:], "``") || strings.HasPrefix(raw[p:], "''"):
+			out.WriteString(`"`)
+			p += 2
+		case c == '\n' && strings.HasPrefix(strings.TrimLeft(raw[p+1:], " \t"), "\n"):
+			// A blank line is a paragraph break
+			out.WriteString("\n")
+			p++
+		case c == '\n':
+			out.WriteString(" ")
+			p++
+		case strings.IndexByte("*@#$_<`", c) != -1:
+			out.WriteByte('\\')
+			out.WriteByte(c)
+			p++
+		default:
+			out.WriteByte(c)
+			p++
+		}
+	}
+}
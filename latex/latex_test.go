@@ -0,0 +1,220 @@
+package latex
+
+import (
+	"strings"
+	"testing"
+)
+
+const moderncvSample = `\documentclass[11pt,a4paper,sans]{moderncv}
+\moderncvstyle{classic}
+\moderncvcolor{blue}
+\usepackage[scale=0.75]{geometry}
+
+\name{John}{Doe}
+\title{Software Engineer \textbar{} Embedded developer}
+\address{street and number}{postcode city}{country}
+\phone[mobile]{+1~(234)~567~890}
+\email{john@doe.org}
+\homepage{www.johndoe.com}
+\social[linkedin]{john.doe}
+\social[github]{jdoe}
+\social[orcid]{0000-0000-0000-0000}
+
+\begin{document}
+\makecvtitle
+
+\section{Summary}
+Engineer with a taste for \textbf{systems} programming. % a comment
+
+\section{Education}
+\cventry{2013--2017}{Master of Science}{Chalmers University}{Göteborg}{\textit{GPA 4.0}}{Thesis on \href{https://example.com/thesis}{compilers}}
+
+\section{Experience}
+\cventry{2018--Now}{Software Engineer}{ACME \& Co.}{Shanghai}{}{
+\begin{itemize}
+\item Built the transaction layer in Rust
+\item Reduced latency by 50\%
+\end{itemize}}
+
+\section{Computer skills}
+\cvitem{Languages}{\textbf{Rust}, Go, C/C++}
+\cvdoubleitem{Frontend}{React, Vue}{Databases}{PostgreSQL}
+
+\section{Interests}
+\cvitem{Writing}{I write a \emph{blog}.}
+
+\section{Publications}
+\cvitem{2020}{A paper}
+\end{document}
+`
+
+const awesomeCVSample = `\documentclass[11pt, a4paper]{awesome-cv}
+\name{Claud D.}{Park}
+\position{Software Architect{\enskip\cdotp\enskip}Security Expert}
+\mobile{(+82) 10-9030-1843}
+\email{posquit0.bj@gmail.com}
+\github{posquit0}
+\linkedin{posquit0}
+\quote{Be the change that you want to see in the world.}
+
+\begin{document}
+\makecvheader
+\cvsection{Summary}
+\begin{cvparagraph}
+Current Site Reliability Engineer at start-up company \href{https://www.qualson.com}{QualSon}.
+\end{cvparagraph}
+
+\cvsection{Work Experience}
+\begin{cventries}
+  \cventry
+    {DevOps Engineer}
+    {Dunamu Inc.}
+    {Seoul, S.Korea}
+    {Jan. 2018 - PRESENT}
+    {
+      \begin{cvitems}
+        \item {Provisioned an easily managable hybrid infrastructure}
+        \item {Automated the security review}
+      \end{cvitems}
+    }
+\end{cventries}
+
+\cvsection{Skills}
+\begin{cvskills}
+  \cvskill{DevOps}{AWS, Docker, Kubernetes}
+\end{cvskills}
+
+\cvsection{Honors \& Awards}
+\begin{cvhonors}
+  \cvhonor{Finalist}{DEFCON CTF}{Las Vegas}{2011}
+\end{cvhonors}
+\end{document}
+`
+
+func TestImportModernCV(t *testing.T) {
+	result, err := ImportResume(moderncvSample, "")
+	if err != nil {
+		t.Fatalf("Failed to import moderncv: %v", err)
+	}
+	if result.Class != ClassModernCV {
+		t.Errorf("Expected class moderncv, got %s", result.Class)
+	}
+
+	resume := result.Resume
+	author := resume.Author
+	if author.FirstName != "John" || author.LastName != "Doe" {
+		t.Errorf("Unexpected name: %s %s", author.FirstName, author.LastName)
+	}
+	if author.Email != "john@doe.org" || author.GitHub != "jdoe" || author.LinkedIn != "john.doe" {
+		t.Errorf("Unexpected contact details: %+v", author)
+	}
+	if author.Phone != "+1 (234) 567 890" {
+		t.Errorf("Unexpected phone: %q", author.Phone)
+	}
+	if len(resume.Positions) != 2 || resume.Positions[1] != "Embedded developer" {
+		t.Errorf("Unexpected positions: %v", resume.Positions)
+	}
+	if resume.Summary != "Engineer with a taste for *systems* programming." {
+		t.Errorf("Unexpected summary: %q", resume.Summary)
+	}
+
+	if len(resume.Education) != 1 {
+		t.Fatalf("Expected 1 education entry, got %d", len(resume.Education))
+	}
+	edu := resume.Education[0]
+	if edu.Title != "Chalmers University" || edu.Description != "Master of Science" || edu.Date != "2013–2017" || edu.Location != "Göteborg" {
+		t.Errorf("Unexpected education entry: %+v", edu)
+	}
+	if edu.Content != `_GPA 4.0_`+"\n"+`Thesis on #link("https://example.com/thesis")[compilers]` {
+		t.Errorf("Unexpected education content: %q", edu.Content)
+	}
+
+	if len(resume.WorkExperience) != 1 {
+		t.Fatalf("Expected 1 work entry, got %d", len(resume.WorkExperience))
+	}
+	work := resume.WorkExperience[0]
+	if work.Title != "Software Engineer" || work.Description != "ACME & Co." {
+		t.Errorf("Unexpected work entry: %+v", work)
+	}
+	if work.Content != "- Built the transaction layer in Rust\n- Reduced latency by 50%" {
+		t.Errorf("Unexpected work content: %q", work.Content)
+	}
+
+	if len(resume.Skills) != 3 {
+		t.Fatalf("Expected 3 skill categories, got %d", len(resume.Skills))
+	}
+	languages := resume.Skills[0]
+	if languages.Name != "Languages" || len(languages.Skills) != 3 || !languages.Skills[0].Strong || languages.Skills[0].Name != "Rust" || languages.Skills[1].Strong {
+		t.Errorf("Unexpected languages: %+v", languages)
+	}
+	if resume.Skills[2].Name != "Databases" {
+		t.Errorf("Expected cvdoubleitem to produce two categories, got %+v", resume.Skills)
+	}
+
+	if len(resume.Interests) != 1 || resume.Interests[0].Description != "I write a _blog_." {
+		t.Errorf("Unexpected interests: %+v", resume.Interests)
+	}
+
+	if author := resume.Author; author.Address != "street and number, postcode city, country" ||
+		len(author.Links) != 1 || author.Links[0].URL != "https://orcid.org/0000-0000-0000-0000" {
+		t.Errorf("Unexpected address or links: %+v", author)
+	}
+
+	unrecognized := strings.Join(result.Unrecognized, "\n")
+	for _, expected := range []string{`section "Publications"`} {
+		if !strings.Contains(unrecognized, expected) {
+			t.Errorf("Expected %s to be reported as unrecognized, got %v", expected, result.Unrecognized)
+		}
+	}
+	if strings.Contains(unrecognized, `\moderncvstyle`) || strings.Contains(unrecognized, `\makecvtitle`) {
+		t.Errorf("Layout macros must not be reported: %v", result.Unrecognized)
+	}
+}
+
+func TestImportAwesomeCV(t *testing.T) {
+	result, err := ImportResume(awesomeCVSample, "")
+	if err != nil {
+		t.Fatalf("Failed to import awesome-cv: %v", err)
+	}
+	if result.Class != ClassAwesomeCV {
+		t.Errorf("Expected class awesome-cv, got %s", result.Class)
+	}
+
+	resume := result.Resume
+	if resume.Author.FirstName != "Claud D." || resume.Author.GitHub != "posquit0" {
+		t.Errorf("Unexpected author: %+v", resume.Author)
+	}
+	if len(resume.Positions) != 2 || resume.Positions[0] != "Software Architect" || resume.Positions[1] != "Security Expert" {
+		t.Errorf("Unexpected positions: %v", resume.Positions)
+	}
+	if !strings.Contains(resume.Summary, `#link("https://www.qualson.com")[QualSon]`) {
+		t.Errorf("Unexpected summary: %q", resume.Summary)
+	}
+
+	if len(resume.WorkExperience) != 1 {
+		t.Fatalf("Expected 1 work entry, got %d", len(resume.WorkExperience))
+	}
+	work := resume.WorkExperience[0]
+	if work.Title != "DevOps Engineer" || work.Description != "Dunamu Inc." || work.Location != "Seoul, S.Korea" || work.Date != "Jan. 2018 - PRESENT" {
+		t.Errorf("Unexpected work entry: %+v", work)
+	}
+	if work.Content != "- Provisioned an easily managable hybrid infrastructure\n- Automated the security review" {
+		t.Errorf("Unexpected work content: %q", work.Content)
+	}
+
+	if len(resume.Skills) != 1 || len(resume.Skills[0].Skills) != 3 {
+		t.Errorf("Unexpected skills: %+v", resume.Skills)
+	}
+
+	unrecognized := strings.Join(result.Unrecognized, "\n")
+	if !strings.Contains(unrecognized, `section "Honors & Awards"`) {
+		t.Errorf("Expected unmapped section to be reported, got %v", result.Unrecognized)
+	}
+}
+
+func TestImportLaTeXRejectsUnknownClass(t *testing.T) {
+	_, err := ImportResume(`\documentclass{article}`, "")
+	if err == nil {
+		t.Error("Expected error for unsupported document class")
+	}
+}
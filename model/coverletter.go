@@ -0,0 +1,84 @@
+package model
+
+import (
+	"strings"
+
+	"cvcl-render/i18n"
+)
+
+// CoverLetterData represents the data to be rendered in the template
+type CoverLetterData struct {
+	// Profile holds the author; its fields appear at the top level of the JSON document
+	Profile
+	Position  string `json:"position"`
+	Addressee string `json:"addressee"`
+	// Opening, AboutMe, WhyMe and WhyCompany are the legacy fixed paragraphs,
+	// used only when Paragraphs is empty
+	Opening          string         `json:"opening,omitempty"`
+	AboutMe          string         `json:"about_me,omitempty"`
+	WhyMe            string         `json:"why_me,omitempty"`
+	WhyCompany       string         `json:"why_company,omitempty"`
+	Paragraphs       []Paragraph    `json:"paragraphs,omitempty"`
+	Date             string         `json:"date,omitempty"`
+	RecipientAddress string         `json:"recipient_address,omitempty"`
+	Salutation       string         `json:"salutation,omitempty"`
+	Closing          string         `json:"closing,omitempty"`
+	Signature        string         `json:"signature,omitempty"`
+	Options          *RenderOptions `json:"options,omitempty"`
+}
+
+// Paragraph is a single body paragraph of a cover letter with an optional heading
+type Paragraph struct {
+	Heading string `json:"heading,omitempty"`
+	Text    string `json:"text"`
+}
+
+// ContentParagraphs returns the body paragraphs of the letter.
+// When Paragraphs is empty, the non-empty legacy fields are used in their fixed order.
+func (d CoverLetterData) ContentParagraphs() []Paragraph {
+	if len(d.Paragraphs) > 0 {
+		return d.Paragraphs
+	}
+	var paragraphs []Paragraph
+	for _, text := range []string{d.Opening, d.AboutMe, d.WhyMe, d.WhyCompany} {
+		if strings.TrimSpace(text) != "" {
+			paragraphs = append(paragraphs, Paragraph{Text: text})
+		}
+	}
+	return paragraphs
+}
+
+// RecipientAddressLines returns the recipient address split into lines
+func (d CoverLetterData) RecipientAddressLines() []string {
+	var lines []string
+	for _, line := range strings.Split(d.RecipientAddress, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// Language returns the requested render language, or "" if none was requested
+func (d CoverLetterData) Language() string {
+	if d.Options == nil {
+		return ""
+	}
+	return d.Options.Language
+}
+
+// PrepareForRender returns a copy of the data with the localized default salutation and closing
+// filled in when a language is requested and the letter does not set its own
+func (d CoverLetterData) PrepareForRender() CoverLetterData {
+	if d.Language() == "" {
+		return d
+	}
+	messages := i18n.Get(d.Language())
+	if d.Salutation == "" {
+		d.Salutation = messages.SalutationFor(d.Addressee)
+	}
+	if d.Closing == "" {
+		d.Closing = messages.Closing
+	}
+	return d
+}
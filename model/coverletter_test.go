@@ -0,0 +1,19 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestContentParagraphsMapsLegacyFields(t *testing.T) {
+	data := CoverLetterData{Opening: "Hello", WhyMe: "Because"}
+	expected := []Paragraph{{Text: "Hello"}, {Text: "Because"}}
+	if got := data.ContentParagraphs(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+
+	data.Paragraphs = []Paragraph{{Text: "Only this"}}
+	if got := data.ContentParagraphs(); len(got) != 1 || got[0].Text != "Only this" {
+		t.Errorf("Expected Paragraphs to take precedence, got %+v", got)
+	}
+}
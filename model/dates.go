@@ -0,0 +1,320 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cvcl-render/i18n"
+)
+
+// YearMonth is a point in time with month precision. Month is 0 when only the year is known.
+type YearMonth struct {
+	Year  int `json:"year"`
+	Month int `json:"month,omitempty"`
+}
+
+// before reports whether y is earlier than other, treating an unknown month as the start of the year
+func (y YearMonth) before(other YearMonth) bool {
+	if y.Year != other.Year {
+		return y.Year < other.Year
+	}
+	return y.Month < other.Month
+}
+
+// DateRange is a single period such as "Jun. 2020 - Sept. 2021 (Intern)".
+// A range with only Start is a single point in time; Ongoing marks ranges ending "Now".
+type DateRange struct {
+	Start   *YearMonth `json:"start,omitempty"`
+	End     *YearMonth `json:"end,omitempty"`
+	Ongoing bool       `json:"ongoing,omitempty"`
+	Note    string     `json:"note,omitempty"`
+}
+
+// DateRanges is one or more periods of the same entry, e.g. an internship followed by a full-time position
+type DateRanges []DateRange
+
+// Format renders the range in the given language, e.g. "Sept. 2021 - Present"
+func (r DateRange) Format(language string) string {
+	locale := i18n.Get(language).Dates
+	format := func(y YearMonth) string { return locale.FormatYearMonth(y.Year, y.Month) }
+	var text string
+	switch {
+	case r.Start != nil && r.Ongoing:
+		text = format(*r.Start) + locale.Separator + locale.Ongoing
+	case r.Start != nil && r.End != nil && *r.Start != *r.End:
+		text = format(*r.Start) + locale.Separator + format(*r.End)
+	case r.Start != nil:
+		text = format(*r.Start)
+	case r.End != nil:
+		text = format(*r.End)
+	}
+	if r.Note != "" {
+		text += " (" + r.Note + ")"
+	}
+	return text
+}
+
+// Format renders all ranges in the given language, separated by commas
+func (d DateRanges) Format(language string) string {
+	parts := make([]string, 0, len(d))
+	for _, r := range d {
+		if text := r.Format(language); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// latest returns the most recent point covered by the ranges; ongoing ranges are latest of all
+func (d DateRanges) latest() (point YearMonth, ongoing bool, ok bool) {
+	for _, r := range d {
+		if r.Ongoing {
+			ongoing = true
+		}
+		for _, candidate := range []*YearMonth{r.Start, r.End} {
+			if candidate != nil && (!ok || point.before(*candidate)) {
+				point, ok = *candidate, true
+			}
+		}
+	}
+	return point, ongoing, ok || ongoing
+}
+
+// Months returns the total number of months covered by the ranges, counting ongoing ranges up to now.
+// Year-only dates count from January to December.
+func (d DateRanges) Months(now time.Time) int {
+	total := 0
+	for _, r := range d {
+		if r.Start == nil {
+			continue
+		}
+		start := *r.Start
+		if start.Month == 0 {
+			start.Month = 1
+		}
+		var end YearMonth
+		switch {
+		case r.Ongoing:
+			end = YearMonth{Year: now.Year(), Month: int(now.Month())}
+		case r.End != nil:
+			end = *r.End
+			if end.Month == 0 {
+				end.Month = 12
+			}
+		default:
+			end = start
+		}
+		if months := (end.Year-start.Year)*12 + end.Month - start.Month + 1; months > 0 {
+			total += months
+		}
+	}
+	return total
+}
+
+// monthNames maps lower-case month names and abbreviations in the supported languages to month numbers
+var monthNames = map[string]int{
+	"jan": 1, "january": 1, "januari": 1, "januar": 1, "jän": 1, "jänner": 1,
+	"feb": 2, "february": 2, "februari": 2, "februar": 2,
+	"mar": 3, "march": 3, "mars": 3, "märz": 3, "mär": 3,
+	"apr": 4, "april": 4,
+	"may": 5, "maj": 5, "mai": 5,
+	"jun": 6, "june": 6, "juni": 6,
+	"jul": 7, "july": 7, "juli": 7,
+	"aug": 8, "august": 8, "augusti": 8,
+	"sep": 9, "sept": 9, "september": 9,
+	"oct": 10, "october": 10, "okt": 10, "oktober": 10,
+	"nov": 11, "november": 11,
+	"dec": 12, "december": 12, "dez": 12, "dezember": 12,
+}
+
+// ongoingWords mark the open end of a range
+var ongoingWords = map[string]bool{
+	"now": true, "present": true, "current": true, "today": true, "ongoing": true,
+	"nu": true, "pågående": true, "heute": true, "jetzt": true, "至今": true, "现在": true,
+}
+
+var (
+	dateNotePattern      = regexp.MustCompile(`\(([^)]*)\)`)
+	dateISOPattern       = regexp.MustCompile(`\b(\d{4})-(\d{1,2})\b`)
+	dateSlashPattern     = regexp.MustCompile(`\b(\d{1,2})/(\d{4})\b`)
+	dateCJKPattern       = regexp.MustCompile(`(\d{4})年(?:(\d{1,2})月)?`)
+	dateRangeSeparator   = regexp.MustCompile(`\s*(?:[-‐‑‒–—~]+|\bto\b|\btill\b|\bbis\b)\s*`)
+	dateYearPattern      = regexp.MustCompile(`^\d{4}$`)
+	dateMonthWordPattern = regexp.MustCompile(`^\p{L}+\.?$`)
+)
+
+// ParseDateRanges parses free-text dates such as "Jun. 2020 ‑ Sept. 2021 (Intern) , Sept. 2021 ‑ Sept 2022",
+// "Jan. - May 2021", "Sept. 2020 - Now" or "2021 - 2023" into structured ranges
+func ParseDateRanges(text string) (DateRanges, error) {
+	var ranges DateRanges
+	for _, part := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ';' }) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		r, err := parseDateRange(part)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("no date found in %q", text)
+	}
+	return ranges, nil
+}
+
+// parseDateRange parses a single range
+func parseDateRange(text string) (DateRange, error) {
+	var r DateRange
+	if match := dateNotePattern.FindStringSubmatch(text); match != nil {
+		r.Note = strings.TrimSpace(match[1])
+		text = strings.Replace(text, match[0], "", 1)
+	}
+
+	// Rewrite numeric forms so that their separators are not taken for range dashes
+	text = dateISOPattern.ReplaceAllString(text, "$2/$1")
+	text = dateCJKPattern.ReplaceAllStringFunc(text, func(m string) string {
+		match := dateCJKPattern.FindStringSubmatch(m)
+		if match[2] == "" {
+			return " " + match[1] + " "
+		}
+		return " " + match[2] + "/" + match[1] + " "
+	})
+
+	sides := dateRangeSeparator.Split(strings.TrimSpace(text), -1)
+	if len(sides) > 2 {
+		return r, fmt.Errorf("too many dates in range %q", text)
+	}
+
+	start, startOngoing, err := parseDatePoint(sides[0])
+	if err != nil {
+		return r, err
+	}
+	if startOngoing {
+		return r, fmt.Errorf("range %q starts with an open end", text)
+	}
+	r.Start = start
+
+	if len(sides) == 2 {
+		end, ongoing, err := parseDatePoint(sides[1])
+		if err != nil {
+			return r, err
+		}
+		r.End = end
+		r.Ongoing = ongoing
+	}
+
+	// "Jan. - May 2021" takes the year from the end of the range
+	if r.Start != nil && r.Start.Year == 0 {
+		if r.End == nil || r.End.Year == 0 {
+			return r, fmt.Errorf("missing year in %q", text)
+		}
+		r.Start.Year = r.End.Year
+		if r.End.Month != 0 && r.Start.Month > r.End.Month {
+			r.Start.Year--
+		}
+	}
+	if r.End != nil && r.End.Year == 0 {
+		return r, fmt.Errorf("missing year in %q", text)
+	}
+	if r.Start == nil {
+		return r, fmt.Errorf("no date found in %q", text)
+	}
+	return r, nil
+}
+
+// parseDatePoint parses "Sept. 2021", "Sept 2022", "2021", "06/2020", "Jan." or "Now".
+// A month without a year returns Year 0 for the caller to resolve.
+func parseDatePoint(text string) (*YearMonth, bool, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, false, fmt.Errorf("empty date")
+	}
+	if ongoingWords[strings.ToLower(text)] {
+		return nil, true, nil
+	}
+	if match := dateSlashPattern.FindStringSubmatch(text); match != nil && match[0] == text {
+		month, _ := strconv.Atoi(match[1])
+		year, _ := strconv.Atoi(match[2])
+		if month < 1 || month > 12 {
+			return nil, false, fmt.Errorf("invalid month in %q", text)
+		}
+		return &YearMonth{Year: year, Month: month}, false, nil
+	}
+
+	point := &YearMonth{}
+	for _, word := range strings.Fields(text) {
+		switch {
+		case dateYearPattern.MatchString(word):
+			point.Year, _ = strconv.Atoi(word)
+		case dateMonthWordPattern.MatchString(word):
+			month, ok := monthNames[strings.ToLower(strings.TrimSuffix(word, "."))]
+			if !ok {
+				return nil, false, fmt.Errorf("unknown month %q", word)
+			}
+			point.Month = month
+		default:
+			return nil, false, fmt.Errorf("unrecognized date %q", text)
+		}
+	}
+	if point.Year == 0 && point.Month == 0 {
+		return nil, false, fmt.Errorf("unrecognized date %q", text)
+	}
+	return point, false, nil
+}
+
+// entryDates returns the structured dates of an entry, parsing the free-text Date if needed
+func entryDates(entry ResumeEntry) (DateRanges, bool) {
+	if len(entry.Dates) > 0 {
+		return entry.Dates, true
+	}
+	if entry.Date == "" {
+		return nil, false
+	}
+	ranges, err := ParseDateRanges(entry.Date)
+	return ranges, err == nil
+}
+
+// SortEntriesByDate orders entries reverse-chronologically by their most recent date.
+// Ongoing entries come first and entries without a recognizable date keep their order at the end.
+func SortEntriesByDate(entries []ResumeEntry) {
+	type sortKey struct {
+		latest  YearMonth
+		ongoing bool
+		ok      bool
+	}
+	keys := make(map[*ResumeEntry]sortKey, len(entries))
+	for i := range entries {
+		var key sortKey
+		if dates, ok := entryDates(entries[i]); ok {
+			key.latest, key.ongoing, key.ok = dates.latest()
+		}
+		keys[&entries[i]] = key
+	}
+
+	indexed := make([]int, len(entries))
+	for i := range indexed {
+		indexed[i] = i
+	}
+	sort.SliceStable(indexed, func(a, b int) bool {
+		ka, kb := keys[&entries[indexed[a]]], keys[&entries[indexed[b]]]
+		if ka.ok != kb.ok {
+			return ka.ok
+		}
+		if ka.ongoing != kb.ongoing {
+			return ka.ongoing
+		}
+		return kb.latest.before(ka.latest)
+	})
+
+	sorted := make([]ResumeEntry, len(entries))
+	for i, idx := range indexed {
+		sorted[i] = entries[idx]
+	}
+	copy(entries, sorted)
+}
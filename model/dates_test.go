@@ -0,0 +1,106 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func ym(year, month int) *YearMonth {
+	return &YearMonth{Year: year, Month: month}
+}
+
+func TestParseDateRanges(t *testing.T) {
+	tests := []struct {
+		input string
+		want  DateRanges
+	}{
+		{"Aug. 2023 - Aug. 2025", DateRanges{{Start: ym(2023, 8), End: ym(2025, 8)}}},
+		{"Sept. 2017 ‑ Aug. 2021", DateRanges{{Start: ym(2017, 9), End: ym(2021, 8)}}},
+		{"Jun. 2020 ‑ Sept. 2021 (Intern) , Sept. 2021 ‑ Sept 2022", DateRanges{
+			{Start: ym(2020, 6), End: ym(2021, 9), Note: "Intern"},
+			{Start: ym(2021, 9), End: ym(2022, 9)},
+		}},
+		{"Jan. - May 2021", DateRanges{{Start: ym(2021, 1), End: ym(2021, 5)}}},
+		{"Nov. - Feb. 2021", DateRanges{{Start: ym(2020, 11), End: ym(2021, 2)}}},
+		{"Sept. 2020 - Now", DateRanges{{Start: ym(2020, 9), Ongoing: true}}},
+		{"May 2024 - Present", DateRanges{{Start: ym(2024, 5), Ongoing: true}}},
+		{"2021 - 2023", DateRanges{{Start: ym(2021, 0), End: ym(2023, 0)}}},
+		{"2013--2017", DateRanges{{Start: ym(2013, 0), End: ym(2017, 0)}}},
+		{"2020-06 – 2021-09", DateRanges{{Start: ym(2020, 6), End: ym(2021, 9)}}},
+		{"06/2020 to 09/2021", DateRanges{{Start: ym(2020, 6), End: ym(2021, 9)}}},
+		{"2020年6月 - 至今", DateRanges{{Start: ym(2020, 6), Ongoing: true}}},
+		{"März 2019 - heute", DateRanges{{Start: ym(2019, 3), Ongoing: true}}},
+		{"2011", DateRanges{{Start: ym(2011, 0)}}},
+	}
+	for _, tc := range tests {
+		got, err := ParseDateRanges(tc.input)
+		if err != nil {
+			t.Errorf("ParseDateRanges(%q) failed: %v", tc.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ParseDateRanges(%q) = %+v, want %+v", tc.input, got, tc.want)
+		}
+	}
+
+	for _, input := range []string{"", "soon", "Jan. - Feb.", "Now - 2020", "Smarch 2020"} {
+		if _, err := ParseDateRanges(input); err == nil {
+			t.Errorf("ParseDateRanges(%q) should fail", input)
+		}
+	}
+}
+
+func TestDateRangesFormat(t *testing.T) {
+	dates := DateRanges{
+		{Start: ym(2020, 6), End: ym(2021, 9), Note: "Intern"},
+		{Start: ym(2021, 9), Ongoing: true},
+	}
+	tests := map[string]string{
+		"":      "Jun. 2020 - Sept. 2021 (Intern), Sept. 2021 - Present",
+		"en-US": "Jun. 2020 - Sept. 2021 (Intern), Sept. 2021 - Present",
+		"sv":    "juni 2020 - sep. 2021 (Intern), sep. 2021 - nu",
+		"de":    "Juni 2020 - Sept. 2021 (Intern), Sept. 2021 - heute",
+		"zh":    "2020年6月 - 2021年9月 (Intern), 2021年9月 - 至今",
+	}
+	for language, want := range tests {
+		if got := dates.Format(language); got != want {
+			t.Errorf("Format(%q) = %q, want %q", language, got, want)
+		}
+	}
+}
+
+func TestDateRangesMonths(t *testing.T) {
+	now := time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC)
+	dates := DateRanges{
+		{Start: ym(2020, 6), End: ym(2021, 9)},
+		{Start: ym(2023, 4), Ongoing: true},
+	}
+	if got := dates.Months(now); got != 16+12 {
+		t.Errorf("Months() = %d, want %d", got, 16+12)
+	}
+}
+
+func TestValidateDateRanges(t *testing.T) {
+	data := ResumeData{Projects: []ResumeEntry{{
+		Title: "Project",
+		Dates: DateRanges{
+			{Start: ym(2022, 13)},
+			{Start: ym(2022, 5), End: ym(2021, 1)},
+		},
+	}}}
+	err := data.Validate()
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("Expected ValidationErrors, got %v", err)
+	}
+	fields := map[string]bool{}
+	for _, fieldErr := range errs {
+		fields[fieldErr.Field] = true
+	}
+	for _, field := range []string{"projects[0].dates[0].start.month", "projects[0].dates[1].end"} {
+		if !fields[field] {
+			t.Errorf("Expected error for %s, got %v", field, errs)
+		}
+	}
+}
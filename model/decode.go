@@ -0,0 +1,366 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// DecodeMode controls how unknown fields in JSON input are handled
+type DecodeMode string
+
+const (
+	// DecodeLenient accepts unknown fields and reports them as warnings
+	DecodeLenient DecodeMode = "lenient"
+	// DecodeStrict rejects input containing unknown fields
+	DecodeStrict DecodeMode = "strict"
+)
+
+// ParseDecodeMode validates a decode mode name, defaulting to lenient when empty
+func ParseDecodeMode(name string) (DecodeMode, error) {
+	switch DecodeMode(strings.ToLower(name)) {
+	case "", DecodeLenient:
+		return DecodeLenient, nil
+	case DecodeStrict:
+		return DecodeStrict, nil
+	}
+	return "", fmt.Errorf("unknown JSON decode mode: %s (expected strict or lenient)", name)
+}
+
+// DecodeJSON decodes a JSON document into v.
+// Type mismatches such as a string where a list is expected are reported as field errors
+// instead of byte offsets. Unknown fields are returned as warnings in lenient mode and as
+// field errors in strict mode.
+func DecodeJSON(r io.Reader, v interface{}, mode DecodeMode) (warnings []string, err error) {
+	return DecodeLocalizedJSON(r, v, mode, "")
+}
+
+// DecodeLocalizedJSON is DecodeJSON for documents with per-language values, which are resolved
+// to the given language (or the document's options.language when empty) before decoding.
+// Fields rendered in a fallback language are reported as warnings.
+func DecodeLocalizedJSON(r io.Reader, v interface{}, mode DecodeMode, language string) (warnings []string, err error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	content, warnings, err = canonicalizeJSON(content, reflect.TypeOf(v))
+	if err != nil {
+		return nil, err
+	}
+
+	content, untranslated, err := localizeJSON(content, reflect.TypeOf(v), language)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range untranslated {
+		warnings = append(warnings, fmt.Sprintf("%s: no translation for the requested language, using %q", field.Field, field.Used))
+	}
+
+	err = json.NewDecoder(bytes.NewReader(content)).Decode(v)
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		field := typeErr.Field
+		if field == "" {
+			field = "(root)"
+		}
+		return nil, ValidationErrors{{
+			Field:   field,
+			Message: fmt.Sprintf("must be %s, got %s", jsonTypeName(typeErr.Type), typeErr.Value),
+		}}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var raw interface{}
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, err
+	}
+	unknown := unknownJSONFields(raw, reflect.TypeOf(v), "")
+	if len(unknown) == 0 {
+		return warnings, nil
+	}
+
+	if mode == DecodeStrict {
+		var validationErrs ValidationErrors
+		for _, field := range unknown {
+			validationErrs = append(validationErrs, FieldError{Field: field.path, Message: field.message()})
+		}
+		return warnings, validationErrs
+	}
+	for _, field := range unknown {
+		warnings = append(warnings, fmt.Sprintf("%s: %s", field.path, field.message()))
+	}
+	return warnings, nil
+}
+
+// unknownField is an input field that does not map onto the target type
+type unknownField struct {
+	path       string
+	suggestion string
+	// caseOnly is set when encoding/json still accepted the field by case-insensitive match
+	caseOnly bool
+}
+
+func (f unknownField) message() string {
+	switch {
+	case f.caseOnly:
+		return fmt.Sprintf("field name should be spelled %q", f.suggestion)
+	case f.suggestion != "":
+		return fmt.Sprintf("unknown field, did you mean %q?", f.suggestion)
+	}
+	return "unknown field"
+}
+
+// unknownJSONFields walks decoded JSON alongside the Go type it was decoded into
+// and returns the paths of fields the type does not declare
+func unknownJSONFields(raw interface{}, typ reflect.Type, path string) []unknownField {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	switch value := raw.(type) {
+	case map[string]interface{}:
+		if typ.Kind() != reflect.Struct {
+			return nil
+		}
+		known := jsonFieldTypes(typ)
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		var unknown []unknownField
+		for _, key := range keys {
+			fieldPath := key
+			if path != "" {
+				fieldPath = path + "." + key
+			}
+			if fieldType, ok := known[key]; ok {
+				unknown = append(unknown, unknownJSONFields(value[key], fieldType, fieldPath)...)
+				continue
+			}
+			// encoding/json matches names case-insensitively, so such fields are decoded
+			if name, fieldType, ok := caseInsensitiveField(known, key); ok {
+				unknown = append(unknown, unknownField{path: fieldPath, suggestion: name, caseOnly: true})
+				unknown = append(unknown, unknownJSONFields(value[key], fieldType, fieldPath)...)
+				continue
+			}
+			unknown = append(unknown, unknownField{path: fieldPath, suggestion: closestFieldName(known, key)})
+		}
+		return unknown
+	case []interface{}:
+		if typ.Kind() != reflect.Slice && typ.Kind() != reflect.Array {
+			return nil
+		}
+		var unknown []unknownField
+		for i, item := range value {
+			unknown = append(unknown, unknownJSONFields(item, typ.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return unknown
+	}
+	return nil
+}
+
+// jsonFieldTypes maps the JSON names of a struct's fields to their types
+func jsonFieldTypes(typ reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			for embeddedName, embeddedType := range jsonFieldTypes(field.Type) {
+				fields[embeddedName] = embeddedType
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// jsonFieldAliases maps the legacy JSON names of a struct's fields, declared in an alias tag
+// such as `json:"first_name" alias:"firstname"`, to their current names
+func jsonFieldAliases(typ reflect.Type) map[string]string {
+	aliases := make(map[string]string)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if field.Anonymous && name == "" {
+			for alias, embeddedName := range jsonFieldAliases(field.Type) {
+				aliases[alias] = embeddedName
+			}
+			continue
+		}
+		if tag := field.Tag.Get("alias"); tag != "" && name != "" && name != "-" {
+			for _, alias := range strings.Split(tag, ",") {
+				aliases[alias] = name
+			}
+		}
+	}
+	return aliases
+}
+
+// canonicalizeJSON renames legacy field spellings to their current names, guided by the
+// target type. When a document sets both spellings, the current one wins with a warning.
+func canonicalizeJSON(content []byte, typ reflect.Type) ([]byte, []string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	var raw interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, nil, err
+	}
+	changed, warnings := renameJSONAliases(raw, typ, "")
+	if !changed {
+		return content, warnings, nil
+	}
+	renamed, err := json.Marshal(raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode document: %w", err)
+	}
+	return renamed, warnings, nil
+}
+
+// renameJSONAliases renames legacy fields in place and reports whether anything changed
+func renameJSONAliases(raw interface{}, typ reflect.Type, path string) (changed bool, warnings []string) {
+	typ = derefType(typ)
+	switch value := raw.(type) {
+	case map[string]interface{}:
+		if typ.Kind() != reflect.Struct {
+			return false, nil
+		}
+		aliases := jsonFieldAliases(typ)
+		legacy := make([]string, 0, len(aliases))
+		for alias := range aliases {
+			legacy = append(legacy, alias)
+		}
+		sort.Strings(legacy)
+		for _, alias := range legacy {
+			aliasValue, ok := value[alias]
+			if !ok {
+				continue
+			}
+			name := aliases[alias]
+			if _, ok := value[name]; ok {
+				warnings = append(warnings, fmt.Sprintf("%s: both %q and %q are set, using %q", joinJSONPath(path, name), alias, name, name))
+			} else {
+				value[name] = aliasValue
+			}
+			delete(value, alias)
+			changed = true
+		}
+
+		known := jsonFieldTypes(typ)
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if fieldType, ok := known[key]; ok {
+				fieldChanged, fieldWarnings := renameJSONAliases(value[key], fieldType, joinJSONPath(path, key))
+				changed = changed || fieldChanged
+				warnings = append(warnings, fieldWarnings...)
+			}
+		}
+	case []interface{}:
+		if typ.Kind() != reflect.Slice && typ.Kind() != reflect.Array {
+			return false, nil
+		}
+		for i, item := range value {
+			itemChanged, itemWarnings := renameJSONAliases(item, typ.Elem(), fmt.Sprintf("%s[%d]", path, i))
+			changed = changed || itemChanged
+			warnings = append(warnings, itemWarnings...)
+		}
+	}
+	return changed, warnings
+}
+
+// joinJSONPath appends a field name to a JSON field path
+func joinJSONPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func caseInsensitiveField(known map[string]reflect.Type, key string) (string, reflect.Type, bool) {
+	for name, typ := range known {
+		if strings.EqualFold(name, key) {
+			return name, typ, true
+		}
+	}
+	return "", nil, false
+}
+
+// closestFieldName suggests a known field within a small edit distance of key
+func closestFieldName(known map[string]reflect.Type, key string) string {
+	best, bestDistance := "", 3
+	for name := range known {
+		d := editDistance(strings.ToLower(name), strings.ToLower(key))
+		if d < bestDistance || (d == bestDistance && best != "" && name < best) {
+			best, bestDistance = name, d
+		}
+	}
+	return best
+}
+
+// editDistance computes the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// jsonTypeName describes a Go type using JSON terminology
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Struct, reflect.Map:
+		return "an object"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	}
+	return t.String()
+}
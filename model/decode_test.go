@@ -0,0 +1,49 @@
+package model
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const misspelledResume = `{
+  "work_experiences": [{"title": "Engineer"}],
+  "author": {"firstname": "John", "linkedIn": "john"},
+  "education": [{"title": "Uni", "locaton": "Göteborg"}]
+}`
+
+func TestDecodeJSONLenientWarnsAboutUnknownFields(t *testing.T) {
+	var data ResumeData
+	warnings, err := DecodeJSON(strings.NewReader(misspelledResume), &data, DecodeLenient)
+	if err != nil {
+		t.Fatalf("Expected lenient decoding to succeed: %v", err)
+	}
+
+	expected := []string{
+		`author.linkedIn: field name should be spelled "linkedin"`,
+		`education[0].locaton: unknown field, did you mean "location"?`,
+		`work_experiences: unknown field, did you mean "work_experience"?`,
+	}
+	if !reflect.DeepEqual(warnings, expected) {
+		t.Errorf("Expected warnings %q, got %q", expected, warnings)
+	}
+
+	// Known fields are still decoded
+	if data.Author.FirstName != "John" || data.Author.LinkedIn != "john" || len(data.Education) != 1 {
+		t.Errorf("Unexpected decoded data: %+v", data)
+	}
+}
+
+func TestDecodeJSONStrictRejectsUnknownFields(t *testing.T) {
+	var data ResumeData
+	_, err := DecodeJSON(strings.NewReader(misspelledResume), &data, DecodeStrict)
+
+	var validationErrs ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		t.Fatalf("Expected ValidationErrors, got %v", err)
+	}
+	if len(validationErrs) != 3 || validationErrs[2].Field != "work_experiences" {
+		t.Errorf("Unexpected field errors: %v", validationErrs)
+	}
+}
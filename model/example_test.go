@@ -0,0 +1,19 @@
+package model_test
+
+import (
+	"fmt"
+
+	"cvcl-render/model"
+)
+
+func ExampleParseDateRanges() {
+	ranges, err := model.ParseDateRanges("Aug. 2019 - Jun. 2021")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(ranges.Format("en"))
+	fmt.Println(ranges.Format("de"))
+	// Output:
+	// Aug. 2019 - Jun. 2021
+	// Aug. 2019 - Juni 2021
+}
@@ -0,0 +1,269 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"cvcl-render/i18n"
+)
+
+// Text fields may hold one value per language instead of a plain string, e.g.
+// {"summary": {"en": "Software developer", "sv": "Mjukvaruutvecklare"}}.
+// Such values are resolved to a single language before the document is decoded.
+
+var languageTagPattern = regexp.MustCompile(`^[A-Za-z]{2,3}(?:[-_][A-Za-z0-9]{2,8})*$`)
+
+// UntranslatedField is a localized field without a value in the requested language
+type UntranslatedField struct {
+	Field string `json:"field"`
+	// Used is the language whose value was rendered instead
+	Used string `json:"used"`
+}
+
+// localizedValue returns the variants of a per-language value, or false if raw is not one
+func localizedValue(raw interface{}) (map[string]string, bool) {
+	object, ok := raw.(map[string]interface{})
+	if !ok || len(object) == 0 {
+		return nil, false
+	}
+	variants := make(map[string]string, len(object))
+	for key, value := range object {
+		text, ok := value.(string)
+		if !ok || !languageTagPattern.MatchString(key) {
+			return nil, false
+		}
+		variants[key] = text
+	}
+	return variants, true
+}
+
+// pickVariant selects the value for a language: the exact tag, then its base language,
+// then the fallback language, then the alphabetically first variant.
+// It returns the key used and whether that key matches the requested language.
+func pickVariant(variants map[string]string, language, fallback string) (string, bool) {
+	for key := range variants {
+		if strings.EqualFold(key, language) {
+			return key, true
+		}
+	}
+	base := i18n.Normalize(language)
+	for key := range variants {
+		if i18n.Normalize(key) == base {
+			return key, true
+		}
+	}
+	for key := range variants {
+		if strings.EqualFold(key, fallback) {
+			return key, false
+		}
+	}
+	keys := make([]string, 0, len(variants))
+	for key := range variants {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys[0], false
+}
+
+// localizeJSON replaces the per-language values in a JSON document with the value for one language.
+// The language is taken from the override when given, otherwise from the document's options.language.
+// The resolved language is written back to options.language so that headings and dates follow it.
+func localizeJSON(content []byte, typ reflect.Type, override string) ([]byte, []UntranslatedField, error) {
+	var raw interface{}
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, nil, err
+	}
+	root, ok := raw.(map[string]interface{})
+	if !ok {
+		return content, nil, nil
+	}
+
+	language, fallback := documentLanguages(root)
+	if override != "" {
+		language = override
+	}
+	if language == "" {
+		language = fallback
+	}
+
+	l := &localizer{language: language, fallback: fallback}
+	resolved := l.resolve(root, typ, "")
+	if !l.changed && override == "" {
+		return content, nil, nil
+	}
+	if override != "" {
+		if _, ok := jsonFieldTypes(derefType(typ))["options"]; ok {
+			options, _ := root["options"].(map[string]interface{})
+			if options == nil {
+				options = map[string]interface{}{}
+			}
+			options["language"] = override
+			resolved.(map[string]interface{})["options"] = options
+		}
+	}
+
+	localized, err := json.Marshal(resolved)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode localized document: %w", err)
+	}
+	return localized, l.untranslated, nil
+}
+
+// documentLanguages returns options.language and options.fallback_language of a document
+func documentLanguages(root map[string]interface{}) (language, fallback string) {
+	fallback = i18n.DefaultLanguage
+	options, _ := root["options"].(map[string]interface{})
+	if value, ok := options["language"].(string); ok {
+		language = value
+	}
+	if value, ok := options["fallback_language"].(string); ok && value != "" {
+		fallback = value
+	}
+	return language, fallback
+}
+
+func derefType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+// localizer resolves per-language values while walking a document alongside its Go type
+type localizer struct {
+	language     string
+	fallback     string
+	changed      bool
+	untranslated []UntranslatedField
+}
+
+func (l *localizer) resolve(raw interface{}, typ reflect.Type, path string) interface{} {
+	typ = derefType(typ)
+	if typ.Kind() == reflect.String {
+		variants, ok := localizedValue(raw)
+		if !ok {
+			return raw
+		}
+		key, translated := pickVariant(variants, l.language, l.fallback)
+		if !translated {
+			l.untranslated = append(l.untranslated, UntranslatedField{Field: path, Used: key})
+		}
+		l.changed = true
+		return variants[key]
+	}
+
+	switch value := raw.(type) {
+	case map[string]interface{}:
+		if typ.Kind() != reflect.Struct {
+			return raw
+		}
+		known := jsonFieldTypes(typ)
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fieldType, ok := known[key]
+			if !ok {
+				_, fieldType, ok = caseInsensitiveField(known, key)
+			}
+			if !ok {
+				continue
+			}
+			fieldPath := key
+			if path != "" {
+				fieldPath = path + "." + key
+			}
+			value[key] = l.resolve(value[key], fieldType, fieldPath)
+		}
+	case []interface{}:
+		if typ.Kind() != reflect.Slice && typ.Kind() != reflect.Array {
+			return raw
+		}
+		for i, item := range value {
+			value[i] = l.resolve(item, typ.Elem(), fmt.Sprintf("%s[%d]", path, i))
+		}
+	}
+	return raw
+}
+
+// TranslationReport lists, for every language used in a document's per-language values,
+// the localized fields that have no value in that language
+func TranslationReport(content []byte, typ reflect.Type) (map[string][]string, error) {
+	var raw interface{}
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]map[string]string)
+	collectLocalizedFields(raw, typ, "", fields)
+
+	languages := make(map[string]bool)
+	for _, variants := range fields {
+		for language := range variants {
+			languages[language] = true
+		}
+	}
+	paths := make([]string, 0, len(fields))
+	for path := range fields {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	report := make(map[string][]string, len(languages))
+	for language := range languages {
+		missing := []string{}
+		for _, path := range paths {
+			if _, translated := pickVariant(fields[path], language, ""); !translated {
+				missing = append(missing, path)
+			}
+		}
+		report[language] = missing
+	}
+	return report, nil
+}
+
+// collectLocalizedFields records the variants of every per-language value by field path
+func collectLocalizedFields(raw interface{}, typ reflect.Type, path string, fields map[string]map[string]string) {
+	typ = derefType(typ)
+	if typ.Kind() == reflect.String {
+		if variants, ok := localizedValue(raw); ok {
+			fields[path] = variants
+		}
+		return
+	}
+
+	switch value := raw.(type) {
+	case map[string]interface{}:
+		if typ.Kind() != reflect.Struct {
+			return
+		}
+		known := jsonFieldTypes(typ)
+		for key, item := range value {
+			fieldType, ok := known[key]
+			if !ok {
+				_, fieldType, ok = caseInsensitiveField(known, key)
+			}
+			if !ok {
+				continue
+			}
+			fieldPath := key
+			if path != "" {
+				fieldPath = path + "." + key
+			}
+			collectLocalizedFields(item, fieldType, fieldPath, fields)
+		}
+	case []interface{}:
+		if typ.Kind() != reflect.Slice && typ.Kind() != reflect.Array {
+			return
+		}
+		for i, item := range value {
+			collectLocalizedFields(item, typ.Elem(), fmt.Sprintf("%s[%d]", path, i), fields)
+		}
+	}
+}
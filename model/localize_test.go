@@ -0,0 +1,94 @@
+package model
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const bilingualResume = `{
+  "positions": [{"en": "Software Engineer", "sv": "Mjukvaruutvecklare"}, "Rustacean"],
+  "summary": {"en": "A software developer.", "sv": "En mjukvaruutvecklare."},
+  "work_experience": [
+    {
+      "title": "PingCAP",
+      "location": {"en": "Shanghai, China", "sv": "Shanghai, Kina"},
+      "date": "Sept. 2021 - Sept. 2022",
+      "description": {"en": "Database Engineer"}
+    }
+  ],
+  "skills": [{"name": {"en": "Languages", "sv": "Språk"}, "skills": [{"name": "Rust", "strong": true}]}],
+  "options": {"language": "sv"}
+}`
+
+func TestDecodeLocalizedJSON(t *testing.T) {
+	var data ResumeData
+	warnings, err := DecodeLocalizedJSON(strings.NewReader(bilingualResume), &data, DecodeStrict, "")
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if data.Summary != "En mjukvaruutvecklare." || data.Positions[0] != "Mjukvaruutvecklare" || data.Positions[1] != "Rustacean" {
+		t.Errorf("Swedish values not selected: %+v", data)
+	}
+	if data.WorkExperience[0].Location != "Shanghai, Kina" || data.Skills[0].Name != "Språk" {
+		t.Errorf("Nested Swedish values not selected: %+v", data)
+	}
+	if data.WorkExperience[0].Description != "Database Engineer" {
+		t.Errorf("Expected English fallback, got %q", data.WorkExperience[0].Description)
+	}
+	want := []string{`work_experience[0].description: no translation for the requested language, using "en"`}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("Warnings = %v, want %v", warnings, want)
+	}
+}
+
+func TestDecodeLocalizedJSONOverride(t *testing.T) {
+	var data ResumeData
+	warnings, err := DecodeLocalizedJSON(strings.NewReader(bilingualResume), &data, DecodeStrict, "en-GB")
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if data.Summary != "A software developer." || data.Skills[0].Name != "Languages" {
+		t.Errorf("English values not selected: %+v", data)
+	}
+	if data.Options == nil || data.Options.Language != "en-GB" {
+		t.Errorf("Requested language not recorded in options: %+v", data.Options)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("Unexpected warnings: %v", warnings)
+	}
+}
+
+func TestDecodeLocalizedJSONFallbackLanguage(t *testing.T) {
+	input := `{"summary": {"de": "Entwickler", "sv": "Utvecklare"}, "options": {"language": "zh", "fallback_language": "sv"}}`
+	var data ResumeData
+	warnings, err := DecodeLocalizedJSON(strings.NewReader(input), &data, DecodeStrict, "")
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if data.Summary != "Utvecklare" || len(warnings) != 1 {
+		t.Errorf("Expected Swedish fallback with one warning, got %q %v", data.Summary, warnings)
+	}
+}
+
+func TestLocalizedValueInNonTextFieldIsTypeError(t *testing.T) {
+	var data ResumeData
+	_, err := DecodeLocalizedJSON(strings.NewReader(`{"skills": [{"name": "Languages", "skills": [{"name": "Go", "strong": {"en": true}}]}]}`), &data, DecodeLenient, "en")
+	if _, ok := err.(ValidationErrors); !ok {
+		t.Errorf("Expected a field error, got %v", err)
+	}
+}
+
+func TestTranslationReport(t *testing.T) {
+	report, err := TranslationReport([]byte(bilingualResume), reflect.TypeOf(ResumeData{}))
+	if err != nil {
+		t.Fatalf("Failed to build report: %v", err)
+	}
+	want := map[string][]string{
+		"en": {},
+		"sv": {"work_experience[0].description"},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("Report = %v, want %v", report, want)
+	}
+}
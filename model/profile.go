@@ -0,0 +1,101 @@
+package model
+
+import "strings"
+
+// Profile describes the author of a resume or cover letter.
+// The legacy resume spellings "firstname" and "lastname" are accepted on input.
+type Profile struct {
+	FirstName string `json:"first_name" alias:"firstname"`
+	LastName  string `json:"last_name" alias:"lastname"`
+	Email     string `json:"email"`
+	Homepage  string `json:"homepage,omitempty"`
+	Phone     string `json:"phone,omitempty"`
+	GitHub    string `json:"github,omitempty"`
+	LinkedIn  string `json:"linkedin,omitempty"`
+	Twitter   string `json:"twitter,omitempty"`
+	Birth     string `json:"birth,omitempty"`
+	// Address is a postal address on one line, e.g. "Gothenburg, Sweden"
+	Address     string `json:"address,omitempty"`
+	Nationality string `json:"nationality,omitempty"`
+	// VisaStatus describes the right to work, e.g. "EU work permit"
+	VisaStatus string `json:"visa_status,omitempty"`
+	// Links are further profiles such as GitLab, ORCID or Mastodon
+	Links []ProfileLink `json:"links,omitempty"`
+}
+
+// ProfileLink is a link to a profile on another site
+type ProfileLink struct {
+	// Type selects the icon, e.g. "gitlab", "orcid" or "mastodon"; other types get a generic link icon
+	Type  string `json:"type"`
+	URL   string `json:"url"`
+	Label string `json:"label,omitempty"`
+}
+
+// maxProfileLinks limits the number of extra links of a profile
+const maxProfileLinks = 10
+
+// profileLinkIcons maps link types to Font Awesome icon names
+var profileLinkIcons = map[string]string{
+	"bitbucket":     "bitbucket",
+	"codeberg":      "code-branch",
+	"gitlab":        "gitlab",
+	"kaggle":        "kaggle",
+	"mastodon":      "mastodon",
+	"orcid":         "orcid",
+	"researchgate":  "researchgate",
+	"scholar":       "graduation-cap",
+	"stackoverflow": "stack-overflow",
+	"website":       "globe",
+	"xing":          "xing",
+	"youtube":       "youtube",
+}
+
+// ProfileLinkType returns the link type shown with a Font Awesome icon, or "link" for the generic icon
+func ProfileLinkType(icon string) string {
+	for linkType, linkIcon := range profileLinkIcons {
+		if linkIcon == icon {
+			return linkType
+		}
+	}
+	return "link"
+}
+
+// ProfileEntry is an extra line of the author header with a Font Awesome icon and an optional link
+type ProfileEntry struct {
+	Text string
+	Icon string
+	Link string
+}
+
+// Name returns the full name of the author
+func (p Profile) Name() string {
+	return strings.TrimSpace(p.FirstName + " " + p.LastName)
+}
+
+// Entries returns the nationality, visa status and extra links as header entries
+func (p Profile) Entries() []ProfileEntry {
+	var entries []ProfileEntry
+	if p.Nationality != "" {
+		entries = append(entries, ProfileEntry{Text: p.Nationality, Icon: "flag"})
+	}
+	if p.VisaStatus != "" {
+		entries = append(entries, ProfileEntry{Text: p.VisaStatus, Icon: "passport"})
+	}
+	for _, link := range p.Links {
+		icon, ok := profileLinkIcons[strings.ToLower(link.Type)]
+		if !ok {
+			icon = "link"
+		}
+		entries = append(entries, ProfileEntry{Text: link.Text(), Icon: icon, Link: link.URL})
+	}
+	return entries
+}
+
+// Text returns the link label, defaulting to the URL without its scheme
+func (l ProfileLink) Text() string {
+	if l.Label != "" {
+		return l.Label
+	}
+	label := strings.TrimPrefix(strings.TrimPrefix(l.URL, "https://"), "http://")
+	return strings.TrimSuffix(label, "/")
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeLegacyProfileKeys(t *testing.T) {
+	var resume ResumeData
+	warnings, err := DecodeJSON(strings.NewReader(`{"author": {"firstname": "Jane", "lastname": "Doe"}}`), &resume, DecodeStrict)
+	if err != nil || len(warnings) != 0 {
+		t.Fatalf("Legacy resume keys should be accepted: %v %v", err, warnings)
+	}
+	if resume.Author.FirstName != "Jane" || resume.Author.LastName != "Doe" {
+		t.Errorf("Unexpected author: %+v", resume.Author)
+	}
+
+	var letter CoverLetterData
+	warnings, err = DecodeJSON(strings.NewReader(`{"firstname": "Old", "first_name": "Jane", "lastname": "Doe"}`), &letter, DecodeStrict)
+	if err != nil {
+		t.Fatalf("Legacy cover letter keys should be accepted: %v", err)
+	}
+	if letter.FirstName != "Jane" || letter.LastName != "Doe" {
+		t.Errorf("Unexpected profile: %+v", letter.Profile)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], `both "firstname" and "first_name"`) {
+		t.Errorf("Expected a warning about the duplicate name, got %v", warnings)
+	}
+}
+
+func TestValidateProfileLinks(t *testing.T) {
+	data := ResumeData{Author: Profile{Links: []ProfileLink{{Type: "mastodon", URL: "@jane@example.social"}, {URL: "https://example.com"}}}}
+	err := data.Validate()
+	if err == nil {
+		t.Fatal("Expected validation errors")
+	}
+	for _, field := range []string{"author.links[0].url", "author.links[1].type"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("Expected an error for %s, got %v", field, err)
+		}
+	}
+}
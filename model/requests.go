@@ -0,0 +1,161 @@
+package model
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// ApplicationRequest is the JSON request body for the application bundle endpoint.
+// Author is shared by both documents; author fields set in the documents themselves must match it.
+type ApplicationRequest struct {
+	Author      Profile         `json:"author"`
+	Company     string          `json:"company,omitempty"`
+	Resume      ResumeData      `json:"resume" openapi:"partial"`
+	CoverLetter CoverLetterData `json:"cover_letter" openapi:"partial"`
+}
+
+// Documents returns the resume and cover letter with the shared author filled in
+func (r ApplicationRequest) Documents() (ResumeData, CoverLetterData) {
+	resume, letter := r.Resume, r.CoverLetter
+	resume.Author = r.Author
+	letter.Profile = r.Author
+	return resume, letter
+}
+
+// Validate checks the shared author and both documents, and that the documents do not
+// set author details that differ from the shared ones
+func (r ApplicationRequest) Validate() error {
+	v := &validator{}
+	// Lengths and formats of the author fields are checked with the resume below
+	v.required("author.first_name", r.Author.FirstName)
+	v.required("author.last_name", r.Author.LastName)
+	v.required("author.email", r.Author.Email)
+	v.text("company", r.Company, maxShortTextLength)
+
+	conflict := func(field, shared, value string) {
+		if value != "" && value != shared {
+			v.add(field, "does not match the shared author")
+		}
+	}
+	conflict("resume.author.first_name", r.Author.FirstName, r.Resume.Author.FirstName)
+	conflict("resume.author.last_name", r.Author.LastName, r.Resume.Author.LastName)
+	conflict("resume.author.email", r.Author.Email, r.Resume.Author.Email)
+	conflict("cover_letter.first_name", r.Author.FirstName, r.CoverLetter.FirstName)
+	conflict("cover_letter.last_name", r.Author.LastName, r.CoverLetter.LastName)
+	conflict("cover_letter.email", r.Author.Email, r.CoverLetter.Email)
+
+	resume, letter := r.Documents()
+	for _, document := range []struct {
+		prefix string
+		err    error
+	}{
+		{"resume.", resume.Validate()},
+		{"cover_letter.", letter.Validate()},
+	} {
+		var validationErrs ValidationErrors
+		if !errors.As(document.err, &validationErrs) {
+			continue
+		}
+		for _, fieldErr := range validationErrs {
+			// Author problems are reported once, on the shared author
+			if document.prefix == "resume." && strings.HasPrefix(fieldErr.Field, "author.") {
+				v.add(fieldErr.Field, "%s", fieldErr.Message)
+			} else if document.prefix == "resume." || !isProfileField(fieldErr.Field) {
+				v.add(document.prefix+fieldErr.Field, "%s", fieldErr.Message)
+			}
+		}
+	}
+	return v.err()
+}
+
+// isProfileField reports whether a cover letter field path such as "links[0].url" belongs to the profile
+func isProfileField(field string) bool {
+	name := field
+	if i := strings.IndexAny(name, ".["); i != -1 {
+		name = name[:i]
+	}
+	_, ok := jsonFieldTypes(reflect.TypeOf(Profile{}))[name]
+	return ok
+}
+
+// KeywordMatchRequest is the JSON request body for the keyword match endpoint
+type KeywordMatchRequest struct {
+	JobDescription string           `json:"job_description"`
+	Resume         *ResumeData      `json:"resume,omitempty"`
+	CoverLetter    *CoverLetterData `json:"cover_letter,omitempty"`
+}
+
+// maxJobDescriptionLength limits the size of job descriptions accepted for matching
+const maxJobDescriptionLength = 50000
+
+// Validate checks that the request has a job description and something to compare it with
+func (r KeywordMatchRequest) Validate() error {
+	v := &validator{}
+	v.requiredText("job_description", r.JobDescription, maxJobDescriptionLength)
+	if r.Resume == nil && r.CoverLetter == nil {
+		v.add("resume", "a resume or cover letter is required")
+	}
+	return v.err()
+}
+
+// draftTones are the supported values of DraftOptions.Tone; the first is the default
+var draftTones = []string{"formal", "friendly", "enthusiastic", "concise"}
+
+// DraftOptions controls the framing and tone of a drafted cover letter
+type DraftOptions struct {
+	// Tone is "formal" (default), "friendly", "enthusiastic" or "concise"
+	Tone string `json:"tone,omitempty"`
+	// Company, Position and Addressee default to generic wording and the first resume position
+	Company   string `json:"company,omitempty"`
+	Position  string `json:"position,omitempty"`
+	Addressee string `json:"addressee,omitempty"`
+	// Language is the language of the letter; it also selects the localized salutation and closing
+	Language string `json:"language,omitempty"`
+}
+
+// DraftRequest is the JSON request body for the cover letter draft endpoint
+type DraftRequest struct {
+	Resume         ResumeData   `json:"resume"`
+	JobDescription string       `json:"job_description"`
+	Options        DraftOptions `json:"options,omitempty"`
+}
+
+// Validate checks the resume, the job description and the draft options
+func (r DraftRequest) Validate() error {
+	v := &validator{}
+	var resumeErrs ValidationErrors
+	if err := r.Resume.Validate(); err != nil && !errors.As(err, &resumeErrs) {
+		return err
+	}
+	for _, fieldErr := range resumeErrs {
+		v.add("resume."+fieldErr.Field, "%s", fieldErr.Message)
+	}
+	v.requiredText("job_description", r.JobDescription, maxJobDescriptionLength)
+	if r.Options.Tone != "" && !containsString(draftTones, r.Options.Tone) {
+		v.add("options.tone", "must be one of %s", strings.Join(draftTones, ", "))
+	}
+	v.text("options.company", r.Options.Company, maxShortTextLength)
+	v.text("options.position", r.Options.Position, maxShortTextLength)
+	v.text("options.addressee", r.Options.Addressee, maxShortTextLength)
+	v.maxLength("options.language", r.Options.Language, 35)
+	return v.err()
+}
+
+// ToneOrDefault returns the requested tone or the default one
+func (o DraftOptions) ToneOrDefault() string {
+	if o.Tone == "" {
+		return draftTones[0]
+	}
+	return o.Tone
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplicationRequestValidate(t *testing.T) {
+	req := ApplicationRequest{
+		Resume:      ResumeData{Author: Profile{Email: "other@example.com"}},
+		CoverLetter: CoverLetterData{Position: "Engineer", Addressee: "Team", Opening: "Hello."},
+	}
+	err := req.Validate()
+	validationErrs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("Expected validation errors, got %v", err)
+	}
+	var fields []string
+	for _, fieldErr := range validationErrs {
+		fields = append(fields, fieldErr.Field)
+	}
+	// Missing author details are reported once on the shared author, not per document
+	want := []string{"author.first_name", "author.last_name", "author.email", "resume.author.email"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("Fields = %v, want %v", fields, want)
+	}
+
+	req.Author = Profile{FirstName: "Jane", LastName: "Doe", Email: "jane@example.com"}
+	req.Resume.Author = Profile{}
+	if err := req.Validate(); err != nil {
+		t.Errorf("Unexpected validation error: %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+// Package model defines the resume and cover letter documents and the API requests built from them,
+// with their validation, JSON decoding, dates and tag-based tailoring.
+package model
+
+// ResumeEntry represents a single entry with title, location, date, and content
+type ResumeEntry struct {
+	Title    string `json:"title"`
+	Location string `json:"location,omitempty"`
+	Date     string `json:"date,omitempty"`
+	// Dates is the structured form of Date; when set it replaces Date at render time
+	Dates       DateRanges `json:"dates,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Content     string     `json:"content,omitempty"`
+	// Bullets are rendered as a list after Content and can be selected by tag
+	Bullets []Bullet `json:"bullets,omitempty"`
+	// Tags select the entry for tailored variants; Priority decides what is kept under max_items
+	Tags     []string `json:"tags,omitempty"`
+	Priority int      `json:"priority,omitempty"`
+}
+
+// SkillCategory represents a category of skills with a name and list of skills
+type SkillCategory struct {
+	Name   string      `json:"name"`
+	Skills []SkillItem `json:"skills"`
+}
+
+// SkillItem represents a single skill with its name and whether it's strong/emphasized
+type SkillItem struct {
+	Name     string   `json:"name"`
+	Strong   bool     `json:"strong"`
+	Tags     []string `json:"tags,omitempty"`
+	Priority int      `json:"priority,omitempty"`
+}
+
+// InterestItem represents a single interest with a category and description
+type InterestItem struct {
+	Category    string `json:"category"`
+	Description string `json:"description"`
+}
+
+// ResumeData represents the complete data structure for rendering a resume
+type ResumeData struct {
+	Author         Profile         `json:"author"`
+	Positions      []string        `json:"positions"`
+	Summary        string          `json:"summary"`
+	Education      []ResumeEntry   `json:"education"`
+	WorkExperience []ResumeEntry   `json:"work_experience"`
+	Projects       []ResumeEntry   `json:"projects"`
+	Skills         []SkillCategory `json:"skills"`
+	Interests      []InterestItem  `json:"interests"`
+	Options        *RenderOptions  `json:"options,omitempty"`
+}
+
+// RenderOptions controls presentation choices that are not part of the document content
+type RenderOptions struct {
+	// Language selects the message catalog used for headings, salutations and dates, e.g. "en", "sv", "de" or "zh"
+	Language string `json:"language,omitempty"`
+	// FallbackLanguage is used for per-language values missing the requested language; defaults to "en"
+	FallbackLanguage string `json:"fallback_language,omitempty"`
+	// SortByDate orders education, work experience and projects reverse-chronologically
+	SortByDate bool `json:"sort_by_date,omitempty"`
+	// Include and Exclude are tag expressions such as "embedded | (cloud & !legacy)" selecting
+	// tagged entries, bullets and skills; untagged items are always kept
+	Include string `json:"include,omitempty"`
+	Exclude string `json:"exclude,omitempty"`
+	// MaxItems limits the items kept per section ("education", "work_experience", "projects",
+	// "skills" per category, "bullets" per entry), dropping the lowest priorities first
+	MaxItems map[string]int `json:"max_items,omitempty"`
+	// SortByPriority orders items by descending priority
+	SortByPriority bool `json:"sort_by_priority,omitempty"`
+	// MaxPages is the page budget of the compiled resume; content is trimmed until it fits
+	MaxPages int `json:"max_pages,omitempty"`
+}
+
+// Language returns the requested render language, or "" if none was requested
+func (d ResumeData) Language() string {
+	if d.Options == nil {
+		return ""
+	}
+	return d.Options.Language
+}
+
+// PrepareForRender returns a copy of the data with structured dates formatted, sections sorted
+// and tagged items selected as requested by the options
+func (d ResumeData) PrepareForRender() (ResumeData, error) {
+	var options RenderOptions
+	if d.Options != nil {
+		options = *d.Options
+	}
+	filter, err := newTagFilter(options)
+	if err != nil {
+		return d, err
+	}
+
+	sections := []struct {
+		name    string
+		entries *[]ResumeEntry
+	}{
+		{"education", &d.Education},
+		{"work_experience", &d.WorkExperience},
+		{"projects", &d.Projects},
+	}
+	for _, section := range sections {
+		entries := append([]ResumeEntry(nil), (*section.entries)...)
+		for i := range entries {
+			if len(entries[i].Dates) > 0 {
+				entries[i].Date = entries[i].Dates.Format(options.Language)
+			} else if options.Language != "" {
+				// Free-text dates are only rewritten when a language is requested and they can be understood
+				if dates, err := ParseDateRanges(entries[i].Date); err == nil {
+					entries[i].Date = dates.Format(options.Language)
+				}
+			}
+		}
+		if options.SortByDate {
+			SortEntriesByDate(entries)
+		}
+		entries = tailorEntries(entries, section.name, options, filter)
+		for i := range entries {
+			entries[i].Content = bulletContent(entries[i].Content, entries[i].Bullets)
+			entries[i].Bullets = nil
+		}
+		*section.entries = entries
+	}
+	d.Skills = tailorSkills(d.Skills, options, filter)
+	d.Positions = tailorPositions(d.Positions, filter)
+	return d, nil
+}
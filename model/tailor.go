@@ -0,0 +1,317 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Bullet is a single bullet point of a resume entry that can be selected by tag
+type Bullet struct {
+	Text     string   `json:"text"`
+	Tags     []string `json:"tags,omitempty"`
+	Priority int      `json:"priority,omitempty"`
+}
+
+// TagExpression is a parsed tag expression evaluated against the tags of an item
+type TagExpression interface {
+	eval(tags map[string]bool) bool
+}
+
+type tagName string
+type tagNot struct{ operand TagExpression }
+type tagAnd struct{ left, right TagExpression }
+type tagOr struct{ left, right TagExpression }
+
+func (t tagName) eval(tags map[string]bool) bool { return tags[string(t)] }
+func (t tagNot) eval(tags map[string]bool) bool  { return !t.operand.eval(tags) }
+func (t tagAnd) eval(tags map[string]bool) bool  { return t.left.eval(tags) && t.right.eval(tags) }
+func (t tagOr) eval(tags map[string]bool) bool   { return t.left.eval(tags) || t.right.eval(tags) }
+
+// ParseTagExpression parses expressions such as "embedded | (cloud & !legacy)".
+// "|", "," and "or" mean or; "&", "+" and "and" mean and; "!" and "not" negate.
+// Tag names are case-insensitive. An empty expression returns nil.
+func ParseTagExpression(text string) (TagExpression, error) {
+	tokens, err := tokenizeTagExpression(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	p := &tagParser{tokens: tokens}
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in tag expression", p.tokens[p.pos])
+	}
+	return expr, nil
+}
+
+func tokenizeTagExpression(text string) ([]string, error) {
+	var tokens []string
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case strings.ContainsRune("|,&+!()", r):
+			tokens = append(tokens, string(r))
+			i++
+		case isTagRune(r):
+			start := i
+			for i < len(runes) && isTagRune(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, strings.ToLower(string(runes[start:i])))
+		default:
+			return nil, fmt.Errorf("invalid character %q in tag expression", r)
+		}
+	}
+	return tokens, nil
+}
+
+func isTagRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.' || r == '/'
+}
+
+// tagParser is a recursive-descent parser over expression tokens
+type tagParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *tagParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *tagParser) or() (TagExpression, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "|" || p.peek() == "," || p.peek() == "or" {
+		p.pos++
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = tagOr{left, right}
+	}
+	return left, nil
+}
+
+func (p *tagParser) and() (TagExpression, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "&" || p.peek() == "+" || p.peek() == "and" {
+		p.pos++
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = tagAnd{left, right}
+	}
+	return left, nil
+}
+
+func (p *tagParser) unary() (TagExpression, error) {
+	token := p.peek()
+	switch token {
+	case "":
+		return nil, fmt.Errorf("unexpected end of tag expression")
+	case "!", "not":
+		p.pos++
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return tagNot{operand}, nil
+	case "(":
+		p.pos++
+		expr, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing ) in tag expression")
+		}
+		p.pos++
+		return expr, nil
+	case ")", "|", ",", "&", "+", "or", "and":
+		return nil, fmt.Errorf("unexpected %q in tag expression", token)
+	}
+	p.pos++
+	return tagName(token), nil
+}
+
+// positiveTags returns the tag names of an expression that are not negated
+func positiveTags(expr TagExpression, negated bool, names map[string]bool) {
+	switch e := expr.(type) {
+	case tagName:
+		if !negated {
+			names[string(e)] = true
+		}
+	case tagNot:
+		positiveTags(e.operand, !negated, names)
+	case tagAnd:
+		positiveTags(e.left, negated, names)
+		positiveTags(e.right, negated, names)
+	case tagOr:
+		positiveTags(e.left, negated, names)
+		positiveTags(e.right, negated, names)
+	}
+}
+
+// tagFilter selects items by their tags. Untagged items are common to every variant
+// and are always kept; tagged items must match Include (if set) and must not match Exclude.
+type tagFilter struct {
+	include TagExpression
+	exclude TagExpression
+}
+
+func newTagFilter(options RenderOptions) (tagFilter, error) {
+	include, err := ParseTagExpression(options.Include)
+	if err != nil {
+		return tagFilter{}, fmt.Errorf("invalid include expression: %w", err)
+	}
+	exclude, err := ParseTagExpression(options.Exclude)
+	if err != nil {
+		return tagFilter{}, fmt.Errorf("invalid exclude expression: %w", err)
+	}
+	return tagFilter{include: include, exclude: exclude}, nil
+}
+
+func (f tagFilter) keep(tags []string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	set := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		set[strings.ToLower(strings.TrimSpace(tag))] = true
+	}
+	if f.include != nil && !f.include.eval(set) {
+		return false
+	}
+	return f.exclude == nil || !f.exclude.eval(set)
+}
+
+// limitByPriority keeps at most max of the items, dropping the lowest priorities first and
+// the later items among equal priorities. The kept items stay in their original order.
+func limitByPriority(count, max int, priority func(i int) int) []int {
+	indexes := make([]int, count)
+	for i := range indexes {
+		indexes[i] = i
+	}
+	if max <= 0 || count <= max {
+		return indexes
+	}
+	sort.SliceStable(indexes, func(a, b int) bool {
+		return priority(indexes[a]) > priority(indexes[b])
+	})
+	indexes = indexes[:max]
+	sort.Ints(indexes)
+	return indexes
+}
+
+// tailorEntries filters, orders and limits the entries of one section and their bullets
+func tailorEntries(entries []ResumeEntry, section string, options RenderOptions, filter tagFilter) []ResumeEntry {
+	var kept []ResumeEntry
+	for _, entry := range entries {
+		if !filter.keep(entry.Tags) {
+			continue
+		}
+		var bullets []Bullet
+		for _, bullet := range entry.Bullets {
+			if filter.keep(bullet.Tags) {
+				bullets = append(bullets, bullet)
+			}
+		}
+		var limited []Bullet
+		for _, i := range limitByPriority(len(bullets), options.MaxItems["bullets"], func(i int) int { return bullets[i].Priority }) {
+			limited = append(limited, bullets[i])
+		}
+		if options.SortByPriority {
+			sort.SliceStable(limited, func(a, b int) bool { return limited[a].Priority > limited[b].Priority })
+		}
+		entry.Bullets = limited
+		kept = append(kept, entry)
+	}
+
+	var limited []ResumeEntry
+	for _, i := range limitByPriority(len(kept), options.MaxItems[section], func(i int) int { return kept[i].Priority }) {
+		limited = append(limited, kept[i])
+	}
+	if options.SortByPriority {
+		sort.SliceStable(limited, func(a, b int) bool { return limited[a].Priority > limited[b].Priority })
+	}
+	return limited
+}
+
+// tailorSkills filters and limits the skills of every category, dropping categories left empty
+func tailorSkills(categories []SkillCategory, options RenderOptions, filter tagFilter) []SkillCategory {
+	var kept []SkillCategory
+	for _, category := range categories {
+		var skills []SkillItem
+		for _, skill := range category.Skills {
+			if filter.keep(skill.Tags) {
+				skills = append(skills, skill)
+			}
+		}
+		if len(skills) == 0 && len(category.Skills) > 0 {
+			continue
+		}
+		var limited []SkillItem
+		for _, i := range limitByPriority(len(skills), options.MaxItems["skills"], func(i int) int { return skills[i].Priority }) {
+			limited = append(limited, skills[i])
+		}
+		if options.SortByPriority {
+			sort.SliceStable(limited, func(a, b int) bool { return limited[a].Priority > limited[b].Priority })
+		}
+		category.Skills = limited
+		kept = append(kept, category)
+	}
+	return kept
+}
+
+// tailorPositions moves the positions mentioning an included tag to the front
+func tailorPositions(positions []string, filter tagFilter) []string {
+	if filter.include == nil {
+		return positions
+	}
+	names := make(map[string]bool)
+	positiveTags(filter.include, false, names)
+	matches := func(position string) bool {
+		lower := strings.ToLower(position)
+		for name := range names {
+			if strings.Contains(lower, name) {
+				return true
+			}
+		}
+		return false
+	}
+	ordered := append([]string(nil), positions...)
+	sort.SliceStable(ordered, func(a, b int) bool { return matches(ordered[a]) && !matches(ordered[b]) })
+	return ordered
+}
+
+// bulletContent appends the bullets of an entry to its free-text content as a Typst list
+func bulletContent(content string, bullets []Bullet) string {
+	lines := make([]string, 0, len(bullets)+1)
+	if strings.TrimSpace(content) != "" {
+		lines = append(lines, content)
+	}
+	for _, bullet := range bullets {
+		lines = append(lines, "- "+bullet.Text)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,132 @@
+package model
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTagExpression(t *testing.T) {
+	tests := []struct {
+		expr string
+		tags []string
+		want bool
+	}{
+		{"embedded", []string{"embedded"}, true},
+		{"embedded", []string{"cloud"}, false},
+		{"Embedded | cloud", []string{"cloud"}, true},
+		{"embedded, cloud", []string{"fullstack"}, false},
+		{"cloud & !legacy", []string{"cloud", "legacy"}, false},
+		{"cloud + !legacy", []string{"cloud"}, true},
+		{"not (embedded or cloud)", []string{"fullstack"}, true},
+		{"embedded | cloud & legacy", []string{"embedded"}, true},
+		{"(embedded | cloud) & legacy", []string{"embedded"}, false},
+	}
+	for _, tc := range tests {
+		expr, err := ParseTagExpression(tc.expr)
+		if err != nil {
+			t.Errorf("ParseTagExpression(%q) failed: %v", tc.expr, err)
+			continue
+		}
+		set := map[string]bool{}
+		for _, tag := range tc.tags {
+			set[tag] = true
+		}
+		if got := expr.eval(set); got != tc.want {
+			t.Errorf("%q on %v = %v, want %v", tc.expr, tc.tags, got, tc.want)
+		}
+	}
+
+	for _, expr := range []string{"embedded |", "(cloud", "cloud)", "& cloud", "cloud $ web"} {
+		if _, err := ParseTagExpression(expr); err == nil {
+			t.Errorf("ParseTagExpression(%q) should fail", expr)
+		}
+	}
+}
+
+func tailoredResume() ResumeData {
+	return ResumeData{
+		Positions: []string{"Software Engineer", "Embedded developer", "Cloud developer"},
+		Projects: []ResumeEntry{
+			{Title: "RTOS kernel", Tags: []string{"embedded"}, Priority: 1},
+			{Title: "Web shop", Tags: []string{"fullstack"}},
+			{Title: "Compiler", Priority: 5, Bullets: []Bullet{
+				{Text: "RISC-V backend", Tags: []string{"embedded"}},
+				{Text: "WASM backend", Tags: []string{"fullstack"}},
+				{Text: "Optimizer", Priority: 2},
+			}},
+			{Title: "Watch firmware", Tags: []string{"embedded"}, Priority: 3},
+		},
+		Skills: []SkillCategory{
+			{Name: "Languages", Skills: []SkillItem{{Name: "C", Tags: []string{"embedded"}}, {Name: "Rust"}, {Name: "TypeScript", Tags: []string{"fullstack"}}}},
+			{Name: "Frontend", Skills: []SkillItem{{Name: "React", Tags: []string{"fullstack"}}}},
+		},
+	}
+}
+
+func TestPrepareForRenderTailorsResume(t *testing.T) {
+	data := tailoredResume()
+	data.Options = &RenderOptions{Include: "embedded", MaxItems: map[string]int{"projects": 2}}
+
+	prepared, err := data.PrepareForRender()
+	if err != nil {
+		t.Fatalf("Failed to prepare resume: %v", err)
+	}
+
+	var titles []string
+	for _, entry := range prepared.Projects {
+		titles = append(titles, entry.Title)
+	}
+	// The untagged compiler and the higher-priority watch survive, in their original order
+	if want := []string{"Compiler", "Watch firmware"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("Projects = %v, want %v", titles, want)
+	}
+	if content := prepared.Projects[0].Content; content != "- RISC-V backend\n- Optimizer" {
+		t.Errorf("Unexpected bullets: %q", content)
+	}
+	if len(prepared.Skills) != 1 || len(prepared.Skills[0].Skills) != 2 {
+		t.Errorf("Unexpected skills: %+v", prepared.Skills)
+	}
+	if prepared.Positions[0] != "Embedded developer" {
+		t.Errorf("Matching position should come first: %v", prepared.Positions)
+	}
+	if len(data.Projects) != 4 {
+		t.Error("Tailoring should not modify the caller's data")
+	}
+}
+
+func TestPrepareForRenderSortsByPriority(t *testing.T) {
+	data := tailoredResume()
+	data.Options = &RenderOptions{Exclude: "fullstack", SortByPriority: true, MaxItems: map[string]int{"bullets": 1}}
+
+	prepared, err := data.PrepareForRender()
+	if err != nil {
+		t.Fatalf("Failed to prepare resume: %v", err)
+	}
+	var titles []string
+	for _, entry := range prepared.Projects {
+		titles = append(titles, entry.Title)
+	}
+	if want := []string{"Compiler", "Watch firmware", "RTOS kernel"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("Projects = %v, want %v", titles, want)
+	}
+	if content := prepared.Projects[0].Content; content != "- Optimizer" {
+		t.Errorf("Expected only the highest-priority bullet, got %q", content)
+	}
+}
+
+func TestValidateTailoringOptions(t *testing.T) {
+	data := tailoredResume()
+	data.Projects[0].Tags = append(data.Projects[0].Tags, "two words")
+	data.Options = &RenderOptions{Include: "embedded |", MaxItems: map[string]int{"hobbies": 1}}
+
+	err := data.Validate()
+	if err == nil {
+		t.Fatal("Expected validation errors")
+	}
+	for _, field := range []string{"projects[0].tags[1]", "options.include", "options.max_items.hobbies"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("Expected an error for %s, got %v", field, err)
+		}
+	}
+}
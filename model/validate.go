@@ -0,0 +1,342 @@
+package model
+
+import (
+	"embed"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+//go:embed schemas/*.schema.json
+var embeddedSchemas embed.FS
+
+// Field length limits shared by validation and the published JSON Schemas
+const (
+	maxNameLength      = 100
+	maxShortTextLength = 300
+	maxLongTextLength  = 5000
+	maxParagraphs      = 10
+)
+
+// FieldError describes a problem with a single input field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors lists every problem found in an input document
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fmt.Sprintf("%s: %s", fieldErr.Field, fieldErr.Message)
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// validator accumulates field errors
+type validator struct {
+	errors ValidationErrors
+}
+
+func (v *validator) add(field, format string, args ...interface{}) {
+	v.errors = append(v.errors, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// err returns the accumulated errors, or nil if there are none
+func (v *validator) err() error {
+	if len(v.errors) == 0 {
+		return nil
+	}
+	return v.errors
+}
+
+func (v *validator) required(field, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		v.add(field, "is required")
+		return false
+	}
+	return true
+}
+
+func (v *validator) maxLength(field, value string, max int) {
+	if n := utf8.RuneCountInString(value); n > max {
+		v.add(field, "must be at most %d characters, got %d", max, n)
+	}
+}
+
+// text validates an optional free-text field
+func (v *validator) text(field, value string, max int) {
+	v.maxLength(field, value, max)
+}
+
+// requiredText validates a mandatory free-text field
+func (v *validator) requiredText(field, value string, max int) {
+	if v.required(field, value) {
+		v.maxLength(field, value, max)
+	}
+}
+
+var emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
+func (v *validator) email(field, value string) {
+	if value != "" && !emailPattern.MatchString(value) {
+		v.add(field, "must be a valid email address")
+	}
+}
+
+var (
+	phonePattern = regexp.MustCompile(`^[0-9+()\-.\s]{5,30}$`)
+	digitPattern = regexp.MustCompile(`\d`)
+)
+
+func (v *validator) phone(field, value string) {
+	if value != "" && (!phonePattern.MatchString(value) || len(digitPattern.FindAllString(value, -1)) < 5) {
+		v.add(field, "must be a valid phone number")
+	}
+}
+
+func (v *validator) url(field, value string) {
+	if value == "" {
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.add(field, "must be a valid http or https URL")
+	}
+}
+
+var handlePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.\-]{0,99}$`)
+
+// handle validates a username on a social network
+func (v *validator) handle(field, value string) {
+	if value != "" && !handlePattern.MatchString(value) {
+		v.add(field, "must be a username, not a URL")
+	}
+}
+
+var yearPattern = regexp.MustCompile(`(?:^|\D)(19|20)\d\d(?:\D|$)`)
+
+// date validates free-text dates such as "Aug. 2023 - Now", which must mention a year
+func (v *validator) date(field, value string) {
+	if value != "" && !yearPattern.MatchString(value) {
+		v.add(field, "must be a date containing a four-digit year")
+	}
+}
+
+// dateRanges validates structured dates: months must exist and ranges must not end before they start
+func (v *validator) dateRanges(field string, ranges DateRanges) {
+	for i, r := range ranges {
+		prefix := fmt.Sprintf("%s[%d]", field, i)
+		if r.Start == nil {
+			v.add(prefix+".start", "is required")
+		}
+		for _, side := range []struct {
+			name  string
+			point *YearMonth
+		}{{"start", r.Start}, {"end", r.End}} {
+			name, point := side.name, side.point
+			if point == nil {
+				continue
+			}
+			if point.Year < 1900 || point.Year > 2100 {
+				v.add(prefix+"."+name+".year", "must be a four-digit year")
+			}
+			if point.Month < 0 || point.Month > 12 {
+				v.add(prefix+"."+name+".month", "must be between 1 and 12")
+			}
+		}
+		if r.Ongoing && r.End != nil {
+			v.add(prefix+".end", "must be empty for an ongoing range")
+		}
+		if r.Start != nil && r.End != nil && r.End.before(*r.Start) {
+			v.add(prefix+".end", "must not be before the start")
+		}
+		v.text(prefix+".note", r.Note, maxShortTextLength)
+	}
+}
+
+var tagPattern = regexp.MustCompile(`^[\pL\pN_./-]{1,50}$`)
+
+// tags validates the tags of an item, which must be usable in tag expressions
+func (v *validator) tags(field string, tags []string) {
+	for i, tag := range tags {
+		if !tagPattern.MatchString(tag) {
+			v.add(fmt.Sprintf("%s[%d]", field, i), "must be a single word of letters, digits, '_', '-', '.' or '/'")
+		}
+	}
+}
+
+// tagExpression validates an include or exclude expression
+func (v *validator) tagExpression(field, value string) {
+	if _, err := ParseTagExpression(value); err != nil {
+		v.add(field, "%v", err)
+	}
+}
+
+// profile validates the fields of a profile, prefixing field names with prefix.
+// requireName makes the name and email mandatory.
+func (v *validator) profile(prefix string, p Profile, requireName bool) {
+	if requireName {
+		v.requiredText(prefix+"first_name", p.FirstName, maxNameLength)
+		v.requiredText(prefix+"last_name", p.LastName, maxNameLength)
+		if v.required(prefix+"email", p.Email) {
+			v.email(prefix+"email", p.Email)
+		}
+	} else {
+		v.text(prefix+"first_name", p.FirstName, maxNameLength)
+		v.text(prefix+"last_name", p.LastName, maxNameLength)
+		v.email(prefix+"email", p.Email)
+	}
+	v.url(prefix+"homepage", p.Homepage)
+	v.phone(prefix+"phone", p.Phone)
+	v.handle(prefix+"github", p.GitHub)
+	v.handle(prefix+"linkedin", p.LinkedIn)
+	v.handle(prefix+"twitter", p.Twitter)
+	v.date(prefix+"birth", p.Birth)
+	v.text(prefix+"address", p.Address, maxShortTextLength)
+	v.text(prefix+"nationality", p.Nationality, maxNameLength)
+	v.text(prefix+"visa_status", p.VisaStatus, maxNameLength)
+	if len(p.Links) > maxProfileLinks {
+		v.add(prefix+"links", "must contain at most %d links, got %d", maxProfileLinks, len(p.Links))
+	}
+	for i, link := range p.Links {
+		field := fmt.Sprintf("%slinks[%d]", prefix, i)
+		v.requiredText(field+".type", link.Type, maxNameLength)
+		if v.required(field+".url", link.URL) {
+			v.url(field+".url", link.URL)
+		}
+		v.text(field+".label", link.Label, maxNameLength)
+	}
+}
+
+// Validate checks a cover letter for missing or malformed fields
+func (d CoverLetterData) Validate() error {
+	v := &validator{}
+
+	v.profile("", d.Profile, true)
+	v.requiredText("position", d.Position, maxShortTextLength)
+	v.requiredText("addressee", d.Addressee, maxShortTextLength)
+
+	v.text("opening", d.Opening, maxLongTextLength)
+	v.text("about_me", d.AboutMe, maxLongTextLength)
+	v.text("why_me", d.WhyMe, maxLongTextLength)
+	v.text("why_company", d.WhyCompany, maxLongTextLength)
+	if len(d.ContentParagraphs()) == 0 {
+		v.add("paragraphs", "at least one paragraph is required")
+	}
+	if len(d.Paragraphs) > maxParagraphs {
+		v.add("paragraphs", "must contain at most %d paragraphs, got %d", maxParagraphs, len(d.Paragraphs))
+	}
+	for i, paragraph := range d.Paragraphs {
+		v.text(fmt.Sprintf("paragraphs[%d].heading", i), paragraph.Heading, maxShortTextLength)
+		v.requiredText(fmt.Sprintf("paragraphs[%d].text", i), paragraph.Text, maxLongTextLength)
+	}
+
+	v.date("date", d.Date)
+	v.text("recipient_address", d.RecipientAddress, maxShortTextLength)
+	v.text("salutation", d.Salutation, maxShortTextLength)
+	v.text("closing", d.Closing, maxShortTextLength)
+	v.text("signature", d.Signature, maxShortTextLength)
+	if d.Options != nil {
+		v.maxLength("options.language", d.Options.Language, 35)
+		v.maxLength("options.fallback_language", d.Options.FallbackLanguage, 35)
+	}
+
+	return v.err()
+}
+
+// Validate checks a resume for missing or malformed fields
+func (d ResumeData) Validate() error {
+	v := &validator{}
+
+	v.profile("author.", d.Author, false)
+
+	for i, position := range d.Positions {
+		v.requiredText(fmt.Sprintf("positions[%d]", i), position, maxShortTextLength)
+	}
+	v.text("summary", d.Summary, maxLongTextLength)
+
+	sections := []struct {
+		name    string
+		entries []ResumeEntry
+	}{
+		{"education", d.Education},
+		{"work_experience", d.WorkExperience},
+		{"projects", d.Projects},
+	}
+	for _, section := range sections {
+		for i, entry := range section.entries {
+			prefix := fmt.Sprintf("%s[%d]", section.name, i)
+			v.requiredText(prefix+".title", entry.Title, maxShortTextLength)
+			v.text(prefix+".location", entry.Location, maxShortTextLength)
+			v.date(prefix+".date", entry.Date)
+			v.dateRanges(prefix+".dates", entry.Dates)
+			v.text(prefix+".description", entry.Description, maxShortTextLength)
+			v.text(prefix+".content", entry.Content, maxLongTextLength)
+			v.tags(prefix+".tags", entry.Tags)
+			for j, bullet := range entry.Bullets {
+				bulletPrefix := fmt.Sprintf("%s.bullets[%d]", prefix, j)
+				v.requiredText(bulletPrefix+".text", bullet.Text, maxLongTextLength)
+				v.tags(bulletPrefix+".tags", bullet.Tags)
+			}
+		}
+	}
+
+	for i, category := range d.Skills {
+		prefix := fmt.Sprintf("skills[%d]", i)
+		v.requiredText(prefix+".name", category.Name, maxNameLength)
+		for j, skill := range category.Skills {
+			v.requiredText(fmt.Sprintf("%s.skills[%d].name", prefix, j), skill.Name, maxNameLength)
+			v.tags(fmt.Sprintf("%s.skills[%d].tags", prefix, j), skill.Tags)
+		}
+	}
+
+	for i, interest := range d.Interests {
+		prefix := fmt.Sprintf("interests[%d]", i)
+		v.requiredText(prefix+".category", interest.Category, maxNameLength)
+		v.text(prefix+".description", interest.Description, maxLongTextLength)
+	}
+
+	if d.Options != nil {
+		v.maxLength("options.language", d.Options.Language, 35)
+		v.maxLength("options.fallback_language", d.Options.FallbackLanguage, 35)
+		v.tagExpression("options.include", d.Options.Include)
+		v.tagExpression("options.exclude", d.Options.Exclude)
+		for _, key := range sortedKeys(d.Options.MaxItems) {
+			if !maxItemsSections[key] {
+				v.add("options.max_items."+key, "unknown section")
+			} else if d.Options.MaxItems[key] < 0 {
+				v.add("options.max_items."+key, "must not be negative")
+			}
+		}
+		if d.Options.MaxPages < 0 {
+			v.add("options.max_pages", "must not be negative")
+		}
+	}
+
+	return v.err()
+}
+
+// maxItemsSections are the keys accepted in options.max_items
+var maxItemsSections = map[string]bool{
+	"education": true, "work_experience": true, "projects": true, "skills": true, "bullets": true,
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Schema returns the published JSON Schema for a document type ("coverletter" or "resume")
+func Schema(name string) ([]byte, error) {
+	return embeddedSchemas.ReadFile("schemas/" + name + ".schema.json")
+}
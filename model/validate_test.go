@@ -0,0 +1,140 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"cvcl-render/examples"
+)
+
+func TestValidateExamples(t *testing.T) {
+	var coverLetter CoverLetterData
+	if err := json.Unmarshal(examples.CoverLetter, &coverLetter); err != nil {
+		t.Fatalf("Failed to parse the example cover letter: %v", err)
+	}
+	if err := coverLetter.Validate(); err != nil {
+		t.Errorf("Expected example cover letter to be valid: %v", err)
+	}
+
+	var resume ResumeData
+	if err := json.Unmarshal(examples.Resume, &resume); err != nil {
+		t.Fatalf("Failed to parse the example resume: %v", err)
+	}
+	if err := resume.Validate(); err != nil {
+		t.Errorf("Expected example resume to be valid: %v", err)
+	}
+}
+
+func TestValidateCoverLetterReportsEveryProblem(t *testing.T) {
+	data := CoverLetterData{
+		Profile: Profile{
+			FirstName: "John",
+			Email:     "not-an-email",
+			Homepage:  "johndoe.com",
+			Phone:     "call me",
+			GitHub:    "https://github.com/johndoe",
+		},
+		Position:  strings.Repeat("x", maxShortTextLength+1),
+		Addressee: "Hiring Manager",
+		Date:      "yesterday",
+	}
+
+	err := data.Validate()
+	var validationErrs ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		t.Fatalf("Expected ValidationErrors, got %v", err)
+	}
+
+	fields := make(map[string]bool)
+	for _, fieldErr := range validationErrs {
+		fields[fieldErr.Field] = true
+	}
+	for _, field := range []string{"last_name", "email", "homepage", "phone", "github", "position", "paragraphs", "date"} {
+		if !fields[field] {
+			t.Errorf("Expected error for %s, got %v", field, validationErrs)
+		}
+	}
+	if fields["first_name"] || fields["addressee"] {
+		t.Errorf("Unexpected errors for valid fields: %v", validationErrs)
+	}
+}
+
+func TestValidateResumeFieldPaths(t *testing.T) {
+	data := ResumeData{
+		Projects: []ResumeEntry{{Title: "Ok"}, {Date: "soon"}},
+		Skills:   []SkillCategory{{Name: "Languages", Skills: []SkillItem{{Name: ""}}}},
+	}
+
+	err := data.Validate()
+	var validationErrs ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		t.Fatalf("Expected ValidationErrors, got %v", err)
+	}
+	expected := ValidationErrors{
+		{Field: "projects[1].title", Message: "is required"},
+		{Field: "projects[1].date", Message: "must be a date containing a four-digit year"},
+		{Field: "skills[0].skills[0].name", Message: "is required"},
+	}
+	if !reflect.DeepEqual(validationErrs, expected) {
+		t.Errorf("Expected %v, got %v", expected, validationErrs)
+	}
+}
+
+func TestDecodeJSONReportsTypeMismatch(t *testing.T) {
+	var data ResumeData
+	_, err := DecodeJSON(strings.NewReader(`{"skills": "Go, Rust"}`), &data, DecodeLenient)
+	var validationErrs ValidationErrors
+	if !errors.As(err, &validationErrs) || len(validationErrs) != 1 {
+		t.Fatalf("Expected a single field error, got %v", err)
+	}
+	if validationErrs[0].Field != "skills" || validationErrs[0].Message != "must be an array, got string" {
+		t.Errorf("Unexpected field error: %+v", validationErrs[0])
+	}
+}
+
+// schemaProperties returns the property names of a JSON Schema object
+func schemaProperties(t *testing.T, schema map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	properties, ok := schema["properties"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Schema has no properties")
+	}
+	return properties
+}
+
+// assertSchemaCoversType checks that every JSON field of typ is described by the schema
+func assertSchemaCoversType(t *testing.T, name string, properties map[string]interface{}, typ reflect.Type) {
+	t.Helper()
+	for tag := range jsonFieldTypes(typ) {
+		if _, ok := properties[tag]; !ok {
+			t.Errorf("%s schema is missing field %s", name, tag)
+		}
+	}
+}
+
+func TestSchemasDescribeAllFields(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		typ  reflect.Type
+	}{
+		{"coverletter", reflect.TypeOf(CoverLetterData{})},
+		{"resume", reflect.TypeOf(ResumeData{})},
+	} {
+		content, err := Schema(tc.name)
+		if err != nil {
+			t.Fatalf("Failed to read %s schema: %v", tc.name, err)
+		}
+		var schema map[string]interface{}
+		if err := json.Unmarshal(content, &schema); err != nil {
+			t.Fatalf("%s schema is not valid JSON: %v", tc.name, err)
+		}
+		properties := schemaProperties(t, schema)
+		assertSchemaCoversType(t, tc.name, properties, tc.typ)
+		if author, ok := properties["author"].(map[string]interface{}); ok {
+			assertSchemaCoversType(t, tc.name+" author", schemaProperties(t, author), reflect.TypeOf(Profile{}))
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package render
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cvcl-render/compile"
+	"cvcl-render/model"
+)
+
+// fileNamePart replaces the characters of s that are unsafe in file names with underscores
+func fileNamePart(s string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// applicationBaseName returns the file name prefix shared by the files of an application,
+// e.g. "Acme_Jane_Doe"
+func applicationBaseName(r model.ApplicationRequest) string {
+	parts := []string{fileNamePart(r.Author.FirstName), fileNamePart(r.Author.LastName)}
+	if company := strings.TrimSpace(r.Company); company != "" {
+		parts = append([]string{fileNamePart(company)}, parts...)
+	}
+	return strings.Join(parts, "_")
+}
+
+// BundleFile is one file of an application bundle
+type BundleFile struct {
+	Name        string
+	ContentType string
+	Content     []byte
+}
+
+// ApplicationBundle holds the rendered documents of an application
+type ApplicationBundle struct {
+	BaseName string
+	Files    []BundleFile
+	// FitReport is set when the resume has options.max_pages
+	FitReport *FitReport
+}
+
+// MergeTypstVersion is the first typst version that places PDF pages as images, which merging takes
+const MergeTypstVersion = "0.14"
+
+// Application renders the cover letter and resume of an application and compiles them to PDF,
+// adding a merged PDF with the cover letter first when merge is set. With skipPDF the bundle holds
+// the rendered Typst sources instead.
+func Application(coverLetterTemplate, resumeTemplate string, req model.ApplicationRequest, merge, skipPDF bool) (*ApplicationBundle, error) {
+	if merge && !skipPDF {
+		if err := compile.RequireVersion(context.Background(), MergeTypstVersion); err != nil {
+			return nil, fmt.Errorf("merge requires typst %s: %w", MergeTypstVersion, err)
+		}
+	}
+	resume, letter := req.Documents()
+	bundle := &ApplicationBundle{BaseName: applicationBaseName(req)}
+	coverLetterName := bundle.BaseName + "_Cover_Letter"
+	resumeName := bundle.BaseName + "_Resume"
+
+	letterSource, err := CoverLetter(coverLetterTemplate, letter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render cover letter: %w", err)
+	}
+	if skipPDF {
+		resumeSource, err := Resume(resumeTemplate, resume)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render resume: %w", err)
+		}
+		bundle.Files = []BundleFile{
+			{Name: coverLetterName + ".typ", ContentType: compile.TypstContentType, Content: []byte(letterSource)},
+			{Name: resumeName + ".typ", ContentType: compile.TypstContentType, Content: []byte(resumeSource)},
+		}
+		return bundle, nil
+	}
+
+	letterPDF, err := compile.Source(letterSource, compile.Options{Format: compile.FormatPDF})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile cover letter: %w", err)
+	}
+	_, resumePDF, report, err := fitResume(resumeTemplate, resume, compile.Options{Format: compile.FormatPDF}, compile.Source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile resume: %w", err)
+	}
+	bundle.FitReport = report
+	bundle.Files = []BundleFile{
+		{Name: coverLetterName + ".pdf", ContentType: "application/pdf", Content: letterPDF.Pages[0]},
+		{Name: resumeName + ".pdf", ContentType: "application/pdf", Content: resumePDF.Pages[0]},
+	}
+
+	if merge {
+		merged, err := mergePDFs([][]byte{letterPDF.Pages[0], resumePDF.Pages[0]})
+		if err != nil {
+			return nil, err
+		}
+		bundle.Files = append(bundle.Files, BundleFile{Name: bundle.BaseName + "_Application.pdf", ContentType: "application/pdf", Content: merged})
+	}
+	return bundle, nil
+}
+
+// mergePDFs concatenates PDF documents by placing each of their pages on a page of its own size
+// in a Typst document. Embedding PDF pages as images needs Typst 0.14 or later.
+func mergePDFs(documents [][]byte) ([]byte, error) {
+	workDir, err := os.MkdirTemp("", "cvcl-merge-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create working directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	var source strings.Builder
+	source.WriteString("#set page(width: auto, height: auto, margin: 0)\n")
+	first := true
+	for i, document := range documents {
+		name := fmt.Sprintf("document-%d.pdf", i+1)
+		if err := os.WriteFile(filepath.Join(workDir, name), document, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write PDF file: %w", err)
+		}
+		pageCount, err := compile.CountPDFPages(document)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count pages of document %d: %w", i+1, err)
+		}
+		for page := 1; page <= pageCount; page++ {
+			if !first {
+				source.WriteString("#pagebreak()\n")
+			}
+			first = false
+			fmt.Fprintf(&source, "#image(%q, page: %d)\n", name, page)
+		}
+	}
+
+	typstFilePath := filepath.Join(workDir, "merged.typ")
+	if err := os.WriteFile(typstFilePath, []byte(source.String()), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write Typst file: %w", err)
+	}
+	files, err := compile.File(typstFilePath, workDir, "merged", compile.Options{Format: compile.FormatPDF})
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge PDF files: %w", err)
+	}
+	merged, err := os.ReadFile(files[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read merged PDF: %w", err)
+	}
+	return merged, nil
+}
+
+// WriteZip writes the bundle files as a zip archive
+func (b *ApplicationBundle) WriteZip(w io.Writer) error {
+	zw := zip.NewWriter(w)
+	for _, file := range b.Files {
+		f, err := zw.Create(file.Name)
+		if err != nil {
+			return fmt.Errorf("failed to add %s: %w", file.Name, err)
+		}
+		if _, err := f.Write(file.Content); err != nil {
+			return fmt.Errorf("failed to add %s: %w", file.Name, err)
+		}
+	}
+	return zw.Close()
+}
+
+// WriteMultipart writes the bundle files as the parts of a multipart/mixed body and
+// returns the content type with the boundary
+func (b *ApplicationBundle) WriteMultipart(w io.Writer) (string, error) {
+	mw := multipart.NewWriter(w)
+	for _, file := range b.Files {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", file.ContentType)
+		header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.Name))
+		part, err := mw.CreatePart(header)
+		if err != nil {
+			return "", fmt.Errorf("failed to add %s: %w", file.Name, err)
+		}
+		if _, err := part.Write(file.Content); err != nil {
+			return "", fmt.Errorf("failed to add %s: %w", file.Name, err)
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return "", err
+	}
+	return "multipart/mixed; boundary=" + mw.Boundary(), nil
+}
@@ -0,0 +1,136 @@
+// Package render fills the Typst templates with resume and cover letter documents, compiles them
+// to PDF or page images and exports Word documents and application bundles.
+package render
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"cvcl-render/compile"
+	"cvcl-render/i18n"
+	"cvcl-render/model"
+)
+
+//go:embed templates/coverletter.typ.template
+var embeddedTemplates embed.FS
+
+// CoverLetterTemplate reads a cover letter template from the filesystem, falling back to the
+// embedded template when a file named coverletter.typ.template does not exist
+func CoverLetterTemplate(templatePath string) (string, error) {
+	templateContent, err := os.ReadFile(templatePath)
+	if err == nil {
+		return string(templateContent), nil
+	}
+
+	// Fall back to the embedded template
+	if errors.Is(err, fs.ErrNotExist) && strings.HasSuffix(templatePath, "coverletter.typ.template") {
+		data, embedErr := embeddedTemplates.ReadFile("templates/coverletter.typ.template")
+		if embedErr == nil {
+			return string(data), nil
+		}
+	}
+	return "", fmt.Errorf("failed to read template file: %w", err)
+}
+
+// coverLetterTemplateData is passed to the cover letter template: the letter fields plus the localized messages
+type coverLetterTemplateData struct {
+	model.CoverLetterData
+	Messages i18n.Messages
+}
+
+// CoverLetter renders the cover letter template with the provided data
+func CoverLetter(templateContent string, data model.CoverLetterData) (string, error) {
+	// Parse template
+	tmpl, err := template.New("coverletter").Funcs(templateFuncs).Parse(templateContent)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	// Execute template
+	var result strings.Builder
+	err = tmpl.Execute(&result, coverLetterTemplateData{CoverLetterData: data.PrepareForRender(), Messages: i18n.Get(data.Language())})
+	if err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	return result.String(), nil
+}
+
+// CoverLetterFromJSON renders the template using JSON data
+func CoverLetterFromJSON(templateContent string, jsonData string) (string, error) {
+	var data model.CoverLetterData
+	err := json.Unmarshal([]byte(jsonData), &data)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse JSON data: %w", err)
+	}
+
+	return CoverLetter(templateContent, data)
+}
+
+// CoverLetterFromJSONFile renders the template using a JSON file
+func CoverLetterFromJSONFile(templateContent string, jsonFilePath string) (string, error) {
+	jsonContent, err := os.ReadFile(jsonFilePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read JSON file: %w", err)
+	}
+
+	return CoverLetterFromJSON(templateContent, string(jsonContent))
+}
+
+// CoverLetterBaseName returns the output file name (without extension) for a cover letter
+func CoverLetterBaseName(data model.CoverLetterData) string {
+	return fmt.Sprintf("Cover_Letter_%s_%s", fileNamePart(data.FirstName), fileNamePart(data.Position))
+}
+
+// CompileCoverLetter renders the template and optionally compiles to PDF
+func CompileCoverLetter(templateContent string, data model.CoverLetterData, outputDir string, skipPDF bool) (typstFile string, pdfFile string, err error) {
+	// Render template
+	result, err := CoverLetter(templateContent, data)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Generate output filenames
+	baseName := CoverLetterBaseName(data)
+	typstFileName := baseName + ".typ"
+	pdfFileName := baseName + ".pdf"
+
+	typstFilePath := filepath.Join(outputDir, typstFileName)
+	pdfFilePath := filepath.Join(outputDir, pdfFileName)
+
+	// Write the rendered Typst file
+	err = os.WriteFile(typstFilePath, []byte(result), 0644)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to write Typst file: %w", err)
+	}
+
+	// Compile to PDF if not skipped
+	if !skipPDF {
+		compiled, err := compile.Source(result, compile.Options{Format: compile.FormatPDF})
+		if err != nil {
+			return "", "", err
+		}
+		err = os.WriteFile(pdfFilePath, compiled.Pages[0], 0644)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to write PDF file: %w", err)
+		}
+	}
+
+	return typstFilePath, pdfFilePath, nil
+}
+
+// PreviewCoverLetter renders the template and compiles it to page images
+func PreviewCoverLetter(templateContent string, data model.CoverLetterData, opts compile.Options) (*compile.Result, error) {
+	result, err := CoverLetter(templateContent, data)
+	if err != nil {
+		return nil, err
+	}
+	return compile.Source(result, opts)
+}
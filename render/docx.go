@@ -0,0 +1,439 @@
+package render
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cvcl-render/i18n"
+	"cvcl-render/model"
+	"cvcl-render/typstparse"
+)
+
+// DOCXContentType is the MIME type of a Word document
+const DOCXContentType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+
+const (
+	docxNamespaceW       = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+	docxNamespaceR       = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	docxRelTypeHyperlink = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink"
+)
+
+// docxRun is a piece of inline text with its formatting
+type docxRun = typstparse.Run
+
+// docxBuilder accumulates the body of a Word document and its hyperlink relationships
+type docxBuilder struct {
+	body       strings.Builder
+	hyperlinks []string
+}
+
+// hyperlinkID registers an external hyperlink and returns its relationship id
+func (b *docxBuilder) hyperlinkID(url string) string {
+	for i, existing := range b.hyperlinks {
+		if existing == url {
+			return fmt.Sprintf("rId%d", i+3)
+		}
+	}
+	b.hyperlinks = append(b.hyperlinks, url)
+	// rId1 and rId2 are reserved for styles and numbering
+	return fmt.Sprintf("rId%d", len(b.hyperlinks)+2)
+}
+
+// writeRuns writes the given runs into the current paragraph
+func (b *docxBuilder) writeRuns(runs []docxRun) {
+	for _, run := range runs {
+		if run.Text == "" {
+			continue
+		}
+		if run.URL != "" {
+			fmt.Fprintf(&b.body, `<w:hyperlink r:id="%s">`, b.hyperlinkID(run.URL))
+		}
+		b.body.WriteString("<w:r>")
+		if run.Bold || run.Italic || run.URL != "" {
+			b.body.WriteString("<w:rPr>")
+			if run.URL != "" {
+				b.body.WriteString(`<w:rStyle w:val="Hyperlink"/>`)
+			}
+			if run.Bold {
+				b.body.WriteString("<w:b/>")
+			}
+			if run.Italic {
+				b.body.WriteString("<w:i/>")
+			}
+			b.body.WriteString("</w:rPr>")
+		}
+		b.body.WriteString(`<w:t xml:space="preserve">`)
+		xml.EscapeText(&b.body, []byte(run.Text))
+		b.body.WriteString("</w:t></w:r>")
+		if run.URL != "" {
+			b.body.WriteString("</w:hyperlink>")
+		}
+	}
+}
+
+// paragraph writes a paragraph with an optional style
+func (b *docxBuilder) paragraph(style string, runs []docxRun) {
+	b.body.WriteString("<w:p>")
+	if style != "" {
+		fmt.Fprintf(&b.body, `<w:pPr><w:pStyle w:val="%s"/></w:pPr>`, style)
+	}
+	b.writeRuns(runs)
+	b.body.WriteString("</w:p>")
+}
+
+// bullet writes a bulleted list item
+func (b *docxBuilder) bullet(runs []docxRun) {
+	b.body.WriteString(`<w:p><w:pPr><w:pStyle w:val="ListBullet"/><w:numPr><w:ilvl w:val="0"/><w:numId w:val="1"/></w:numPr></w:pPr>`)
+	b.writeRuns(runs)
+	b.body.WriteString("</w:p>")
+}
+
+// heading writes a heading paragraph of the given level
+func (b *docxBuilder) heading(level int, text string) {
+	b.paragraph(fmt.Sprintf("Heading%d", level), typstparse.Inline(text))
+}
+
+// markup writes a block of Typst markup, turning "- " lines into bullets
+func (b *docxBuilder) markup(content string) {
+	for _, block := range splitTypstBlocks(content) {
+		if block.bullet {
+			b.bullet(typstparse.Inline(block.text))
+		} else {
+			b.paragraph("", typstparse.Inline(block.text))
+		}
+	}
+}
+
+// contactHeader writes the name title and a line of contact details
+func (b *docxBuilder) contactHeader(name string, contacts []docxRun) {
+	b.paragraph("Title", []docxRun{{Text: name}})
+	var runs []docxRun
+	for _, contact := range contacts {
+		if contact.Text == "" {
+			continue
+		}
+		if len(runs) > 0 {
+			runs = append(runs, docxRun{Text: " | "})
+		}
+		runs = append(runs, contact)
+	}
+	if len(runs) > 0 {
+		b.paragraph("Contact", runs)
+	}
+}
+
+// bytes packages the accumulated body into a DOCX (OOXML zip) file
+func (b *docxBuilder) bytes() ([]byte, error) {
+	var document strings.Builder
+	document.WriteString(xml.Header)
+	fmt.Fprintf(&document, `<w:document xmlns:w="%s" xmlns:r="%s"><w:body>`, docxNamespaceW, docxNamespaceR)
+	document.WriteString(b.body.String())
+	document.WriteString(`<w:sectPr><w:pgSz w:w="12240" w:h="15840"/><w:pgMar w:top="1080" w:right="1080" w:bottom="1080" w:left="1080" w:header="720" w:footer="720" w:gutter="0"/></w:sectPr>`)
+	document.WriteString("</w:body></w:document>")
+
+	var rels strings.Builder
+	rels.WriteString(xml.Header)
+	rels.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	rels.WriteString(`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`)
+	rels.WriteString(`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/numbering" Target="numbering.xml"/>`)
+	for i, url := range b.hyperlinks {
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="%s" Target="`, i+3, docxRelTypeHyperlink)
+		xml.EscapeText(&rels, []byte(url))
+		rels.WriteString(`" TargetMode="External"/>`)
+	}
+	rels.WriteString("</Relationships>")
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", docxContentTypes},
+		{"_rels/.rels", docxPackageRels},
+		{"word/document.xml", document.String()},
+		{"word/_rels/document.xml.rels", rels.String()},
+		{"word/styles.xml", docxStyles},
+		{"word/numbering.xml", docxNumbering},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, part := range parts {
+		w, err := zw.Create(part.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", part.name, err)
+		}
+		if _, err := w.Write([]byte(part.content)); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", part.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finalize DOCX: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// typstBlock is a paragraph or bullet item in Typst markup
+type typstBlock struct {
+	text   string
+	bullet bool
+}
+
+// splitTypstBlocks splits Typst markup into paragraphs and bullet items.
+// Lines following a bullet are treated as its continuation until a blank line.
+func splitTypstBlocks(content string) []typstBlock {
+	var blocks []typstBlock
+	current := -1
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line), `\`))
+		if line == "" {
+			current = -1
+			continue
+		}
+		if strings.HasPrefix(line, "- ") || line == "-" {
+			blocks = append(blocks, typstBlock{text: strings.TrimSpace(strings.TrimPrefix(line, "-")), bullet: true})
+			current = len(blocks) - 1
+			continue
+		}
+		if current != -1 && blocks[current].bullet {
+			blocks[current].text += " " + line
+			continue
+		}
+		blocks = append(blocks, typstBlock{text: line})
+		current = len(blocks) - 1
+	}
+	return blocks
+}
+
+// contactRun builds a hyperlinked contact entry, or an empty run if value is empty
+func contactRun(value, urlPrefix string) docxRun {
+	if value == "" {
+		return docxRun{}
+	}
+	return docxRun{Text: value, URL: urlPrefix + value}
+}
+
+// profileRuns returns the contact details of a profile for the contact line
+func profileRuns(p model.Profile) []docxRun {
+	runs := []docxRun{
+		contactRun(p.Email, "mailto:"),
+		{Text: p.Phone},
+		{Text: p.Address},
+		contactRun(p.Homepage, ""),
+		contactRun(p.GitHub, "https://github.com/"),
+		contactRun(p.LinkedIn, "https://www.linkedin.com/in/"),
+		contactRun(p.Twitter, "https://twitter.com/"),
+	}
+	for _, entry := range p.Entries() {
+		runs = append(runs, docxRun{Text: entry.Text, URL: entry.Link})
+	}
+	return runs
+}
+
+// ResumeDOCX renders resume data as a Word document
+func ResumeDOCX(data model.ResumeData) ([]byte, error) {
+	b := &docxBuilder{}
+	messages := i18n.Get(data.Language())
+	data, err := data.PrepareForRender()
+	if err != nil {
+		return nil, err
+	}
+	b.contactHeader(data.Author.Name(), profileRuns(data.Author))
+	if len(data.Positions) > 0 {
+		b.paragraph("Subtitle", []docxRun{{Text: strings.Join(data.Positions, " · ")}})
+	}
+
+	if data.Summary != "" {
+		b.heading(1, messages.Summary)
+		b.markup(data.Summary)
+	}
+
+	// Like the Typst template, only project locations such as "user/repo" link to GitHub
+	sections := []struct {
+		name        string
+		entries     []model.ResumeEntry
+		githubLinks bool
+	}{
+		{messages.Education, data.Education, false},
+		{messages.WorkExperience, data.WorkExperience, false},
+		{messages.Projects, data.Projects, true},
+	}
+	for _, section := range sections {
+		if len(section.entries) == 0 {
+			continue
+		}
+		b.heading(1, section.name)
+		for _, entry := range section.entries {
+			writeResumeEntryDOCX(b, entry, section.githubLinks)
+		}
+	}
+
+	if len(data.Skills) > 0 {
+		b.heading(1, messages.Skills)
+		for _, category := range data.Skills {
+			runs := []docxRun{{Text: category.Name + ": ", Bold: true}}
+			for i, skill := range category.Skills {
+				if i > 0 {
+					runs = append(runs, docxRun{Text: ", "})
+				}
+				runs = append(runs, docxRun{Text: skill.Name, Bold: skill.Strong})
+			}
+			b.paragraph("", runs)
+		}
+	}
+
+	if len(data.Interests) > 0 {
+		b.heading(1, messages.Interests)
+		for _, interest := range data.Interests {
+			runs := []docxRun{{Text: interest.Category + ": ", Bold: true}}
+			runs = append(runs, typstparse.Inline(interest.Description)...)
+			b.paragraph("", runs)
+		}
+	}
+
+	return b.bytes()
+}
+
+// writeResumeEntryDOCX writes a single resume entry with its heading line and content.
+// With githubLinks, a location containing "/" links to the GitHub repository of that name.
+func writeResumeEntryDOCX(b *docxBuilder, entry model.ResumeEntry, githubLinks bool) {
+	b.heading(2, entry.Title)
+
+	var details []docxRun
+	if entry.Description != "" {
+		details = append(details, typstparse.Inline(entry.Description)...)
+	}
+	for _, extra := range []string{entry.Location, entry.Date} {
+		if extra == "" {
+			continue
+		}
+		if len(details) > 0 {
+			details = append(details, docxRun{Text: " | "})
+		}
+		if githubLinks && extra == entry.Location && strings.Contains(extra, "/") {
+			details = append(details, docxRun{Text: extra, URL: "https://github.com/" + extra})
+		} else {
+			details = append(details, docxRun{Text: extra})
+		}
+	}
+	for i := range details {
+		details[i].Italic = true
+	}
+	if len(details) > 0 {
+		b.paragraph("EntryDetails", details)
+	}
+
+	if entry.Content != "" {
+		b.markup(entry.Content)
+	}
+}
+
+// CoverLetterDOCX renders cover letter data as a Word document
+func CoverLetterDOCX(data model.CoverLetterData) ([]byte, error) {
+	b := &docxBuilder{}
+	messages := i18n.Get(data.Language())
+	data = data.PrepareForRender()
+
+	b.contactHeader(data.Name(), profileRuns(data.Profile))
+
+	if data.Date != "" {
+		b.paragraph("Date", typstparse.Inline(data.Date))
+	}
+	for _, line := range data.RecipientAddressLines() {
+		b.paragraph("Address", typstparse.Inline(line))
+	}
+	if data.Addressee != "" {
+		b.paragraph("", []docxRun{{Text: data.Addressee}})
+	}
+	if data.Position != "" {
+		b.heading(1, messages.JobApplicationFor(data.Position))
+	}
+	if data.Salutation != "" {
+		b.paragraph("", typstparse.Inline(data.Salutation))
+	}
+
+	for _, paragraph := range data.ContentParagraphs() {
+		if paragraph.Heading != "" {
+			b.heading(2, paragraph.Heading)
+		}
+		b.markup(paragraph.Text)
+	}
+
+	if data.Closing != "" {
+		b.paragraph("Closing", typstparse.Inline(data.Closing))
+	}
+	if data.Signature != "" {
+		b.paragraph("Signature", typstparse.Inline(data.Signature))
+	}
+
+	return b.bytes()
+}
+
+// WriteCoverLetterDOCX renders the cover letter as DOCX into outputDir and returns the file path
+func WriteCoverLetterDOCX(data model.CoverLetterData, outputDir string) (string, error) {
+	content, err := CoverLetterDOCX(data)
+	if err != nil {
+		return "", err
+	}
+	docxFilePath := filepath.Join(outputDir, CoverLetterBaseName(data)+".docx")
+	err = os.WriteFile(docxFilePath, content, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to write DOCX file: %w", err)
+	}
+	return docxFilePath, nil
+}
+
+// WriteResumeDOCX renders the resume as DOCX into outputDir and returns the file path
+func WriteResumeDOCX(data model.ResumeData, outputDir string) (string, error) {
+	content, err := ResumeDOCX(data)
+	if err != nil {
+		return "", err
+	}
+	docxFilePath := filepath.Join(outputDir, ResumeBaseName(data)+".docx")
+	err = os.WriteFile(docxFilePath, content, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to write DOCX file: %w", err)
+	}
+	return docxFilePath, nil
+}
+
+const docxContentTypes = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>` +
+	`<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>` +
+	`<Override PartName="/word/numbering.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.numbering+xml"/>` +
+	`</Types>`
+
+const docxPackageRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>` +
+	`</Relationships>`
+
+const docxStyles = xml.Header + `<w:styles xmlns:w="` + docxNamespaceW + `">` +
+	`<w:docDefaults><w:rPrDefault><w:rPr><w:rFonts w:ascii="Source Sans Pro" w:hAnsi="Source Sans Pro" w:cs="Source Sans Pro"/><w:sz w:val="21"/></w:rPr></w:rPrDefault>` +
+	`<w:pPrDefault><w:pPr><w:spacing w:after="80"/></w:pPr></w:pPrDefault></w:docDefaults>` +
+	`<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="Title"><w:name w:val="Title"/><w:basedOn w:val="Normal"/><w:pPr><w:jc w:val="center"/></w:pPr><w:rPr><w:rFonts w:ascii="Roboto" w:hAnsi="Roboto"/><w:b/><w:sz w:val="48"/></w:rPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="Subtitle"><w:name w:val="Subtitle"/><w:basedOn w:val="Normal"/><w:pPr><w:jc w:val="center"/></w:pPr><w:rPr><w:color w:val="DC3522"/><w:sz w:val="20"/></w:rPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="Contact"><w:name w:val="Contact"/><w:basedOn w:val="Normal"/><w:pPr><w:jc w:val="center"/></w:pPr><w:rPr><w:sz w:val="18"/></w:rPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="240" w:after="80"/><w:pBdr><w:bottom w:val="single" w:sz="4" w:space="1" w:color="DC3522"/></w:pBdr><w:outlineLvl w:val="0"/></w:pPr><w:rPr><w:rFonts w:ascii="Roboto" w:hAnsi="Roboto"/><w:b/><w:color w:val="DC3522"/><w:sz w:val="28"/></w:rPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="Heading2"><w:name w:val="heading 2"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="120" w:after="0"/><w:outlineLvl w:val="1"/></w:pPr><w:rPr><w:b/><w:sz w:val="22"/></w:rPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="EntryDetails"><w:name w:val="Entry Details"/><w:basedOn w:val="Normal"/><w:rPr><w:color w:val="5D5D5D"/></w:rPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="Date"><w:name w:val="Date"/><w:basedOn w:val="Normal"/><w:pPr><w:jc w:val="right"/></w:pPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="Address"><w:name w:val="Address"/><w:basedOn w:val="Normal"/><w:pPr><w:spacing w:after="0"/></w:pPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="Closing"><w:name w:val="Closing"/><w:basedOn w:val="Normal"/><w:pPr><w:spacing w:before="240"/></w:pPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="Signature"><w:name w:val="Signature"/><w:basedOn w:val="Normal"/><w:pPr><w:spacing w:before="480"/></w:pPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="ListBullet"><w:name w:val="List Bullet"/><w:basedOn w:val="Normal"/><w:pPr><w:spacing w:after="20"/></w:pPr></w:style>` +
+	`<w:style w:type="character" w:styleId="Hyperlink"><w:name w:val="Hyperlink"/><w:rPr><w:color w:val="0066CC"/><w:u w:val="single"/></w:rPr></w:style>` +
+	`</w:styles>`
+
+const docxNumbering = xml.Header + `<w:numbering xmlns:w="` + docxNamespaceW + `">` +
+	`<w:abstractNum w:abstractNumId="0"><w:multiLevelType w:val="singleLevel"/>` +
+	`<w:lvl w:ilvl="0"><w:start w:val="1"/><w:numFmt w:val="bullet"/><w:lvlText w:val="•"/><w:lvlJc w:val="left"/><w:pPr><w:ind w:left="720" w:hanging="360"/></w:pPr></w:lvl>` +
+	`</w:abstractNum>` +
+	`<w:num w:numId="1"><w:abstractNumId w:val="0"/></w:num>` +
+	`</w:numbering>`
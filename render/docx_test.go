@@ -0,0 +1,178 @@
+package render
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"cvcl-render/examples"
+	"cvcl-render/model"
+)
+
+// readDOCXParts unzips a DOCX file and returns its parts by name
+func readDOCXParts(t *testing.T, content []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatalf("Failed to open DOCX as zip: %v", err)
+	}
+	parts := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", f.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", f.Name, err)
+		}
+		parts[f.Name] = string(data)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "word/document.xml", "word/_rels/document.xml.rels", "word/styles.xml", "word/numbering.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("Missing DOCX part %s", name)
+		}
+		// Every part must be well-formed XML
+		decoder := xml.NewDecoder(strings.NewReader(parts[name]))
+		for {
+			_, err := decoder.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("Part %s is not well-formed XML: %v", name, err)
+			}
+		}
+	}
+	return parts
+}
+
+// documentText extracts the concatenated text runs from document.xml
+func documentText(t *testing.T, document string) string {
+	t.Helper()
+	var text strings.Builder
+	decoder := xml.NewDecoder(strings.NewReader(document))
+	inText := false
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to decode document.xml: %v", err)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			inText = tok.Name.Local == "t"
+		case xml.EndElement:
+			if tok.Name.Local == "p" {
+				text.WriteString("\n")
+			}
+			inText = false
+		case xml.CharData:
+			if inText {
+				text.Write(tok)
+			}
+		}
+	}
+	return text.String()
+}
+
+func TestResumeDOCX(t *testing.T) {
+	var data model.ResumeData
+	if err := json.Unmarshal(examples.Resume, &data); err != nil {
+		t.Fatalf("Failed to parse example resume: %v", err)
+	}
+
+	docx, err := ResumeDOCX(data)
+	if err != nil {
+		t.Fatalf("Failed to render DOCX: %v", err)
+	}
+	parts := readDOCXParts(t, docx)
+	document := parts["word/document.xml"]
+	text := documentText(t, document)
+
+	for _, heading := range []string{"Summary", "Education", "Working Experience", "Projects", "Skills", "Interests"} {
+		if !strings.Contains(document, `<w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t xml:space="preserve">`+heading+"<") {
+			t.Errorf("Expected heading %q in document", heading)
+		}
+	}
+
+	if !strings.Contains(text, data.Author.FirstName+" "+data.Author.LastName) {
+		t.Errorf("Expected author name in contact header")
+	}
+	if !strings.Contains(text, data.Author.Email) {
+		t.Errorf("Expected email in contact header")
+	}
+
+	if strings.Count(document, `<w:numId w:val="1"/>`) == 0 {
+		t.Error("Expected bullet list items")
+	}
+
+	// Typst link markup must be converted into hyperlinks, not left as text
+	if strings.Contains(text, "#link") {
+		t.Error("Typst link markup leaked into document text")
+	}
+	if !strings.Contains(text, "Chalmers University of Technology") {
+		t.Error("Expected link label to be kept as text")
+	}
+	rels := parts["word/_rels/document.xml.rels"]
+	if !strings.Contains(rels, `Target="https://www.chalmers.se/" TargetMode="External"`) {
+		t.Error("Expected external hyperlink relationship for link target")
+	}
+	if strings.Count(document, "<w:hyperlink ") == 0 {
+		t.Error("Expected hyperlinks in document")
+	}
+}
+
+func TestResumeDOCXLinksOnlyProjectsToGitHub(t *testing.T) {
+	data := model.ResumeData{
+		Author:         model.Profile{FirstName: "Jane", LastName: "Doe"},
+		WorkExperience: []model.ResumeEntry{{Title: "Engineer", Location: "Gothenburg/Remote"}},
+		Projects:       []model.ResumeEntry{{Title: "Tool", Location: "jane/tool"}},
+	}
+	docx, err := ResumeDOCX(data)
+	if err != nil {
+		t.Fatalf("Failed to render DOCX: %v", err)
+	}
+	rels := readDOCXParts(t, docx)["word/_rels/document.xml.rels"]
+	if !strings.Contains(rels, `Target="https://github.com/jane/tool"`) {
+		t.Error("Expected a GitHub link for the project location")
+	}
+	if strings.Contains(rels, "Gothenburg") {
+		t.Error("Work locations must not link to GitHub")
+	}
+}
+
+func TestCoverLetterDOCX(t *testing.T) {
+	var data model.CoverLetterData
+	if err := json.Unmarshal(examples.CoverLetter, &data); err != nil {
+		t.Fatalf("Failed to parse example cover letter: %v", err)
+	}
+	data.WhyCompany = ""
+
+	docx, err := CoverLetterDOCX(data)
+	if err != nil {
+		t.Fatalf("Failed to render DOCX: %v", err)
+	}
+	parts := readDOCXParts(t, docx)
+	text := documentText(t, parts["word/document.xml"])
+
+	for _, expected := range []string{"John Doe", data.Email, data.Addressee, data.Position, data.Opening, data.AboutMe, data.WhyMe} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("Expected %q in document text", expected)
+		}
+	}
+	if !strings.Contains(parts["word/_rels/document.xml.rels"], `Target="mailto:john.doe@example.com"`) {
+		t.Error("Expected mailto hyperlink for email")
+	}
+	// Empty paragraphs are skipped
+	if strings.Count(text, "\n\n") > 0 {
+		t.Error("Expected no empty paragraphs")
+	}
+}
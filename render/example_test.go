@@ -0,0 +1,28 @@
+package render_test
+
+import (
+	"fmt"
+	"strings"
+
+	"cvcl-render/model"
+	"cvcl-render/render"
+)
+
+func ExampleCoverLetter() {
+	templateContent, err := render.CoverLetterTemplate("templates/coverletter.typ.template")
+	if err != nil {
+		panic(err)
+	}
+	data := model.CoverLetterData{
+		Profile:   model.Profile{FirstName: "Jane", LastName: "Doe", Email: "jane@example.com"},
+		Position:  "Software Engineer",
+		Addressee: "Hiring Manager",
+		Opening:   "I am writing to apply for the Software Engineer role.",
+	}
+	typst, err := render.CoverLetter(templateContent, data)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(strings.Contains(typst, "Jane"), render.CoverLetterBaseName(data))
+	// Output: true Cover_Letter_Jane_Software_Engineer
+}
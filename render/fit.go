@@ -0,0 +1,215 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"cvcl-render/compile"
+	"cvcl-render/model"
+)
+
+// maxTightenLevel is the highest spacing level supported by the resume template
+const maxTightenLevel = 2
+
+// maxFitSteps bounds the number of recompilations when fitting a resume to max_pages
+const maxFitSteps = 40
+
+// resumeLayout holds presentation settings adjusted while fitting a resume to a page budget
+type resumeLayout struct {
+	// Tighten is 0 for the normal layout; each level reduces spacing and font size
+	Tighten int
+}
+
+// FitCut describes one change made to fit a resume into its page budget
+type FitCut struct {
+	// Kind is "layout", "bullet", "interest", "skill" or "entry"
+	Kind    string `json:"kind"`
+	Section string `json:"section,omitempty"`
+	Title   string `json:"title,omitempty"`
+	Text    string `json:"text,omitempty"`
+}
+
+// FitReport lists what was changed to fit a resume into options.max_pages
+type FitReport struct {
+	MaxPages  int      `json:"max_pages"`
+	PageCount int      `json:"page_count"`
+	Fits      bool     `json:"fits"`
+	Cuts      []FitCut `json:"cuts"`
+}
+
+// compileFunc compiles Typst source; it is compile.Source outside of tests
+type compileFunc func(source string, opts compile.Options) (*compile.Result, error)
+
+// fitResume renders and compiles a resume, and while it has more pages than options.max_pages,
+// first tightens the layout and then drops the lowest-priority content one item at a time.
+// The report is nil when no page budget is set.
+func fitResume(templateContent string, data model.ResumeData, opts compile.Options, compileSource compileFunc) (string, *compile.Result, *FitReport, error) {
+	maxPages := 0
+	if data.Options != nil {
+		maxPages = data.Options.MaxPages
+	}
+
+	// Prepare once: the trimmed copy is rendered again at every step
+	work, err := data.PrepareForRender()
+	if err != nil {
+		return "", nil, nil, err
+	}
+	layout := resumeLayout{}
+	source, err := renderResume(templateContent, work, layout)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	result, err := compileSource(source, opts)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if maxPages <= 0 {
+		return source, result, nil, nil
+	}
+
+	report := &FitReport{MaxPages: maxPages, Cuts: []FitCut{}}
+	for step := 0; step < maxFitSteps; step++ {
+		// An unknown page count would always look like a fit
+		if result.PageCount <= 0 {
+			return "", nil, nil, fmt.Errorf("failed to fit resume to %d pages: page count unknown", maxPages)
+		}
+		if result.PageCount <= maxPages {
+			break
+		}
+		if layout.Tighten < maxTightenLevel {
+			layout.Tighten++
+			report.Cuts = append(report.Cuts, FitCut{Kind: "layout", Text: fmt.Sprintf("tightened spacing to level %d", layout.Tighten)})
+		} else {
+			cut, ok := trimResume(&work)
+			if !ok {
+				break
+			}
+			report.Cuts = append(report.Cuts, cut)
+		}
+
+		source, err = renderResume(templateContent, work, layout)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		result, err = compileSource(source, opts)
+		if err != nil {
+			return "", nil, nil, err
+		}
+	}
+
+	report.PageCount = result.PageCount
+	report.Fits = result.PageCount > 0 && result.PageCount <= maxPages
+	return source, result, report, nil
+}
+
+// resumeListLines splits entry content into its text and list lines ("- ..."), which can be dropped one by one
+func resumeListLines(content string) (lines []string, listIndexes []int) {
+	lines = strings.Split(content, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "- ") {
+			listIndexes = append(listIndexes, i)
+		}
+	}
+	return lines, listIndexes
+}
+
+// trimResume removes the least important remaining item of a prepared resume.
+// Among the entries with the lowest priority, the entry with the most list lines loses its
+// last one (each entry keeps at least one; ties go to projects first); interests go next,
+// then skills down to one per category; then the last of those entries is dropped, starting
+// with projects, then work experience, then education. Skill categories go once no entries are left.
+func trimResume(d *model.ResumeData) (FitCut, bool) {
+	sections := []struct {
+		name    string
+		entries *[]model.ResumeEntry
+	}{
+		{"projects", &d.Projects},
+		{"work_experience", &d.WorkExperience},
+		{"education", &d.Education},
+	}
+
+	lowest, found := 0, false
+	for _, section := range sections {
+		for _, entry := range *section.entries {
+			if !found || entry.Priority < lowest {
+				lowest, found = entry.Priority, true
+			}
+		}
+	}
+
+	if found {
+		bestSection, bestIndex, bestCount := -1, -1, 1
+		for s, section := range sections {
+			for i, entry := range *section.entries {
+				if entry.Priority != lowest {
+					continue
+				}
+				if _, list := resumeListLines(entry.Content); len(list) > bestCount {
+					bestSection, bestIndex, bestCount = s, i, len(list)
+				}
+			}
+		}
+		if bestSection != -1 {
+			section := sections[bestSection]
+			entry := &(*section.entries)[bestIndex]
+			lines, list := resumeListLines(entry.Content)
+			last := list[len(list)-1]
+			removed := strings.TrimPrefix(strings.TrimSpace(lines[last]), "- ")
+			entry.Content = strings.Join(append(lines[:last], lines[last+1:]...), "\n")
+			return FitCut{Kind: "bullet", Section: section.name, Title: entry.Title, Text: removed}, true
+		}
+	}
+
+	if len(d.Interests) > 0 && (!found || lowest >= 0) {
+		last := d.Interests[len(d.Interests)-1]
+		d.Interests = d.Interests[:len(d.Interests)-1]
+		return FitCut{Kind: "interest", Section: "interests", Title: last.Category}, true
+	}
+	if !found || lowest >= 0 {
+		if cut, ok := trimSkill(d); ok {
+			return cut, true
+		}
+	}
+
+	if found {
+		for _, section := range sections {
+			entries := *section.entries
+			for i := len(entries) - 1; i >= 0; i-- {
+				if entries[i].Priority == lowest {
+					*section.entries = append(entries[:i:i], entries[i+1:]...)
+					return FitCut{Kind: "entry", Section: section.name, Title: entries[i].Title}, true
+				}
+			}
+		}
+	}
+	if len(d.Skills) > 0 {
+		last := d.Skills[len(d.Skills)-1]
+		d.Skills = d.Skills[:len(d.Skills)-1]
+		return FitCut{Kind: "skill", Section: "skills", Title: last.Name}, true
+	}
+	return FitCut{}, false
+}
+
+// trimSkill removes the lowest-priority skill, the last among equals, of the category with the
+// most skills; every category keeps at least one
+func trimSkill(d *model.ResumeData) (FitCut, bool) {
+	best := -1
+	for i, category := range d.Skills {
+		if len(category.Skills) > 1 && (best == -1 || len(category.Skills) > len(d.Skills[best].Skills)) {
+			best = i
+		}
+	}
+	if best == -1 {
+		return FitCut{}, false
+	}
+	category := &d.Skills[best]
+	lowest := len(category.Skills) - 1
+	for i := lowest - 1; i >= 0; i-- {
+		if category.Skills[i].Priority < category.Skills[lowest].Priority {
+			lowest = i
+		}
+	}
+	removed := category.Skills[lowest]
+	category.Skills = append(category.Skills[:lowest:lowest], category.Skills[lowest+1:]...)
+	return FitCut{Kind: "skill", Section: "skills", Title: category.Name, Text: removed.Name}, true
+}
@@ -0,0 +1,140 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"cvcl-render/compile"
+	"cvcl-render/model"
+)
+
+// fakeCompile lays out ten lines per page, twelve once the spacing is tightened,
+// where every entry and every list line takes one line
+func fakeCompile(source string, opts compile.Options) (*compile.Result, error) {
+	lines := strings.Count(source, "#resume-entry(")
+	for _, line := range strings.Split(source, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "- ") {
+			lines++
+		}
+	}
+	perPage := 10
+	if strings.Contains(source, "#set par(") {
+		perPage = 12
+	}
+	pages := (lines + perPage - 1) / perPage
+	return &compile.Result{Format: compile.FormatPDF, Pages: make([][]byte, pages), PageCount: pages}, nil
+}
+
+func longResume() model.ResumeData {
+	return model.ResumeData{
+		Author: model.Profile{FirstName: "Jane", LastName: "Doe"},
+		WorkExperience: []model.ResumeEntry{
+			{Title: "Lead", Date: "2020 - Present", Priority: 2, Bullets: []model.Bullet{{Text: "Led a team"}, {Text: "Shipped v2"}, {Text: "Hired"}}},
+			{Title: "Developer", Date: "2016 - 2020", Bullets: []model.Bullet{{Text: "Wrote drivers"}, {Text: "Fixed bugs"}, {Text: "Reviewed code"}, {Text: "Ran builds"}}},
+		},
+		Projects: []model.ResumeEntry{
+			{Title: "Compiler", Date: "2019", Bullets: []model.Bullet{{Text: "Parser"}, {Text: "Backend"}}},
+			{Title: "Game", Date: "2015", Bullets: []model.Bullet{{Text: "Physics"}, {Text: "Sound"}}},
+		},
+		Interests: []model.InterestItem{{Category: "Music", Description: "Guitar"}},
+		Skills: []model.SkillCategory{
+			{Name: "Languages", Skills: []model.SkillItem{{Name: "Go", Priority: 1}, {Name: "C"}, {Name: "Rust"}}},
+			{Name: "Tools", Skills: []model.SkillItem{{Name: "Git"}}},
+		},
+	}
+}
+
+func TestFitResumeWithoutBudget(t *testing.T) {
+	templateContent, err := ResumeTemplate("templates/resume.typ.template")
+	if err != nil {
+		t.Fatalf("Failed to read template: %v", err)
+	}
+	_, result, report, err := fitResume(templateContent, longResume(), compile.Options{}, fakeCompile)
+	if err != nil {
+		t.Fatalf("fitResume failed: %v", err)
+	}
+	if report != nil {
+		t.Errorf("Expected no report without max_pages, got %+v", report)
+	}
+	if result.PageCount != 2 {
+		t.Errorf("PageCount = %d, want 2", result.PageCount)
+	}
+}
+
+func TestFitResumeTrimsToBudget(t *testing.T) {
+	templateContent, err := ResumeTemplate("templates/resume.typ.template")
+	if err != nil {
+		t.Fatalf("Failed to read template: %v", err)
+	}
+	data := longResume()
+	data.Options = &model.RenderOptions{MaxPages: 1}
+
+	source, result, report, err := fitResume(templateContent, data, compile.Options{}, fakeCompile)
+	if err != nil {
+		t.Fatalf("fitResume failed: %v", err)
+	}
+	if !report.Fits || report.PageCount != 1 || result.PageCount != 1 {
+		t.Fatalf("Expected the resume to fit on one page: %+v", report)
+	}
+
+	// 4 entries and 11 bullets need 15 lines: tightening gives 12, then three bullets go,
+	// always from the longest list and from projects first among equally long ones
+	want := []FitCut{
+		{Kind: "layout", Text: "tightened spacing to level 1"},
+		{Kind: "layout", Text: "tightened spacing to level 2"},
+		{Kind: "bullet", Section: "work_experience", Title: "Developer", Text: "Ran builds"},
+		{Kind: "bullet", Section: "work_experience", Title: "Developer", Text: "Reviewed code"},
+		{Kind: "bullet", Section: "projects", Title: "Compiler", Text: "Backend"},
+	}
+	if len(report.Cuts) != len(want) {
+		t.Fatalf("Cuts = %+v, want %+v", report.Cuts, want)
+	}
+	for i := range want {
+		if report.Cuts[i] != want[i] {
+			t.Errorf("Cut %d = %+v, want %+v", i, report.Cuts[i], want[i])
+		}
+	}
+	if strings.Contains(source, "Ran builds") || !strings.Contains(source, "#set text(size: 9pt)") {
+		t.Error("The returned source should be the trimmed, tightened one")
+	}
+	if len(data.WorkExperience[1].Bullets) != 4 {
+		t.Error("Fitting should not modify the caller's data")
+	}
+}
+
+func TestTrimResumeOrder(t *testing.T) {
+	data, err := longResume().PrepareForRender()
+	if err != nil {
+		t.Fatalf("Failed to prepare resume: %v", err)
+	}
+	var kinds []string
+	for {
+		cut, ok := trimResume(&data)
+		if !ok {
+			break
+		}
+		kinds = append(kinds, cut.Kind+":"+cut.Title)
+	}
+	got := strings.Join(kinds, ",")
+	want := "bullet:Developer,bullet:Developer,bullet:Compiler,bullet:Game,bullet:Developer," +
+		"interest:Music,skill:Languages,skill:Languages,entry:Game,entry:Compiler,entry:Developer," +
+		"bullet:Lead,bullet:Lead,entry:Lead,skill:Tools,skill:Languages"
+	if got != want {
+		t.Errorf("Trim order:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestFitResumeRejectsUnknownPageCount(t *testing.T) {
+	templateContent, err := ResumeTemplate("templates/resume.typ.template")
+	if err != nil {
+		t.Fatalf("Failed to read template: %v", err)
+	}
+	data := longResume()
+	data.Options = &model.RenderOptions{MaxPages: 1}
+	noPages := func(string, compile.Options) (*compile.Result, error) {
+		return &compile.Result{Format: compile.FormatPDF}, nil
+	}
+	if _, _, _, err := fitResume(templateContent, data, compile.Options{}, noPages); err == nil {
+		t.Error("Expected an error when the page count is unknown")
+	}
+}
@@ -0,0 +1,151 @@
+package render
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"cvcl-render/compile"
+	"cvcl-render/i18n"
+	"cvcl-render/model"
+)
+
+//go:embed templates/resume.typ.template
+var embeddedResumeTemplates embed.FS
+
+// resumeTemplateData is passed to the resume template: the resume fields plus the localized messages and layout
+type resumeTemplateData struct {
+	model.ResumeData
+	Messages i18n.Messages
+	Layout   resumeLayout
+}
+
+// ResumeTemplate reads a resume template from the filesystem, falling back to the
+// embedded template when a file named resume.typ.template does not exist
+func ResumeTemplate(templatePath string) (string, error) {
+	templateContent, err := os.ReadFile(templatePath)
+	if err == nil {
+		return string(templateContent), nil
+	}
+
+	// Fall back to the embedded template
+	if errors.Is(err, fs.ErrNotExist) && strings.HasSuffix(templatePath, "resume.typ.template") {
+		data, embedErr := embeddedResumeTemplates.ReadFile("templates/resume.typ.template")
+		if embedErr == nil {
+			return string(data), nil
+		}
+	}
+	return "", fmt.Errorf("failed to read template file: %w", err)
+}
+
+// Resume renders the resume template with the provided data
+func Resume(templateContent string, data model.ResumeData) (string, error) {
+	prepared, err := data.PrepareForRender()
+	if err != nil {
+		return "", err
+	}
+	return renderResume(templateContent, prepared, resumeLayout{})
+}
+
+// templateFuncs are the functions available to the Typst templates
+var templateFuncs = template.FuncMap{
+	"contains":    strings.Contains,
+	"typstString": typstString,
+}
+
+// typstStringEscaper escapes the characters with a meaning inside a Typst string literal
+var typstStringEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// typstString escapes a value for use inside a quoted Typst string, so that quotes and
+// backslashes in user input cannot end the string
+func typstString(s string) string {
+	return typstStringEscaper.Replace(s)
+}
+
+// renderResume renders the resume template with data already prepared by PrepareForRender,
+// which must not run twice, and the layout settings
+func renderResume(templateContent string, data model.ResumeData, layout resumeLayout) (string, error) {
+	// Parse template
+	tmpl, err := template.New("resume").Funcs(templateFuncs).Parse(templateContent)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	// Execute template
+	var result strings.Builder
+	err = tmpl.Execute(&result, resumeTemplateData{ResumeData: data, Messages: i18n.Get(data.Language()), Layout: layout})
+	if err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	return result.String(), nil
+}
+
+// ResumeFromJSON renders the template using JSON data
+func ResumeFromJSON(templateContent string, jsonData string) (string, error) {
+	var data model.ResumeData
+	err := json.Unmarshal([]byte(jsonData), &data)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse JSON data: %w", err)
+	}
+
+	return Resume(templateContent, data)
+}
+
+// ResumeBaseName returns the output file name (without extension) for a resume
+func ResumeBaseName(data model.ResumeData) string {
+	return fmt.Sprintf("Resume_%s_%s", fileNamePart(data.Author.FirstName), fileNamePart(data.Author.LastName))
+}
+
+// CompileResume renders the resume template and optionally compiles to PDF.
+// When options.max_pages is set and the PDF is compiled, the returned report lists what was cut to fit.
+func CompileResume(templateContent string, data model.ResumeData, outputDir string, skipPDF bool) (typstFile string, pdfFile string, report *FitReport, err error) {
+	// Render template, fitting it to the page budget when compiling
+	var result string
+	var compiled *compile.Result
+	if skipPDF {
+		result, err = Resume(templateContent, data)
+	} else {
+		result, compiled, report, err = fitResume(templateContent, data, compile.Options{Format: compile.FormatPDF}, compile.Source)
+	}
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	// Generate output filenames
+	baseName := ResumeBaseName(data)
+	typstFileName := baseName + ".typ"
+	pdfFileName := baseName + ".pdf"
+
+	typstFilePath := filepath.Join(outputDir, typstFileName)
+	pdfFilePath := filepath.Join(outputDir, pdfFileName)
+
+	// Write the rendered Typst file
+	err = os.WriteFile(typstFilePath, []byte(result), 0644)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to write Typst file: %w", err)
+	}
+
+	// Write the PDF if compiled
+	if compiled != nil {
+		err = os.WriteFile(pdfFilePath, compiled.Pages[0], 0644)
+		if err != nil {
+			return "", "", nil, fmt.Errorf("failed to write PDF file: %w", err)
+		}
+	}
+
+	return typstFilePath, pdfFilePath, report, nil
+}
+
+// PreviewResume renders the resume template and compiles it to page images,
+// fitting it to options.max_pages like CompileResume
+func PreviewResume(templateContent string, data model.ResumeData, opts compile.Options) (*compile.Result, *FitReport, error) {
+	_, result, report, err := fitResume(templateContent, data, opts, compile.Source)
+	return result, report, err
+}
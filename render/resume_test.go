@@ -0,0 +1,57 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"cvcl-render/model"
+)
+
+func TestResumeSortsAndFormatsDates(t *testing.T) {
+	templateContent, err := ResumeTemplate("templates/resume.typ.template")
+	if err != nil {
+		t.Fatalf("Failed to read template: %v", err)
+	}
+	data := model.ResumeData{
+		WorkExperience: []model.ResumeEntry{
+			{Title: "Oldest", Date: "2015 - 2017"},
+			{Title: "Undated"},
+			{Title: "Current", Dates: model.DateRanges{{Start: &model.YearMonth{Year: 2022, Month: 1}, Ongoing: true}}},
+			{Title: "Recent", Date: "Jan. - May 2021"},
+		},
+		Options: &model.RenderOptions{Language: "sv", SortByDate: true},
+	}
+
+	result, err := Resume(templateContent, data)
+	if err != nil {
+		t.Fatalf("Failed to render resume: %v", err)
+	}
+	order := []string{`"Current"`, `"Recent"`, `"Oldest"`, `"Undated"`}
+	last := -1
+	for _, title := range order {
+		idx := strings.Index(result, title)
+		if idx <= last {
+			t.Fatalf("Expected entries in order %v, got:\n%s", order, result)
+		}
+		last = idx
+	}
+	for _, date := range []string{"jan. 2022 - nu", "jan. 2021 - maj 2021", "2015 - 2017"} {
+		if !strings.Contains(result, date) {
+			t.Errorf("Rendered resume is missing date %q", date)
+		}
+	}
+	if data.WorkExperience[0].Title != "Oldest" {
+		t.Error("Rendering should not reorder the caller's entries")
+	}
+}
+
+func TestBaseNamesKeepNamesInOutputDir(t *testing.T) {
+	author := model.Profile{FirstName: "../../etc", LastName: "Doe/x"}
+	if name := ResumeBaseName(model.ResumeData{Author: author}); name != "Resume_______etc_Doe_x" {
+		t.Errorf("Unexpected resume file name %q", name)
+	}
+	letter := model.CoverLetterData{Profile: author, Position: "Go/Rust Engineer"}
+	if name := CoverLetterBaseName(letter); name != "Cover_Letter_______etc_Go_Rust_Engineer" {
+		t.Errorf("Unexpected cover letter file name %q", name)
+	}
+}
@@ -0,0 +1,650 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cvcl-render/auth"
+	"cvcl-render/compile"
+	"cvcl-render/draft"
+	"cvcl-render/examples"
+	"cvcl-render/keywords"
+	"cvcl-render/latex"
+	"cvcl-render/model"
+	"cvcl-render/render"
+	"cvcl-render/usage"
+)
+
+// The v1 API serves the endpoints of the unversioned API under /v1 with typed request and
+// response bodies and a single error envelope. The unversioned routes keep their responses
+// for existing clients.
+
+// apiV1Prefix is the path prefix of the v1 API
+const apiV1Prefix = "/v1"
+
+// Error codes of the v1 error envelope
+const (
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeNotFound         = "not_found"
+	CodeInvalidParameter = "invalid_parameter"
+	CodeInvalidJSON      = "invalid_json"
+	CodeValidationFailed = "validation_failed"
+	CodeBodyTooLarge     = "body_too_large"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeRateLimited      = "rate_limited"
+	CodeQuotaExceeded    = "quota_exceeded"
+	CodeUnreadableFile   = "unreadable_file"
+	CodeParseFailed      = "parse_failed"
+	CodeRenderFailed     = "render_failed"
+	CodeInternal         = "internal_error"
+	CodeNotImplemented   = "not_implemented"
+)
+
+// APIError describes why a v1 request failed. Details lists the offending fields of
+// validation errors.
+type APIError struct {
+	Code    string             `json:"code"`
+	Message string             `json:"message"`
+	Details []model.FieldError `json:"details,omitempty"`
+}
+
+// ErrorResponse is the body of every failed v1 request
+type ErrorResponse struct {
+	Error    APIError `json:"error"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Request bodies of the v1 endpoints that take a document as it is
+type (
+	RenderCoverLetterRequest = model.CoverLetterData
+	RenderResumeRequest      = model.ResumeData
+	RenderApplicationRequest = model.ApplicationRequest
+	MatchKeywordsRequest     = model.KeywordMatchRequest
+	DraftCoverLetterRequest  = model.DraftRequest
+)
+
+// ParseCoverLetterRequest is the request body of /v1/parse-coverletter
+type ParseCoverLetterRequest struct {
+	// Source is a rendered Typst cover letter
+	Source string `json:"source,omitempty"`
+	// FilePath is a rendered Typst cover letter in the server's output directory, relative to it
+	FilePath string `json:"file_path,omitempty"`
+}
+
+// Validate checks that either the source or a file is given
+func (r ParseCoverLetterRequest) Validate() error {
+	source, file := strings.TrimSpace(r.Source) != "", strings.TrimSpace(r.FilePath) != ""
+	switch {
+	case !source && !file:
+		return model.ValidationErrors{{Field: "source", Message: "is required unless file_path is given"}}
+	case source && file:
+		return model.ValidationErrors{{Field: "file_path", Message: "must not be given with source"}}
+	}
+	return nil
+}
+
+// ParseResumeRequest is the request body of /v1/parse-resume
+type ParseResumeRequest struct {
+	// Source is a Typst or LaTeX resume
+	Source string `json:"source,omitempty"`
+	// FilePath is a Typst or LaTeX resume in the server's output directory, relative to it
+	FilePath string `json:"file_path,omitempty"`
+	// SourceFormat is typst (default), latex, moderncv or awesome-cv
+	SourceFormat string `json:"source_format,omitempty"`
+}
+
+// Validate checks that either the source or a file, and a known source format are given
+func (r ParseResumeRequest) Validate() error {
+	var errs model.ValidationErrors
+	source, file := strings.TrimSpace(r.Source) != "", strings.TrimSpace(r.FilePath) != ""
+	switch {
+	case !source && !file:
+		errs = append(errs, model.FieldError{Field: "source", Message: "is required unless file_path is given"})
+	case source && file:
+		errs = append(errs, model.FieldError{Field: "file_path", Message: "must not be given with source"})
+	}
+	switch r.SourceFormat {
+	case "", "typst", "latex", latex.ClassModernCV, latex.ClassAwesomeCV:
+	default:
+		errs = append(errs, model.FieldError{Field: "source_format", Message: fmt.Sprintf("must be typst, latex, %s or %s", latex.ClassModernCV, latex.ClassAwesomeCV)})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ResetUsageRequest is the request body of /v1/admin/usage/reset
+type ResetUsageRequest struct {
+	// Client is a client ID as listed by /v1/admin/usage, e.g. "api_key:ci-bot" or "ip:10.0.0.7"
+	Client string `json:"client"`
+}
+
+// Validate checks that a client is given
+func (r ResetUsageRequest) Validate() error {
+	if strings.TrimSpace(r.Client) == "" {
+		return model.ValidationErrors{{Field: "client", Message: "is required"}}
+	}
+	return nil
+}
+
+// ParseCoverLetterResponse is the response body of /v1/parse-coverletter
+type ParseCoverLetterResponse struct {
+	CoverLetter model.CoverLetterData `json:"cover_letter"`
+}
+
+// ParseResumeResponse is the response body of /v1/parse-resume.
+// Resume is in the form accepted by /v1/render-resume.
+type ParseResumeResponse struct {
+	Resume model.ResumeData `json:"resume"`
+	// Class and Unrecognized are set for LaTeX imports
+	Class        string   `json:"class,omitempty"`
+	Unrecognized []string `json:"unrecognized,omitempty"`
+}
+
+// MatchKeywordsResponse is the response body of /v1/match-keywords
+type MatchKeywordsResponse struct {
+	Report   keywords.Report `json:"report"`
+	Warnings []string        `json:"warnings,omitempty"`
+}
+
+// DraftCoverLetterResponse is the response body of /v1/draft-coverletter
+type DraftCoverLetterResponse struct {
+	CoverLetter model.CoverLetterData `json:"cover_letter"`
+	// Generator names the generator that wrote the draft
+	Generator string   `json:"generator"`
+	Warnings  []string `json:"warnings,omitempty"`
+}
+
+// ClientUsage is a client's use of its rate limit and daily render quota
+type ClientUsage struct {
+	Client string `json:"client"`
+	// Tokens is the number of requests the client may make at once; omitted without a rate limit
+	Tokens  *int `json:"tokens,omitempty"`
+	Renders int  `json:"renders"`
+	// RemainingRenders is omitted without a daily quota
+	RemainingRenders *int `json:"remaining_renders,omitempty"`
+}
+
+// UsageResponse is the response body of /v1/admin/usage. Zero limits are not enforced.
+type UsageResponse struct {
+	RequestsPerMinute int `json:"requests_per_minute"`
+	Burst             int `json:"burst"`
+	DailyRenders      int `json:"daily_renders"`
+	// QuotaReset is when the render counts start over
+	QuotaReset string        `json:"quota_reset,omitempty"`
+	Clients    []ClientUsage `json:"clients"`
+}
+
+// HealthResponse is the response body of /v1/health and the /health endpoints.
+// Status is "ok", "unavailable" when a readiness check fails, or "draining" while the server shuts down.
+type HealthResponse struct {
+	Status string `json:"status"`
+	// Checks lists the readiness checks of /health/ready, which ran at CheckedAt
+	Checks    []CheckResult `json:"checks,omitempty"`
+	CheckedAt string        `json:"checked_at,omitempty"`
+}
+
+// Config holds the settings of the HTTP API
+type Config struct {
+	TemplatePath       string
+	ResumeTemplatePath string
+	OutputDir          string
+	SkipPDF            bool
+	DecodeMode         model.DecodeMode
+	Generator          draft.Generator
+	// MaxBodyBytes limits the size of request bodies; zero means no limit
+	MaxBodyBytes int64
+	// ReadTimeout, WriteTimeout and IdleTimeout bound connections of a Server; zero means no timeout
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	// Health reports the drain state and checks on /health/ready; nil means always ready
+	Health *Health
+	// Authenticator identifies callers of every endpoint but the health checks; nil disables authentication
+	Authenticator auth.Authenticator
+	// AuditLog receives a JSON line per authenticated or rejected request; nil disables it
+	AuditLog io.Writer
+	// RateLimiter limits the requests of each client to the render and parse endpoints; nil disables it
+	RateLimiter *usage.Limiter
+	// Quotas counts and limits the daily renders of each client; nil disables it
+	Quotas *usage.Quotas
+}
+
+// apiParam is a query parameter of a v1 endpoint
+type apiParam struct {
+	Name string
+	// Type is the OpenAPI type: string, integer or boolean
+	Type        string
+	Enum        []string
+	Description string
+}
+
+var (
+	strictParam   = apiParam{Name: "strict", Type: "boolean", Description: "Reject unknown fields; defaults to the server's decode mode"}
+	languageParam = apiParam{Name: "language", Type: "string", Description: "Language for per-language values"}
+)
+
+// apiRoute describes a v1 endpoint for both the server and the OpenAPI document
+type apiRoute struct {
+	Method string
+	// Path may end in a {name} path parameter
+	Path        string
+	OperationID string
+	Summary     string
+	Query       []apiParam
+	// Request is a zero value of the JSON request body, or nil; a slice of zero values
+	// accepts any one of them
+	Request interface{}
+	// Response is a zero value of the JSON response body; file endpoints list their content types in Files instead
+	Response interface{}
+	Files    []string
+	// Headers are the X- headers set on successful responses
+	Headers []string
+	// Error is a zero value of the error body; defaults to ErrorResponse
+	Error interface{}
+	// RequestExample and ResponseExample are shown in the OpenAPI document
+	RequestExample  interface{}
+	ResponseExample interface{}
+	Handler         http.HandlerFunc
+}
+
+// pattern returns the ServeMux pattern of the route
+func (route apiRoute) pattern() string {
+	if i := strings.Index(route.Path, "{"); i >= 0 {
+		return route.Path[:i]
+	}
+	return route.Path
+}
+
+// handle checks the request method before calling the handler
+func (route apiRoute) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != route.Method {
+		w.Header().Set("Allow", route.Method)
+		writeAPIError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, fmt.Sprintf("Method not allowed. Please use %s.", route.Method))
+		return
+	}
+	route.Handler(w, r)
+}
+
+// v1Routes lists the endpoints of the v1 API
+func v1Routes(cfg Config) []apiRoute {
+	documentParams := []apiParam{strictParam, languageParam}
+	routes := []apiRoute{
+		{
+			Method: http.MethodPost, Path: "/v1/render", OperationID: "renderCoverLetter",
+			Summary:        "Render a cover letter to PDF or DOCX",
+			Query:          append([]apiParam{{Name: "format", Type: "string", Enum: []string{"pdf", "docx"}}}, documentParams...),
+			Request:        RenderCoverLetterRequest{},
+			RequestExample: json.RawMessage(examples.CoverLetter),
+			Files:          []string{"application/pdf", render.DOCXContentType, compile.TypstContentType},
+			Headers:        []string{"X-Warnings"},
+			Handler:        handleV1Render(cfg),
+		},
+		{
+			Method: http.MethodPost, Path: "/v1/render-resume", OperationID: "renderResume",
+			Summary:        "Render a resume to PDF or DOCX",
+			Query:          append([]apiParam{{Name: "format", Type: "string", Enum: []string{"pdf", "docx"}}}, documentParams...),
+			Request:        RenderResumeRequest{},
+			RequestExample: json.RawMessage(examples.Resume),
+			Files:          []string{"application/pdf", render.DOCXContentType, compile.TypstContentType},
+			Headers:        []string{"X-Warnings", "X-Fit-Report"},
+			Handler:        handleV1RenderResume(cfg),
+		},
+		{
+			Method: http.MethodPost, Path: "/v1/render-application", OperationID: "renderApplication",
+			Summary: "Render a cover letter and a resume with a shared author",
+			Query: append([]apiParam{
+				{Name: "format", Type: "string", Enum: []string{"zip", "multipart"}},
+				{Name: "merge", Type: "boolean", Description: "Add both documents merged into one PDF; requires typst " + render.MergeTypstVersion},
+			}, documentParams...),
+			Request: RenderApplicationRequest{},
+			Files:   []string{"application/zip", "multipart/mixed"},
+			Headers: []string{"X-Warnings", "X-Fit-Report"},
+			Handler: handleV1RenderApplication(cfg),
+		},
+		{
+			Method: http.MethodPost, Path: "/v1/preview", OperationID: "preview",
+			Summary: "Render page images of a cover letter or resume",
+			Query: append([]apiParam{
+				{Name: "document", Type: "string", Enum: []string{"coverletter", "resume"}},
+				{Name: "format", Type: "string", Enum: []string{"png", "svg"}},
+				{Name: "ppi", Type: "integer", Description: "Resolution of PNG pages"},
+				{Name: "page", Type: "integer", Description: "1-based page to return; all pages are zipped when omitted"},
+			}, documentParams...),
+			Request: []interface{}{RenderCoverLetterRequest{}, RenderResumeRequest{}},
+			Files:   []string{"image/png", "image/svg+xml", "application/zip"},
+			Headers: []string{"X-Warnings", "X-Fit-Report", "X-Page-Count"},
+			Handler: handleV1Preview(cfg),
+		},
+		{
+			Method: http.MethodPost, Path: "/v1/parse-coverletter", OperationID: "parseCoverLetter",
+			Summary:  "Parse a rendered Typst cover letter",
+			Request:  ParseCoverLetterRequest{},
+			Response: ParseCoverLetterResponse{},
+			Handler:  handleV1ParseCoverLetter(cfg),
+		},
+		{
+			Method: http.MethodPost, Path: "/v1/parse-resume", OperationID: "parseResume",
+			Summary:         "Parse a Typst resume or import a LaTeX one, given as source or as a file in the output directory",
+			Request:         ParseResumeRequest{},
+			RequestExample:  ParseResumeRequest{FilePath: "resume.typ"},
+			Response:        ParseResumeResponse{},
+			ResponseExample: map[string]interface{}{"resume": json.RawMessage(examples.Resume)},
+			Handler:         handleV1ParseResume(cfg),
+		},
+		{
+			Method: http.MethodPost, Path: "/v1/match-keywords", OperationID: "matchKeywords",
+			Summary:  "Compare a job description with a resume and cover letter",
+			Query:    documentParams,
+			Request:  MatchKeywordsRequest{},
+			Response: MatchKeywordsResponse{},
+			Handler:  handleV1MatchKeywords(cfg),
+		},
+		{
+			Method: http.MethodPost, Path: "/v1/draft-coverletter", OperationID: "draftCoverLetter",
+			Summary:  "Draft a cover letter from a resume and a job description",
+			Query:    documentParams,
+			Request:  DraftCoverLetterRequest{},
+			Response: DraftCoverLetterResponse{},
+			Handler:  handleV1DraftCoverLetter(cfg),
+		},
+		{
+			Method: http.MethodGet, Path: "/v1/schema/{name}", OperationID: "getSchema",
+			Summary: "JSON Schema of an input document (coverletter or resume)",
+			Files:   []string{"application/schema+json"},
+			Handler: handleV1Schema,
+		},
+		{
+			Method: http.MethodGet, Path: "/v1/health", OperationID: "health",
+			Summary:         "Health check",
+			Response:        HealthResponse{},
+			ResponseExample: HealthResponse{Status: "ok"},
+			Handler:         handleV1Health,
+		},
+	}
+	// Usage can only be managed by admins, so not without authentication
+	if cfg.Authenticator != nil {
+		routes = append(routes, apiRoute{
+			Method: http.MethodGet, Path: "/v1/admin/usage", OperationID: "getUsage",
+			Summary:  "Rate limit and render quota usage of the clients",
+			Query:    []apiParam{{Name: "client", Type: "string", Description: "Only this client, e.g. api_key:ci-bot or ip:10.0.0.7"}},
+			Response: UsageResponse{},
+			Handler:  handleV1Usage(cfg),
+		}, apiRoute{
+			Method: http.MethodPost, Path: "/v1/admin/usage/reset", OperationID: "resetUsage",
+			Summary:        "Clear a client's render count for today and refill its rate limit",
+			Request:        ResetUsageRequest{},
+			RequestExample: ResetUsageRequest{Client: "api_key:ci-bot"},
+			Response:       ClientUsage{},
+			Handler:        handleV1ResetUsage(cfg),
+		})
+	}
+	return routes
+}
+
+// registerV1Routes adds the v1 endpoints to a mux; unknown /v1 paths get a not_found error
+func registerV1Routes(mux *http.ServeMux, routes []apiRoute) {
+	for _, route := range routes {
+		mux.HandleFunc(route.pattern(), route.handle)
+	}
+	mux.HandleFunc(apiV1Prefix+"/", func(w http.ResponseWriter, r *http.Request) {
+		writeAPIError(w, http.StatusNotFound, CodeNotFound, fmt.Sprintf("Unknown endpoint: %s", r.URL.Path))
+	})
+}
+
+// writeAPIJSON sends a v1 JSON response
+func writeAPIJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeAPIError sends the v1 error envelope
+func writeAPIError(w http.ResponseWriter, status int, code, message string) {
+	writeAPIJSON(w, status, ErrorResponse{Error: APIError{Code: code, Message: message}})
+}
+
+// decodeAPIRequest decodes and validates the JSON body of a v1 request.
+// On failure it sends the error envelope and returns false.
+func decodeAPIRequest(w http.ResponseWriter, r *http.Request, v interface{ Validate() error }, decodeMode model.DecodeMode) ([]string, bool) {
+	warnings, err := decodeRequest(r, v, decodeMode)
+	if err != nil {
+		writeAPIRequestError(w, err)
+		return warnings, false
+	}
+	return warnings, true
+}
+
+// writeAPIRequestError sends the v1 error envelope for a failed request
+func writeAPIRequestError(w http.ResponseWriter, err error) {
+	reqErr := asRequestError(err)
+	writeAPIJSON(w, reqErr.Status, ErrorResponse{
+		Error:    APIError{Code: reqErr.Code, Message: reqErr.Message, Details: reqErr.Details},
+		Warnings: reqErr.Warnings,
+	})
+}
+
+// readOutputFile reads a file given relative to the output directory, refusing paths that
+// lead outside of it
+func readOutputFile(outputDir, name string) ([]byte, error) {
+	if outputDir == "" {
+		return nil, errors.New("no output directory is configured")
+	}
+	root, err := filepath.Abs(outputDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve output directory: %w", err)
+	}
+	path := filepath.Join(root, name)
+	if filepath.IsAbs(name) {
+		path = filepath.Clean(name)
+	}
+	if !withinDir(root, path) {
+		return nil, fmt.Errorf("%s is outside the output directory", name)
+	}
+	// Symbolic links must not lead outside either
+	if resolvedRoot, err := filepath.EvalSymlinks(root); err == nil {
+		if resolved, err := filepath.EvalSymlinks(path); err == nil && !withinDir(resolvedRoot, resolved) {
+			return nil, fmt.Errorf("%s is outside the output directory", name)
+		}
+	}
+	return os.ReadFile(path)
+}
+
+// withinDir reports whether path is dir or inside it
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// handleV1Render handles POST /v1/render
+func handleV1Render(cfg Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format, err := documentFormat(r.URL.Query())
+		if err != nil {
+			writeAPIRequestError(w, err)
+			return
+		}
+		var data RenderCoverLetterRequest
+		warnings, ok := decodeAPIRequest(w, r, &data, cfg.DecodeMode)
+		if !ok {
+			return
+		}
+
+		file, err := renderCoverLetter(cfg, data, format)
+		if err != nil {
+			writeAPIRequestError(w, err)
+			return
+		}
+		writeFileResult(w, file, warnings)
+	}
+}
+
+// handleV1RenderResume handles POST /v1/render-resume
+func handleV1RenderResume(cfg Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format, err := documentFormat(r.URL.Query())
+		if err != nil {
+			writeAPIRequestError(w, err)
+			return
+		}
+		var data RenderResumeRequest
+		warnings, ok := decodeAPIRequest(w, r, &data, cfg.DecodeMode)
+		if !ok {
+			return
+		}
+
+		file, err := renderResume(cfg, data, format)
+		if err != nil {
+			writeAPIRequestError(w, err)
+			return
+		}
+		writeFileResult(w, file, warnings)
+	}
+}
+
+// handleV1RenderApplication handles POST /v1/render-application
+func handleV1RenderApplication(cfg Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format, merge, err := applicationParams(r.URL.Query())
+		if err != nil {
+			writeAPIRequestError(w, err)
+			return
+		}
+		var req RenderApplicationRequest
+		warnings, ok := decodeAPIRequest(w, r, &req, cfg.DecodeMode)
+		if !ok {
+			return
+		}
+
+		file, err := renderApplication(cfg, req, format, merge)
+		if err != nil {
+			writeAPIRequestError(w, err)
+			return
+		}
+		writeFileResult(w, file, warnings)
+	}
+}
+
+// handleV1Preview handles POST /v1/preview
+func handleV1Preview(cfg Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		opts, page, err := previewParams(query)
+		if err != nil {
+			writeAPIRequestError(w, err)
+			return
+		}
+		document, err := previewDocument(query)
+		if err != nil {
+			writeAPIRequestError(w, err)
+			return
+		}
+		warnings, ok := decodeAPIRequest(w, r, document, cfg.DecodeMode)
+		if !ok {
+			return
+		}
+
+		file, err := previewPages(cfg, document, opts, page)
+		if err != nil {
+			writeAPIRequestError(w, err)
+			return
+		}
+		writeFileResult(w, file, warnings)
+	}
+}
+
+// handleV1ParseCoverLetter handles POST /v1/parse-coverletter
+func handleV1ParseCoverLetter(cfg Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ParseCoverLetterRequest
+		if _, ok := decodeAPIRequest(w, r, &req, cfg.DecodeMode); !ok {
+			return
+		}
+		coverLetter, err := parseCoverLetter(cfg, req)
+		if err != nil {
+			writeAPIRequestError(w, err)
+			return
+		}
+		writeAPIJSON(w, http.StatusOK, ParseCoverLetterResponse{CoverLetter: *coverLetter})
+	}
+}
+
+// handleV1ParseResume handles POST /v1/parse-resume
+func handleV1ParseResume(cfg Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ParseResumeRequest
+		if _, ok := decodeAPIRequest(w, r, &req, cfg.DecodeMode); !ok {
+			return
+		}
+		resume, err := parseResume(cfg, req)
+		if err != nil {
+			writeAPIRequestError(w, err)
+			return
+		}
+		if resume.Imported != nil {
+			writeAPIJSON(w, http.StatusOK, ParseResumeResponse{Resume: resume.Imported.Resume, Class: resume.Imported.Class, Unrecognized: resume.Imported.Unrecognized})
+			return
+		}
+		writeAPIJSON(w, http.StatusOK, ParseResumeResponse{Resume: resume.Typst.Data()})
+	}
+}
+
+// handleV1MatchKeywords handles POST /v1/match-keywords
+func handleV1MatchKeywords(cfg Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req MatchKeywordsRequest
+		warnings, ok := decodeAPIRequest(w, r, &req, cfg.DecodeMode)
+		if !ok {
+			return
+		}
+		report := keywords.Compare(req.JobDescription, req.Resume, req.CoverLetter)
+		writeAPIJSON(w, http.StatusOK, MatchKeywordsResponse{Report: report, Warnings: warnings})
+	}
+}
+
+// handleV1DraftCoverLetter handles POST /v1/draft-coverletter
+func handleV1DraftCoverLetter(cfg Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req DraftCoverLetterRequest
+		warnings, ok := decodeAPIRequest(w, r, &req, cfg.DecodeMode)
+		if !ok {
+			return
+		}
+		letter, generatorName, draftWarnings, err := draftCoverLetter(r.Context(), cfg.Generator, req)
+		if err != nil {
+			writeAPIRequestError(w, err)
+			return
+		}
+		writeAPIJSON(w, http.StatusOK, DraftCoverLetterResponse{
+			CoverLetter: letter,
+			Generator:   generatorName,
+			Warnings:    append(warnings, draftWarnings...),
+		})
+	}
+}
+
+// handleV1Schema handles GET /v1/schema/{name}
+func handleV1Schema(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, apiV1Prefix+"/schema/"), ".json")
+	schema, err := model.Schema(name)
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, CodeNotFound, fmt.Sprintf("Unknown schema: %s", name))
+		return
+	}
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Write(schema)
+}
+
+// handleV1Health handles GET /v1/health
+func handleV1Health(w http.ResponseWriter, r *http.Request) {
+	writeAPIJSON(w, http.StatusOK, HealthResponse{Status: "ok"})
+}
@@ -0,0 +1,349 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"cvcl-render/compile"
+	"cvcl-render/examples"
+	"cvcl-render/model"
+	"cvcl-render/render"
+)
+
+// testOpenAPI returns the generated OpenAPI document as decoded JSON
+func testOpenAPI(t *testing.T) map[string]interface{} {
+	t.Helper()
+	document, err := buildOpenAPI(v1Routes(Config{}), false)
+	if err != nil {
+		t.Fatalf("Failed to build OpenAPI document: %v", err)
+	}
+	encoded, err := json.Marshal(document)
+	if err != nil {
+		t.Fatalf("Failed to encode OpenAPI document: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Failed to decode OpenAPI document: %v", err)
+	}
+	return decoded
+}
+
+// componentSchema returns a component schema of an OpenAPI document
+func componentSchema(t *testing.T, document map[string]interface{}, name string) map[string]interface{} {
+	t.Helper()
+	schema, ok := document["components"].(map[string]interface{})["schemas"].(map[string]interface{})[name].(map[string]interface{})
+	if !ok {
+		t.Fatalf("OpenAPI document has no %s schema", name)
+	}
+	return schema
+}
+
+func TestOpenAPIDescribesV1Types(t *testing.T) {
+	document := testOpenAPI(t)
+	paths := document["paths"].(map[string]interface{})
+	for _, route := range v1Routes(Config{}) {
+		operation, ok := paths[route.Path].(map[string]interface{})[strings.ToLower(route.Method)].(map[string]interface{})
+		if !ok {
+			t.Errorf("OpenAPI document is missing %s %s", route.Method, route.Path)
+			continue
+		}
+		errorResponse := operation["responses"].(map[string]interface{})["default"]
+		if !strings.Contains(mustJSON(t, errorResponse), "#/components/schemas/ErrorResponse") {
+			t.Errorf("%s %s does not describe the error envelope", route.Method, route.Path)
+		}
+	}
+
+	types := []interface{}{ErrorResponse{}, APIError{}, model.FieldError{}, model.Profile{}, RenderApplicationRequest{}, model.DraftOptions{}}
+	for _, route := range v1Routes(Config{}) {
+		if route.Response != nil {
+			types = append(types, route.Response)
+		}
+	}
+	for _, value := range types {
+		typ := reflect.TypeOf(value)
+		var want []string
+		want = jsonFieldNames(typ)
+		var got []string
+		for name := range componentSchema(t, document, typ.Name())["properties"].(map[string]interface{}) {
+			got = append(got, name)
+		}
+		sort.Strings(want)
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s schema has properties %v, want %v", typ.Name(), got, want)
+		}
+	}
+
+	published, err := model.Schema("coverletter")
+	if err != nil {
+		t.Fatalf("Failed to read schema: %v", err)
+	}
+	var want map[string]interface{}
+	json.Unmarshal(published, &want)
+	if !reflect.DeepEqual(componentSchema(t, document, "CoverLetterDocument"), want) {
+		t.Error("CoverLetter component differs from the published schema")
+	}
+	partial := componentSchema(t, document, "PartialCoverLetterDocument")
+	if partial["required"] != nil || partial["$id"] == want["$id"] {
+		t.Errorf("Unexpected partial cover letter schema: required %v, $id %v", partial["required"], partial["$id"])
+	}
+}
+
+// jsonFieldNames lists the JSON names of a struct's exported fields, including embedded ones
+func jsonFieldNames(typ reflect.Type) []string {
+	var names []string
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		switch {
+		case !field.IsExported() || name == "-":
+		case field.Anonymous && name == "":
+			names = append(names, jsonFieldNames(field.Type)...)
+		case name == "":
+			names = append(names, field.Name)
+		default:
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func mustJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	return string(encoded)
+}
+
+func TestV1ResponsesMatchOpenAPI(t *testing.T) {
+	outputDir := t.TempDir()
+	mux := http.NewServeMux()
+	registerV1Routes(mux, v1Routes(Config{
+		TemplatePath:       "templates/coverletter.typ.template",
+		ResumeTemplatePath: "templates/resume.typ.template",
+		OutputDir:          outputDir,
+		SkipPDF:            true,
+		DecodeMode:         model.DecodeStrict,
+	}))
+	document := testOpenAPI(t)
+
+	example := examples.CoverLetter
+	templateContent, err := render.CoverLetterTemplate("templates/coverletter.typ.template")
+	if err != nil {
+		t.Fatalf("Failed to read template: %v", err)
+	}
+	var letter model.CoverLetterData
+	json.Unmarshal(example, &letter)
+	typst, err := render.CoverLetter(templateContent, letter)
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+	os.WriteFile(filepath.Join(outputDir, "letter.typ"), []byte(typst), 0644)
+	outsideFile := filepath.Join(t.TempDir(), "letter.typ")
+	os.WriteFile(outsideFile, []byte(typst), 0644)
+	source, _ := json.Marshal(typst)
+
+	cases := []struct {
+		method, target, body string
+		status               int
+		schema               string
+		code                 string
+	}{
+		{"GET", "/v1/health", "", http.StatusOK, "HealthResponse", ""},
+		{"POST", "/v1/parse-coverletter", `{"file_path": "letter.typ"}`, http.StatusOK, "ParseCoverLetterResponse", ""},
+		{"POST", "/v1/parse-coverletter", `{"source": ` + string(source) + `}`, http.StatusOK, "ParseCoverLetterResponse", ""},
+		{"POST", "/v1/match-keywords", `{"job_description": "Go and Rust developer", "cover_letter": ` + string(example) + `}`, http.StatusOK, "MatchKeywordsResponse", ""},
+		{"POST", "/v1/render", `{"position": "Engineer"}`, http.StatusUnprocessableEntity, "ErrorResponse", CodeValidationFailed},
+		{"POST", "/v1/render", `{`, http.StatusBadRequest, "ErrorResponse", CodeInvalidJSON},
+		{"POST", "/v1/render?format=odt", string(example), http.StatusBadRequest, "ErrorResponse", CodeInvalidParameter},
+		{"POST", "/v1/parse-resume", `{"file_path": "missing.typ", "source_format": "word"}`, http.StatusUnprocessableEntity, "ErrorResponse", CodeValidationFailed},
+		{"POST", "/v1/parse-coverletter", `{"file_path": "missing.typ"}`, http.StatusUnprocessableEntity, "ErrorResponse", CodeUnreadableFile},
+		{"POST", "/v1/parse-coverletter", `{"file_path": "` + outsideFile + `"}`, http.StatusUnprocessableEntity, "ErrorResponse", CodeUnreadableFile},
+		{"POST", "/v1/parse-coverletter", `{"file_path": "../letter.typ"}`, http.StatusUnprocessableEntity, "ErrorResponse", CodeUnreadableFile},
+		{"POST", "/v1/parse-coverletter", `{}`, http.StatusUnprocessableEntity, "ErrorResponse", CodeValidationFailed},
+		{"POST", "/v1/parse-resume", `{"file_path": "/etc/passwd", "source_format": "latex"}`, http.StatusUnprocessableEntity, "ErrorResponse", CodeUnreadableFile},
+		{"POST", "/v1/parse-resume", `{"file_path": "../letter.typ"}`, http.StatusUnprocessableEntity, "ErrorResponse", CodeUnreadableFile},
+		{"POST", "/v1/parse-resume", `{"source": "\\documentclass{moderncv}\n\\firstname{Jane}\\familyname{Doe}", "source_format": "latex"}`, http.StatusOK, "ParseResumeResponse", ""},
+		{"GET", "/v1/render", "", http.StatusMethodNotAllowed, "ErrorResponse", CodeMethodNotAllowed},
+		{"GET", "/v1/schema/letter.json", "", http.StatusNotFound, "ErrorResponse", CodeNotFound},
+		{"GET", "/v1/unknown", "", http.StatusNotFound, "ErrorResponse", CodeNotFound},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body)))
+		if rec.Code != tc.status {
+			t.Errorf("%s %s: status %d, want %d: %s", tc.method, tc.target, rec.Code, tc.status, rec.Body.String())
+			continue
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Errorf("%s %s: invalid JSON response: %v", tc.method, tc.target, err)
+			continue
+		}
+		schema := componentSchema(t, document, tc.schema)
+		properties := schema["properties"].(map[string]interface{})
+		for key := range body {
+			if _, ok := properties[key]; !ok {
+				t.Errorf("%s %s: field %q is not in the %s schema", tc.method, tc.target, key, tc.schema)
+			}
+		}
+		required, _ := schema["required"].([]interface{})
+		for _, key := range required {
+			if _, ok := body[key.(string)]; !ok {
+				t.Errorf("%s %s: required field %q is missing", tc.method, tc.target, key)
+			}
+		}
+		if tc.code != "" {
+			if code := body["error"].(map[string]interface{})["code"]; code != tc.code {
+				t.Errorf("%s %s: error code %v, want %s", tc.method, tc.target, code, tc.code)
+			}
+		}
+	}
+
+	// File endpoints answer with the document itself
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("POST", "/v1/render", strings.NewReader(string(example))))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != compile.TypstContentType || !strings.Contains(rec.Body.String(), "John") {
+		t.Errorf("Unexpected render response %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
+
+func TestOpenAPIDescribesLegacyRoutes(t *testing.T) {
+	document, err := buildOpenAPI(append(legacyRoutes(), v1Routes(Config{})...), false)
+	if err != nil {
+		t.Fatalf("Failed to build OpenAPI document: %v", err)
+	}
+	var decoded map[string]interface{}
+	json.Unmarshal([]byte(mustJSON(t, document)), &decoded)
+
+	// Examples come from the example documents
+	operation := decoded["paths"].(map[string]interface{})["/render"].(map[string]interface{})["post"].(map[string]interface{})
+	example := operation["requestBody"].(map[string]interface{})["content"].(map[string]interface{})["application/json"].(map[string]interface{})["example"]
+	var want interface{}
+	json.Unmarshal(examples.CoverLetter, &want)
+	if !reflect.DeepEqual(example, want) {
+		t.Errorf("/render example = %v, want example.json", example)
+	}
+
+	// The unversioned handlers answer with the described types
+	templateContent, err := render.ResumeTemplate("templates/resume.typ.template")
+	if err != nil {
+		t.Fatalf("Failed to read template: %v", err)
+	}
+	var resume model.ResumeData
+	json.Unmarshal(json.RawMessage(examples.Resume), &resume)
+	typst, err := render.Resume(templateContent, resume)
+	if err != nil {
+		t.Fatalf("Failed to render resume: %v", err)
+	}
+	outputDir := t.TempDir()
+	os.WriteFile(filepath.Join(outputDir, "resume.typ"), []byte(typst), 0644)
+
+	cases := []struct {
+		name    string
+		handler http.HandlerFunc
+		body    string
+		schema  string
+	}{
+		{"/parse-resume", handleParseResume(Config{OutputDir: outputDir}), `{"file_path": "resume.typ"}`, "ParseResumeResult"},
+		{"/parse-resume", handleParseResume(Config{OutputDir: outputDir}), `{}`, "RenderResponse"},
+		{"/render-resume", handleRenderResume(Config{ResumeTemplatePath: "templates/resume.typ.template", OutputDir: t.TempDir(), SkipPDF: true, DecodeMode: model.DecodeStrict}), `{"positions": 1}`, "RenderResponse"},
+		{"/health", handleHealth, ``, "HealthResponse"},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		tc.handler(rec, httptest.NewRequest(http.MethodPost, tc.name, strings.NewReader(tc.body)))
+		var body map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: invalid JSON response: %v", tc.name, err)
+		}
+		properties := componentSchema(t, decoded, tc.schema)["properties"].(map[string]interface{})
+		for key := range body {
+			if _, ok := properties[key]; !ok {
+				t.Errorf("%s: field %q is not in the %s schema", tc.name, key, tc.schema)
+			}
+		}
+	}
+}
+
+func TestOpenAPIDescribesSecurityOnlyWithAuth(t *testing.T) {
+	for _, authEnabled := range []bool{false, true} {
+		document, err := buildOpenAPI(v1Routes(Config{}), authEnabled)
+		if err != nil {
+			t.Fatalf("Failed to build OpenAPI document: %v", err)
+		}
+		_, security := document["security"]
+		_, schemes := document["components"].(map[string]interface{})["securitySchemes"]
+		paths := document["paths"].(map[string]interface{})
+		_, healthSecurity := paths["/v1/health"].(map[string]interface{})["get"].(map[string]interface{})["security"]
+		_, renderScope := paths["/v1/render"].(map[string]interface{})["post"].(map[string]interface{})["description"]
+		if security != authEnabled || schemes != authEnabled || healthSecurity != authEnabled || renderScope != authEnabled {
+			t.Errorf("Auth enabled %v: security %v, schemes %v, health security %v, render scope %v",
+				authEnabled, security, schemes, healthSecurity, renderScope)
+		}
+	}
+}
+
+func TestDocsPageIsSelfContained(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handleDocs(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	page := rec.Body.String()
+	if !strings.Contains(page, `fetch("/openapi.json")`) {
+		t.Error("Docs page does not load /openapi.json")
+	}
+	for _, external := range []string{`src="http`, `href="http`, "@import", "url(http"} {
+		if strings.Contains(page, external) {
+			t.Errorf("Docs page loads external resources: %s", external)
+		}
+	}
+}
+
+func TestReadOutputFileStaysInOutputDir(t *testing.T) {
+	outputDir, outside := t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(outputDir, "letter.typ"), []byte("letter"), 0644)
+	os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644)
+	os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(outputDir, "link.typ"))
+
+	if content, err := readOutputFile(outputDir, "letter.typ"); err != nil || string(content) != "letter" {
+		t.Errorf("Failed to read a file in the output directory: %q, %v", content, err)
+	}
+	if _, err := readOutputFile(outputDir, filepath.Join(outputDir, "letter.typ")); err != nil {
+		t.Errorf("Failed to read an absolute path in the output directory: %v", err)
+	}
+	for _, name := range []string{"../secret.txt", filepath.Join(outside, "secret.txt"), "link.typ", "/etc/passwd"} {
+		if content, err := readOutputFile(outputDir, name); err == nil {
+			t.Errorf("Read %s outside the output directory: %q", name, content)
+		}
+	}
+
+	// The unversioned endpoints are restricted too, also when the path is a query parameter
+	outsidePath := filepath.Join(outside, "secret.txt")
+	requests := []struct {
+		handler http.HandlerFunc
+		target  string
+		body    string
+	}{
+		{handleParseCoverLetter(Config{OutputDir: outputDir}), "/parse-coverletter", `{"file_path": "` + outsidePath + `"}`},
+		{handleParseResume(Config{OutputDir: outputDir}), "/parse-resume", `{"file_path": "` + outsidePath + `", "source_format": "latex"}`},
+		{handleParseResume(Config{OutputDir: outputDir}), "/parse-resume", `{"file_path": "../secret.txt", "source_format": "latex"}`},
+		{handleParseResume(Config{OutputDir: outputDir}), "/parse-resume?source_format=latex&file_path=" + url.QueryEscape(outsidePath), ``},
+		{handleParseResume(Config{OutputDir: outputDir}), "/parse-resume?source_format=latex&file_path=" + url.QueryEscape("../secret.txt"), ``},
+	}
+	for _, req := range requests {
+		rec := httptest.NewRecorder()
+		req.handler(rec, httptest.NewRequest(http.MethodPost, req.target, strings.NewReader(req.body)))
+		if rec.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s %s: expected 422 for a file outside the output directory, got %d: %s", req.target, req.body, rec.Code, rec.Body)
+		}
+	}
+}
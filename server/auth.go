@@ -0,0 +1,135 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"cvcl-render/auth"
+)
+
+// scopeFor returns the scope a request path needs. Public paths need no credentials;
+// other paths without a scope accept any authenticated caller.
+func scopeFor(path string) (scope string, public bool) {
+	path = strings.TrimPrefix(path, apiV1Prefix)
+	switch {
+	case path == "/health" || strings.HasPrefix(path, "/health/"):
+		return "", true
+	case strings.HasPrefix(path, "/parse-"):
+		return auth.ScopeParse, false
+	case strings.HasPrefix(path, "/render"), path == "/preview", path == "/match-keywords", path == "/draft-coverletter":
+		return auth.ScopeRender, false
+	case strings.HasPrefix(path, "/admin/"):
+		return auth.ScopeAdmin, false
+	}
+	return "", false
+}
+
+// auditEntry is a line of the audit log
+type auditEntry struct {
+	Time       string   `json:"time"`
+	Principal  string   `json:"principal,omitempty"`
+	Method     string   `json:"auth_method,omitempty"`
+	Scopes     []string `json:"scopes,omitempty"`
+	Request    string   `json:"request"`
+	Status     int      `json:"status"`
+	DurationMS int64    `json:"duration_ms"`
+	Remote     string   `json:"remote"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// auditLog writes audit entries as JSON lines
+type auditLog struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (a *auditLog) write(entry auditEntry) {
+	if a.w == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	json.NewEncoder(a.w).Encode(entry)
+}
+
+// statusRecorder remembers the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// requireAuth authenticates requests to non-public paths and checks their scope.
+// Every authenticated or rejected request is written to the audit log.
+func requireAuth(next http.Handler, authenticator auth.Authenticator, audit io.Writer) http.Handler {
+	audits := &auditLog{w: audit}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope, public := scopeFor(r.URL.Path)
+		if public {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		entry := auditEntry{Time: start.UTC().Format(time.RFC3339), Request: r.Method + " " + r.URL.Path, Remote: r.RemoteAddr}
+		principal, err := authenticator.Authenticate(r)
+		switch {
+		case errors.Is(err, auth.ErrNoCredentials):
+			w.Header().Set("WWW-Authenticate", `Bearer realm="cvcl-render"`)
+			writeMiddlewareError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Authentication required: send an API key in X-API-Key or a bearer token")
+			entry.Status, entry.Error = http.StatusUnauthorized, err.Error()
+		case err != nil:
+			w.Header().Set("WWW-Authenticate", `Bearer realm="cvcl-render", error="invalid_token"`)
+			writeMiddlewareError(w, r, http.StatusUnauthorized, CodeUnauthorized, err.Error())
+			entry.Status, entry.Error = http.StatusUnauthorized, err.Error()
+		case scope != "" && !principal.Allows(scope):
+			writeMiddlewareError(w, r, http.StatusForbidden, CodeForbidden, fmt.Sprintf("The %s scope is required", scope))
+			entry.Status, entry.Error = http.StatusForbidden, "missing scope "+scope
+		default:
+			recorder := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(recorder, r.WithContext(auth.NewContext(r.Context(), principal)))
+			entry.Status = recorder.status
+		}
+		if principal != nil {
+			entry.Principal, entry.Method, entry.Scopes = principal.ID, principal.Method, principal.Scopes
+		}
+		entry.DurationMS = time.Since(start).Milliseconds()
+		audits.write(entry)
+	})
+}
+
+// writeMiddlewareError rejects a request before it reaches its handler, with the v1 error
+// envelope on /v1 paths and a RenderResponse on unversioned ones
+func writeMiddlewareError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	if strings.HasPrefix(r.URL.Path, apiV1Prefix+"/") {
+		writeAPIError(w, status, code, message)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(RenderResponse{Success: false, Error: message})
+}
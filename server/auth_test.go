@@ -0,0 +1,87 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cvcl-render/auth"
+	"cvcl-render/examples"
+	"cvcl-render/model"
+)
+
+func TestRequireAuth(t *testing.T) {
+	keys, err := auth.ParseAPIKeys("parser " + auth.HashKey("parse-key") + " parse\nrenderer " + auth.HashKey("render-key") + " render\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var audit bytes.Buffer
+	handler, err := NewMux(Config{
+		TemplatePath:  "templates/coverletter.typ.template",
+		OutputDir:     t.TempDir(),
+		SkipPDF:       true,
+		DecodeMode:    model.DecodeLenient,
+		Authenticator: keys,
+		AuditLog:      &audit,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method, path, key string
+		status            int
+		code              string
+	}{
+		{"GET", "/health", "", http.StatusOK, ""},
+		{"GET", "/v1/health", "", http.StatusOK, ""},
+		{"GET", "/health/ready", "", http.StatusOK, ""},
+		{"GET", "/health/live", "", http.StatusOK, ""},
+		{"POST", "/v1/render", "", http.StatusUnauthorized, CodeUnauthorized},
+		{"GET", "/v1/schema/resume.json", "", http.StatusUnauthorized, ""},
+		{"POST", "/v1/render", "wrong-key", http.StatusUnauthorized, CodeUnauthorized},
+		{"POST", "/v1/render", "parse-key", http.StatusForbidden, CodeForbidden},
+		{"POST", "/render", "parse-key", http.StatusForbidden, ""},
+		{"POST", "/v1/render", "render-key", http.StatusOK, ""},
+		{"GET", "/v1/schema/resume.json", "parse-key", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, bytes.NewReader(examples.CoverLetter))
+		if tt.key != "" {
+			req.Header.Set("X-API-Key", tt.key)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.status {
+			t.Errorf("%s %s with %q: status %d, want %d: %s", tt.method, tt.path, tt.key, rec.Code, tt.status, rec.Body)
+			continue
+		}
+		if tt.code != "" {
+			var resp ErrorResponse
+			if json.Unmarshal(rec.Body.Bytes(), &resp); resp.Error.Code != tt.code {
+				t.Errorf("%s %s with %q: code %q, want %q", tt.method, tt.path, tt.key, resp.Error.Code, tt.code)
+			}
+		}
+		if tt.status == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s %s: missing WWW-Authenticate header", tt.method, tt.path)
+		}
+	}
+
+	// Public paths are not audited, every other request is
+	lines := strings.Split(strings.TrimSpace(audit.String()), "\n")
+	if len(lines) != len(tests)-4 {
+		t.Fatalf("Expected %d audit lines, got %d:\n%s", len(tests)-4, len(lines), audit.String())
+	}
+	var entry auditEntry
+	json.Unmarshal([]byte(lines[5]), &entry)
+	if entry.Principal != "renderer" || entry.Method != auth.MethodAPIKey || entry.Request != "POST /v1/render" || entry.Status != http.StatusOK {
+		t.Errorf("Unexpected audit entry %+v", entry)
+	}
+	json.Unmarshal([]byte(lines[3]), &entry)
+	if entry.Principal != "parser" || entry.Status != http.StatusForbidden || entry.Error != "missing scope render" {
+		t.Errorf("Unexpected audit entry for a missing scope %+v", entry)
+	}
+}
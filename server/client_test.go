@@ -0,0 +1,85 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cvcl-render/client"
+	"cvcl-render/examples"
+	"cvcl-render/model"
+)
+
+// newTestServer runs the v1 handlers without compiling PDFs, so render endpoints return Typst sources
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	registerV1Routes(mux, v1Routes(Config{
+		TemplatePath:       "templates/coverletter.typ.template",
+		ResumeTemplatePath: "templates/resume.typ.template",
+		OutputDir:          t.TempDir(),
+		SkipPDF:            true,
+		DecodeMode:         model.DecodeStrict,
+	}))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClientAgainstServer(t *testing.T) {
+	c := client.New(newTestServer(t).URL)
+	ctx := context.Background()
+
+	health, err := c.Health(ctx)
+	if err != nil || health.Status != "ok" {
+		t.Fatalf("Health = %v, %v", health, err)
+	}
+
+	var letter bytes.Buffer
+	result, err := c.RenderCoverLetter(ctx, json.RawMessage(examples.CoverLetter), nil, &letter)
+	if err != nil {
+		t.Fatalf("RenderCoverLetter failed: %v", err)
+	}
+	if result.FileName != "Cover_Letter_John_Senior_Software_Engineer.typ" || result.Size != int64(letter.Len()) || !strings.Contains(letter.String(), "Senior Software Engineer") {
+		t.Errorf("Unexpected cover letter %+v", result)
+	}
+
+	// A resume rendered through the client parses back to the same content
+	resume := model.ResumeData{Author: model.Profile{FirstName: "Jane", LastName: "Doe", Email: "jane@example.com"}}
+	json.Unmarshal(json.RawMessage(examples.Resume), &resume)
+	var source bytes.Buffer
+	if _, err := c.RenderResume(ctx, resume, &client.RenderOptions{Language: "sv"}, &source); err != nil {
+		t.Fatalf("RenderResume failed: %v", err)
+	}
+	parsed, err := c.ParseResume(ctx, client.ParseResumeRequest{Source: source.String()})
+	if err != nil {
+		t.Fatalf("ParseResume failed: %v", err)
+	}
+	var parsedResume model.ResumeData
+	if err := json.Unmarshal(parsed.Resume, &parsedResume); err != nil {
+		t.Fatalf("Failed to decode parsed resume: %v", err)
+	}
+	if parsedResume.Author.Name() != "Jane Doe" || parsedResume.Summary != resume.Summary || parsedResume.Options == nil || parsedResume.Options.Language != "sv" {
+		t.Errorf("Unexpected parsed resume %+v", parsedResume)
+	}
+
+	// Validation errors keep their field details
+	strict := true
+	_, err = c.RenderCoverLetter(ctx, map[string]string{"position": "Engineer", "nickname": "JD"}, &client.RenderOptions{Strict: &strict}, &bytes.Buffer{})
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != CodeValidationFailed || len(apiErr.Details) == 0 {
+		t.Fatalf("Expected a validation error, got %v", err)
+	}
+	found := false
+	for _, detail := range apiErr.Details {
+		found = found || detail.Field == "nickname"
+	}
+	if !found {
+		t.Errorf("Expected an error for the unknown field, got %v", apiErr.Details)
+	}
+}
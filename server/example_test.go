@@ -0,0 +1,22 @@
+package server_test
+
+import (
+	"log"
+	"net/http"
+
+	"cvcl-render/model"
+	"cvcl-render/server"
+)
+
+func ExampleNewMux() {
+	mux, err := server.NewMux(server.Config{
+		TemplatePath:       "templates/coverletter.typ.template",
+		ResumeTemplatePath: "templates/resume.typ.template",
+		OutputDir:          "output",
+		DecodeMode:         model.DecodeStrict,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Fatal(http.ListenAndServe(":8080", mux))
+}
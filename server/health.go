@@ -0,0 +1,194 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"cvcl-render/compile"
+)
+
+// Check statuses reported by /health/ready
+const (
+	CheckOK     = "ok"
+	CheckFailed = "failed"
+)
+
+// Check is a named readiness check. Run returns a short detail, such as a version, on success.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) (string, error)
+}
+
+// CheckResult is the outcome of a Check
+type CheckResult struct {
+	Name string `json:"name"`
+	// Status is ok or failed
+	Status     string `json:"status"`
+	Detail     string `json:"detail,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// Health tracks whether the server should receive new requests: it is not ready
+// while draining or while one of its checks fails
+type Health struct {
+	Checks []Check
+	// TTL is how long check results are reused; zero runs the checks on every request
+	TTL time.Duration
+	// Timeout bounds a run of all checks; zero means no timeout
+	Timeout time.Duration
+
+	draining  atomic.Bool
+	mu        sync.Mutex
+	checkedAt time.Time
+	results   []CheckResult
+}
+
+// SetDraining marks the server as shutting down, failing /health/ready
+func (h *Health) SetDraining() {
+	h.draining.Store(true)
+}
+
+// Draining reports whether the server is shutting down
+func (h *Health) Draining() bool {
+	return h != nil && h.draining.Load()
+}
+
+// Run returns the results of the checks and when they ran, reusing results younger than TTL.
+// Concurrent callers wait for a single run. The checks do not stop with the request that started
+// them, which would fail them for every caller until the results expire; only Timeout bounds them.
+func (h *Health) Run() ([]CheckResult, time.Time) {
+	if h == nil || len(h.Checks) == 0 {
+		return nil, time.Time{}
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.results != nil && time.Since(h.checkedAt) < h.TTL {
+		return h.results, h.checkedAt
+	}
+
+	ctx := context.Background()
+	if h.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.Timeout)
+		defer cancel()
+	}
+	results := make([]CheckResult, len(h.Checks))
+	canceled := false
+	for i, check := range h.Checks {
+		start := time.Now()
+		detail, err := check.Run(ctx)
+		results[i] = CheckResult{Name: check.Name, Status: CheckOK, Detail: detail, DurationMS: time.Since(start).Milliseconds()}
+		if err != nil {
+			results[i].Status = CheckFailed
+			results[i].Error = err.Error()
+			canceled = canceled || errors.Is(err, context.Canceled)
+		}
+	}
+	checkedAt := time.Now()
+	// Canceled checks say nothing about the server, so the next caller runs them again
+	if !canceled {
+		h.results, h.checkedAt = results, checkedAt
+	}
+	return results, checkedAt
+}
+
+// handleReady handles the /health/ready GET endpoint, which fails while the server drains
+// or one of the checks fails
+func handleReady(health *Health) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := HealthResponse{Status: "ok"}
+		results, checkedAt := health.Run()
+		resp.Checks = results
+		if !checkedAt.IsZero() {
+			resp.CheckedAt = checkedAt.UTC().Format(time.RFC3339)
+		}
+		for _, result := range results {
+			if result.Status != CheckOK {
+				resp.Status = "unavailable"
+			}
+		}
+		if health.Draining() {
+			resp.Status = "draining"
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if resp.Status != "ok" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// TypstChecks returns checks for the typst version, the installation of the packages and the fonts
+// the templates need, and optionally a canary compilation importing the packages.
+// The package checks only find the installed package directories; the canary also imports them.
+func TypstChecks(packages, fonts []string, canary bool) []Check {
+	checks := []Check{{
+		Name: "typst",
+		Run:  compile.Version,
+	}}
+	for _, spec := range packages {
+		spec := spec
+		checks = append(checks, Check{
+			Name: "package " + spec + " installed",
+			Run: func(context.Context) (string, error) {
+				return compile.FindPackage(spec)
+			},
+		})
+	}
+	if len(fonts) > 0 {
+		checks = append(checks, Check{Name: "fonts", Run: func(ctx context.Context) (string, error) {
+			return checkFonts(ctx, fonts)
+		}})
+	}
+	if canary {
+		checks = append(checks, Check{Name: "canary", Run: func(ctx context.Context) (string, error) {
+			return compileCanary(ctx, packages)
+		}})
+	}
+	return checks
+}
+
+// checkFonts reports the required font families that typst does not find
+func checkFonts(ctx context.Context, required []string) (string, error) {
+	families, err := compile.Fonts(ctx)
+	if err != nil {
+		return "", err
+	}
+	available := make(map[string]bool)
+	for _, family := range families {
+		available[strings.ToLower(family)] = true
+	}
+	var missing []string
+	for _, family := range required {
+		if !available[strings.ToLower(family)] {
+			missing = append(missing, family)
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("missing fonts: %s", strings.Join(missing, ", "))
+	}
+	return fmt.Sprintf("%d families available", len(families)), nil
+}
+
+// compileCanary compiles a one-line document importing the packages, bypassing the cache
+func compileCanary(ctx context.Context, packages []string) (string, error) {
+	var source strings.Builder
+	for _, spec := range packages {
+		fmt.Fprintf(&source, "#import %q: *\n", spec)
+	}
+	source.WriteString("#set page(width: 4cm, height: 2cm)\nHealth check\n")
+	result, err := compile.SourceContext(ctx, source.String(), compile.Options{Format: compile.FormatPDF, NoCache: true})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d page", result.PageCount), nil
+}
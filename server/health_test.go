@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReadinessReflectsDrain(t *testing.T) {
+	health := &Health{}
+	handler, err := NewMux(Config{Health: health})
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(path string) (int, string) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var resp HealthResponse
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec.Code, resp.Status
+	}
+
+	if code, status := get("/health/ready"); code != http.StatusOK || status != "ok" {
+		t.Errorf("Ready before drain: %d %q", code, status)
+	}
+	health.SetDraining()
+	if code, status := get("/health/ready"); code != http.StatusServiceUnavailable || status != "draining" {
+		t.Errorf("Ready while draining: %d %q", code, status)
+	}
+	if code, status := get("/health/live"); code != http.StatusOK || status != "ok" {
+		t.Errorf("Live while draining: %d %q", code, status)
+	}
+}
+
+func TestReadinessChecks(t *testing.T) {
+	runs := 0
+	failing := false
+	health := &Health{TTL: time.Hour, Checks: []Check{
+		{Name: "typst", Run: func(context.Context) (string, error) {
+			runs++
+			return "typst 0.11.0", nil
+		}},
+		{Name: "fonts", Run: func(context.Context) (string, error) {
+			if failing {
+				return "", errors.New("missing fonts: Roboto")
+			}
+			return "2 families available", nil
+		}},
+	}}
+	handler := handleReady(health)
+	get := func() (int, HealthResponse) {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+		var resp HealthResponse
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec.Code, resp
+	}
+
+	code, resp := get()
+	if code != http.StatusOK || resp.Status != "ok" || len(resp.Checks) != 2 || resp.Checks[0].Detail != "typst 0.11.0" || resp.CheckedAt == "" {
+		t.Errorf("Unexpected response %d: %+v", code, resp)
+	}
+	failing = true
+	if get(); runs != 1 {
+		t.Errorf("Expected cached results, checks ran %d times", runs)
+	}
+
+	health.TTL = 0
+	code, resp = get()
+	if code != http.StatusServiceUnavailable || resp.Status != "unavailable" {
+		t.Errorf("Expected a failed readiness check, got %d: %+v", code, resp)
+	}
+	if fonts := resp.Checks[1]; fonts.Status != CheckFailed || fonts.Error != "missing fonts: Roboto" || resp.Checks[0].Status != CheckOK {
+		t.Errorf("Unexpected check results %+v", resp.Checks)
+	}
+}
+
+func TestReadinessOutlivesCanceledRequests(t *testing.T) {
+	runs := 0
+	health := &Health{TTL: time.Hour, Timeout: time.Second, Checks: []Check{
+		{Name: "canary", Run: func(ctx context.Context) (string, error) {
+			runs++
+			if runs == 1 {
+				return "", fmt.Errorf("failed to compile Typst file: %w", context.Canceled)
+			}
+			select {
+			case <-ctx.Done():
+				return "", ctx.Err()
+			case <-time.After(20 * time.Millisecond):
+				return "1 page", nil
+			}
+		}},
+	}}
+	handler := handleReady(health)
+	get := func(ctx context.Context) int {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, "/health/ready", nil).WithContext(ctx))
+		return rec.Code
+	}
+
+	// Canceled checks are not cached
+	if code := get(context.Background()); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected the canceled check to fail, got %d", code)
+	}
+
+	// A probe that gives up does not stop the checks
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if code := get(ctx); code != http.StatusOK || runs != 2 {
+		t.Errorf("Expected the checks to run again for a canceled probe, got %d after %d runs", code, runs)
+	}
+	if code := get(context.Background()); code != http.StatusOK || runs != 2 {
+		t.Errorf("Expected cached results, got %d after %d runs", code, runs)
+	}
+}
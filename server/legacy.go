@@ -0,0 +1,331 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"cvcl-render/keywords"
+	"cvcl-render/model"
+)
+
+// The unversioned endpoints share their operations with the v1 API and differ in their
+// envelopes: they answer with "success" flags and report errors as a RenderResponse.
+
+// RenderResponse is the JSON response for the render endpoint
+type RenderResponse struct {
+	Success   bool               `json:"success"`
+	Message   string             `json:"message"`
+	TypstFile string             `json:"typst_file,omitempty"`
+	PDFFile   string             `json:"pdf_file,omitempty"`
+	Error     string             `json:"error,omitempty"`
+	Errors    []model.FieldError `json:"errors,omitempty"`
+	Warnings  []string           `json:"warnings,omitempty"`
+}
+
+// requirePost answers 405 Method Not Allowed to requests other than POST, and reports whether
+// the request may proceed
+func requirePost(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method == http.MethodPost {
+		return true
+	}
+	writeLegacyJSON(w, http.StatusMethodNotAllowed, RenderResponse{
+		Success: false,
+		Error:   "Method not allowed. Please use POST.",
+	})
+	return false
+}
+
+// writeLegacyJSON sends an unversioned JSON response
+func writeLegacyJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeLegacyError answers a failed request with a RenderResponse. Validation errors are
+// reported with the given message, naming what was invalid.
+func writeLegacyError(w http.ResponseWriter, err error, invalidMessage string) {
+	reqErr := asRequestError(err)
+	message := reqErr.Message
+	if reqErr.Code == CodeValidationFailed {
+		message = invalidMessage
+	}
+	writeLegacyJSON(w, reqErr.Status, RenderResponse{
+		Success:  false,
+		Error:    message,
+		Errors:   reqErr.Details,
+		Warnings: reqErr.Warnings,
+	})
+}
+
+// handleRender handles the /render POST endpoint
+func handleRender(cfg Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requirePost(w, r) {
+			return
+		}
+		format, err := documentFormat(r.URL.Query())
+		if err != nil {
+			writeLegacyError(w, err, "")
+			return
+		}
+		var data model.CoverLetterData
+		warnings, err := decodeRequest(r, &data, cfg.DecodeMode)
+		if err != nil {
+			writeLegacyError(w, err, "Invalid cover letter data")
+			return
+		}
+
+		file, err := renderCoverLetter(cfg, data, format)
+		if err != nil {
+			writeLegacyError(w, err, "")
+			return
+		}
+		writeFileResult(w, file, warnings)
+	}
+}
+
+// handleSchema handles the /schema/ GET endpoint, serving the published JSON Schemas
+func handleSchema(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/schema/"), ".json")
+	schema, err := model.Schema(name)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   fmt.Sprintf("Unknown schema: %s", name),
+		})
+		return
+	}
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Write(schema)
+}
+
+// handleHealth handles the /health GET endpoint
+func handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// handleRenderResume handles the /render-resume POST endpoint
+func handleRenderResume(cfg Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requirePost(w, r) {
+			return
+		}
+		format, err := documentFormat(r.URL.Query())
+		if err != nil {
+			writeLegacyError(w, err, "")
+			return
+		}
+		var data model.ResumeData
+		warnings, err := decodeRequest(r, &data, cfg.DecodeMode)
+		if err != nil {
+			writeLegacyError(w, err, "Invalid resume data")
+			return
+		}
+
+		file, err := renderResume(cfg, data, format)
+		if err != nil {
+			writeLegacyError(w, err, "")
+			return
+		}
+		writeFileResult(w, file, warnings)
+	}
+}
+
+// handleMatchKeywords handles the /match-keywords POST endpoint, comparing a job description with an application
+func handleMatchKeywords(cfg Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requirePost(w, r) {
+			return
+		}
+		var req model.KeywordMatchRequest
+		warnings, err := decodeRequest(r, &req, cfg.DecodeMode)
+		if err != nil {
+			writeLegacyError(w, err, "Invalid keyword match request")
+			return
+		}
+
+		report := keywords.Compare(req.JobDescription, req.Resume, req.CoverLetter)
+		writeLegacyJSON(w, http.StatusOK, map[string]interface{}{
+			"success":  true,
+			"report":   report,
+			"warnings": warnings,
+		})
+	}
+}
+
+// handleDraftCoverLetter handles the /draft-coverletter POST endpoint, drafting a cover letter from a resume and a job description
+func handleDraftCoverLetter(cfg Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requirePost(w, r) {
+			return
+		}
+		var req model.DraftRequest
+		warnings, err := decodeRequest(r, &req, cfg.DecodeMode)
+		if err != nil {
+			writeLegacyError(w, err, "Invalid draft request")
+			return
+		}
+
+		letter, generatorName, draftWarnings, err := draftCoverLetter(r.Context(), cfg.Generator, req)
+		if err != nil {
+			writeLegacyError(w, err, "")
+			return
+		}
+		writeLegacyJSON(w, http.StatusOK, map[string]interface{}{
+			"success":      true,
+			"cover_letter": letter,
+			"generator":    generatorName,
+			"warnings":     append(warnings, draftWarnings...),
+		})
+	}
+}
+
+// handleParseCoverLetter handles cover letter parsing requests. The cover letter is given as
+// Typst source or as a file in the output directory.
+func handleParseCoverLetter(cfg Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requirePost(w, r) {
+			return
+		}
+
+		// Read the Typst source or file path from query parameter or request body
+		var req ParseCoverLetterRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err := bodyTooLarge(err); err != nil {
+			writeLegacyError(w, err, "")
+			return
+		}
+		if err != nil {
+			req.FilePath = r.URL.Query().Get("file_path")
+		}
+		if err := invalidRequest(req.Validate(), nil); err != nil {
+			writeLegacyError(w, err, "Invalid parse request")
+			return
+		}
+
+		coverLetter, err := parseCoverLetter(cfg, req)
+		if err != nil {
+			writeLegacyError(w, err, "")
+			return
+		}
+		writeLegacyJSON(w, http.StatusOK, map[string]interface{}{
+			"success":      true,
+			"cover_letter": coverLetter,
+		})
+	}
+}
+
+// handleParseResume handles resume parsing requests. The resume is given as source or as a file
+// in the output directory.
+func handleParseResume(cfg Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requirePost(w, r) {
+			return
+		}
+
+		// Read the source or file path and source format from query parameter or request body
+		var req ParseResumeRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err := bodyTooLarge(err); err != nil {
+			writeLegacyError(w, err, "")
+			return
+		}
+		if err != nil {
+			req.FilePath = r.URL.Query().Get("file_path")
+		}
+		if req.SourceFormat == "" {
+			req.SourceFormat = r.URL.Query().Get("source_format")
+		}
+		if err := invalidRequest(req.Validate(), nil); err != nil {
+			writeLegacyError(w, err, "Invalid parse request")
+			return
+		}
+
+		resume, err := parseResume(cfg, req)
+		if err != nil {
+			writeLegacyError(w, err, "")
+			return
+		}
+		if resume.Imported != nil {
+			writeLegacyJSON(w, http.StatusOK, map[string]interface{}{
+				"success":      true,
+				"resume":       resume.Imported.Resume,
+				"class":        resume.Imported.Class,
+				"unrecognized": resume.Imported.Unrecognized,
+			})
+			return
+		}
+		writeLegacyJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"resume":  resume.Typst,
+		})
+	}
+}
+
+// handlePreview handles the /preview POST endpoint, returning page images of a cover letter or resume.
+// Query parameters: document (coverletter or resume), format (png or svg), ppi, page (1-based).
+// Without page, all pages are returned as a zip archive.
+func handlePreview(cfg Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requirePost(w, r) {
+			return
+		}
+		query := r.URL.Query()
+		opts, page, err := previewParams(query)
+		if err != nil {
+			writeLegacyError(w, err, "")
+			return
+		}
+		document, err := previewDocument(query)
+		if err != nil {
+			writeLegacyError(w, err, "")
+			return
+		}
+		warnings, err := decodeRequest(r, document, cfg.DecodeMode)
+		if err != nil {
+			writeLegacyError(w, err, "Invalid document data")
+			return
+		}
+
+		file, err := previewPages(cfg, document, opts, page)
+		if err != nil {
+			writeLegacyError(w, err, "")
+			return
+		}
+		writeFileResult(w, file, warnings)
+	}
+}
+
+// handleRenderApplication handles the /render-application POST endpoint, rendering a cover letter
+// and a resume with a shared author into one zip or multipart response
+func handleRenderApplication(cfg Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requirePost(w, r) {
+			return
+		}
+		format, merge, err := applicationParams(r.URL.Query())
+		if err != nil {
+			writeLegacyError(w, err, "")
+			return
+		}
+		var req model.ApplicationRequest
+		warnings, err := decodeRequest(r, &req, cfg.DecodeMode)
+		if err != nil {
+			writeLegacyError(w, err, "Invalid application data")
+			return
+		}
+
+		file, err := renderApplication(cfg, req, format, merge)
+		if err != nil {
+			writeLegacyError(w, err, "")
+			return
+		}
+		writeFileResult(w, file, warnings)
+	}
+}
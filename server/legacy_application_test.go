@@ -0,0 +1,92 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"cvcl-render/compile"
+	"cvcl-render/model"
+	"cvcl-render/render"
+)
+
+const sampleApplication = `{
+	"author": {"firstname": "Jane", "lastname": "Doe", "email": "jane@example.com"},
+	"company": "Acme Sensors AB",
+	"resume": {"positions": ["Embedded developer"], "summary": "Firmware engineer."},
+	"cover_letter": {"position": "Firmware Engineer", "addressee": "Hiring Manager", "opening": "Hello."}
+}`
+
+func TestHandleRenderApplication(t *testing.T) {
+	handler := handleRenderApplication(Config{TemplatePath: "templates/coverletter.typ.template", ResumeTemplatePath: "templates/resume.typ.template", SkipPDF: true, DecodeMode: model.DecodeStrict})
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodPost, "/render-application", strings.NewReader(sampleApplication)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Unexpected status %d: %s", rec.Code, rec.Body.String())
+	}
+	if disposition := rec.Header().Get("Content-Disposition"); !strings.Contains(disposition, "Acme_Sensors_AB_Jane_Doe_Application.zip") {
+		t.Errorf("Unexpected Content-Disposition %q", disposition)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatalf("Failed to read zip: %v", err)
+	}
+	var names []string
+	for _, f := range archive.File {
+		names = append(names, f.Name)
+	}
+	want := []string{"Acme_Sensors_AB_Jane_Doe_Cover_Letter.typ", "Acme_Sensors_AB_Jane_Doe_Resume.typ"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("Files = %v, want %v", names, want)
+	}
+
+	rec = httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodPost, "/render-application?format=multipart", strings.NewReader(sampleApplication)))
+	mediaType, params, err := mime.ParseMediaType(rec.Header().Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("Unexpected Content-Type %q", rec.Header().Get("Content-Type"))
+	}
+	reader := multipart.NewReader(rec.Body, params["boundary"])
+	for _, name := range want {
+		part, err := reader.NextPart()
+		if err != nil {
+			t.Fatalf("Failed to read part %s: %v", name, err)
+		}
+		content, _ := io.ReadAll(part)
+		if part.FileName() != name || !bytes.Contains(content, []byte("Jane")) {
+			t.Errorf("Unexpected part %q", part.FileName())
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodPost, "/render-application?format=tar", strings.NewReader(sampleApplication)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown format, got %d", rec.Code)
+	}
+}
+
+func TestRenderApplicationMergeRequiresTypstVersion(t *testing.T) {
+	binary := filepath.Join(t.TempDir(), "fake-typst")
+	if err := os.WriteFile(binary, []byte("#!/bin/sh\necho 'typst 0.11.0 (2bf9f95d)'\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	compile.Configure(compile.Settings{Binary: binary})
+	t.Cleanup(func() { compile.Configure(compile.Settings{}) })
+
+	cfg := Config{TemplatePath: "templates/coverletter.typ.template", ResumeTemplatePath: "templates/resume.typ.template", DecodeMode: model.DecodeStrict}
+	rec := httptest.NewRecorder()
+	handleV1RenderApplication(cfg)(rec, httptest.NewRequest(http.MethodPost, "/v1/render-application?merge=true", strings.NewReader(sampleApplication)))
+	if rec.Code != http.StatusNotImplemented || !strings.Contains(rec.Body.String(), "merge requires typst "+render.MergeTypstVersion) {
+		t.Errorf("Expected 501 for merging with typst 0.11, got %d: %s", rec.Code, rec.Body)
+	}
+}
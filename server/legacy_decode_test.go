@@ -0,0 +1,54 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cvcl-render/model"
+)
+
+func TestRequestDecodeModeOverridesServerDefault(t *testing.T) {
+	cases := []struct {
+		query    string
+		server   model.DecodeMode
+		expected model.DecodeMode
+	}{
+		{"", model.DecodeStrict, model.DecodeStrict},
+		{"?strict=false", model.DecodeStrict, model.DecodeLenient},
+		{"?strict=true", model.DecodeLenient, model.DecodeStrict},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/render"+tc.query, nil)
+		mode, err := requestDecodeMode(req, tc.server)
+		if err != nil || mode != tc.expected {
+			t.Errorf("%q with server mode %s: expected %s, got %s (%v)", tc.query, tc.server, tc.expected, mode, err)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/render?strict=maybe", nil)
+	if _, err := requestDecodeMode(req, model.DecodeLenient); err == nil {
+		t.Error("Expected error for invalid strict parameter")
+	}
+}
+
+func TestRenderStrictModeResponse(t *testing.T) {
+	body := `{"first_name": "John", "last_name": "Doe", "email": "john@doe.org", "position": "Engineer",
+		"addressee": "Hiring Manager", "opening": "Hi", "linkedin_url": "john"}`
+	req := httptest.NewRequest(http.MethodPost, "/render?strict=true", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	handleRender(Config{TemplatePath: "templates/coverletter.typ.template", OutputDir: t.TempDir(), SkipPDF: true, DecodeMode: model.DecodeLenient})(rec, req)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status 422, got %d", rec.Code)
+	}
+	var resp RenderResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(resp.Errors) != 1 || resp.Errors[0].Field != "linkedin_url" {
+		t.Errorf("Expected unknown field error for linkedin_url, got %v", resp.Errors)
+	}
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cvcl-render/model"
+)
+
+func TestHandleDraftCoverLetter(t *testing.T) {
+	body := `{"resume": {"author": {"firstname": "Jane", "lastname": "Doe", "email": "jane@example.com"}, "positions": ["Engineer"]},
+		"job_description": "We need Go and Kubernetes.", "options": {"tone": "chatty"}}`
+	rec := httptest.NewRecorder()
+	handleDraftCoverLetter(Config{DecodeMode: model.DecodeLenient})(rec, httptest.NewRequest(http.MethodPost, "/draft-coverletter", strings.NewReader(body)))
+	if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), "options.tone") {
+		t.Fatalf("Expected a validation error for the tone, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	body = strings.Replace(body, "chatty", "formal", 1)
+	handleDraftCoverLetter(Config{DecodeMode: model.DecodeLenient})(rec, httptest.NewRequest(http.MethodPost, "/draft-coverletter", strings.NewReader(body)))
+	var resp struct {
+		Success     bool                  `json:"success"`
+		Generator   string                `json:"generator"`
+		CoverLetter model.CoverLetterData `json:"cover_letter"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || !resp.Success {
+		t.Fatalf("Unexpected response %d: %v", rec.Code, err)
+	}
+	if resp.Generator != "template" || resp.CoverLetter.Addressee != "Hiring Manager" {
+		t.Errorf("Unexpected draft: %+v", resp)
+	}
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"cvcl-render/keywords"
+	"cvcl-render/model"
+)
+
+func TestHandleMatchKeywords(t *testing.T) {
+	body := `{"job_description": "Golang and Docker, Golang again", "resume": {"skills": [{"name": "Backend", "skills": [{"name": "Go", "strong": false}]}]}}`
+	rec := httptest.NewRecorder()
+	handleMatchKeywords(Config{DecodeMode: model.DecodeLenient})(rec, httptest.NewRequest(http.MethodPost, "/match-keywords", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Status = %d, body %s", rec.Code, rec.Body)
+	}
+	var response struct {
+		Success bool            `json:"success"`
+		Report  keywords.Report `json:"report"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Invalid response: %v", err)
+	}
+	if !response.Success || !reflect.DeepEqual(response.Report.Missing, []string{"Docker"}) || response.Report.Coverage != 0.5 {
+		t.Errorf("Unexpected report: %+v", response.Report)
+	}
+
+	rec = httptest.NewRecorder()
+	handleMatchKeywords(Config{DecodeMode: model.DecodeLenient})(rec, httptest.NewRequest(http.MethodPost, "/match-keywords", strings.NewReader(`{"job_description": "Go"}`)))
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 without a resume or cover letter, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cvcl-render/examples"
+	"cvcl-render/model"
+)
+
+func TestRenderRejectsInvalidData(t *testing.T) {
+	body := `{"first_name": "John", "email": "john@", "opening": "Hi"}`
+	req := httptest.NewRequest(http.MethodPost, "/render", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	handleRender(Config{TemplatePath: "templates/coverletter.typ.template", OutputDir: t.TempDir(), SkipPDF: true, DecodeMode: model.DecodeLenient})(rec, req)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status 422, got %d", rec.Code)
+	}
+	var resp RenderResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(resp.Errors) != 4 {
+		t.Errorf("Expected 4 field errors (last_name, email, position, addressee), got %v", resp.Errors)
+	}
+}
+
+func TestLegacyAndV1ShareOperations(t *testing.T) {
+	cfg := Config{
+		TemplatePath:       "templates/coverletter.typ.template",
+		ResumeTemplatePath: "templates/resume.typ.template",
+		OutputDir:          t.TempDir(),
+		SkipPDF:            true,
+		DecodeMode:         model.DecodeLenient,
+	}
+	cases := []struct {
+		legacy, v1 http.HandlerFunc
+		target     string
+		body       string
+		status     int
+	}{
+		{handleRender(cfg), handleV1Render(cfg), "/render", string(examples.CoverLetter), http.StatusOK},
+		{handleParseResume(cfg), handleV1ParseResume(cfg), "/parse-resume", `{"source": "= CV", "source_format": "word"}`, http.StatusUnprocessableEntity},
+		{handleParseResume(cfg), handleV1ParseResume(cfg), "/parse-resume", `{"file_path": "missing.typ"}`, http.StatusUnprocessableEntity},
+		{handlePreview(cfg), handleV1Preview(cfg), "/preview?document=letter", `{}`, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		legacy, v1 := httptest.NewRecorder(), httptest.NewRecorder()
+		tc.legacy(legacy, httptest.NewRequest(http.MethodPost, tc.target, strings.NewReader(tc.body)))
+		tc.v1(v1, httptest.NewRequest(http.MethodPost, "/v1"+tc.target, strings.NewReader(tc.body)))
+		if legacy.Code != tc.status || v1.Code != tc.status {
+			t.Errorf("%s %s: expected %d from both APIs, got %d (legacy) and %d (v1)", tc.target, tc.body, tc.status, legacy.Code, v1.Code)
+		}
+		if tc.status == http.StatusOK && legacy.Body.String() != v1.Body.String() {
+			t.Errorf("%s: the APIs render different files", tc.target)
+		}
+	}
+}
@@ -0,0 +1,442 @@
+package server
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"cvcl-render/auth"
+	"cvcl-render/compile"
+	"cvcl-render/examples"
+	"cvcl-render/latex"
+	"cvcl-render/model"
+	"cvcl-render/render"
+	"cvcl-render/typstparse"
+)
+
+//go:embed docs/index.html
+var embeddedDocs []byte
+
+// openAPIVersion is the version of the generated document. OpenAPI 3.1 uses JSON Schema
+// draft 2020-12, so the published document schemas are included unchanged.
+const openAPIVersion = "3.1.0"
+
+// documentComponents maps the document types to the components holding their published JSON Schemas
+var documentComponents = map[reflect.Type]struct{ Component, Schema string }{
+	reflect.TypeOf(model.CoverLetterData{}): {"CoverLetterDocument", "coverletter"},
+	reflect.TypeOf(model.ResumeData{}):      {"ResumeDocument", "resume"},
+}
+
+// responseHeaders describes the X- headers of file responses and the headers of rate limited responses
+var responseHeaders = map[string]string{
+	"X-Warnings":          "JSON array of decode warnings",
+	"X-Fit-Report":        "JSON report of how the resume was fitted to max_pages",
+	"X-Page-Count":        "Number of pages of the document",
+	"RateLimit-Limit":     "Requests allowed by the limit closest to exhaustion",
+	"RateLimit-Remaining": "Requests left under that limit",
+	"RateLimit-Reset":     "Seconds until that limit is fully available again",
+	"RateLimit-Policy":    "The rate limit and daily render quota, as <requests>;w=<seconds>",
+	"Retry-After":         "Seconds to wait before retrying",
+}
+
+// rateLimitHeaders are set on responses of rate limited endpoints when limits are enabled
+var rateLimitHeaders = []string{"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy"}
+
+// openAPISchemas collects the component schemas of an OpenAPI document
+type openAPISchemas map[string]interface{}
+
+// schemaRef returns a reference to a component schema
+func schemaRef(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+// schemaFor returns the schema of the JSON encoding of typ, adding named structs as components
+func (s openAPISchemas) schemaFor(typ reflect.Type) (map[string]interface{}, error) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if document, ok := documentComponents[typ]; ok {
+		if _, ok := s[document.Component]; !ok {
+			schema, err := model.Schema(document.Schema)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s schema: %w", document.Schema, err)
+			}
+			s[document.Component] = json.RawMessage(schema)
+		}
+		return schemaRef(document.Component), nil
+	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}, nil
+	case reflect.Interface:
+		return map[string]interface{}{}, nil
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}, nil
+		}
+		items, err := s.schemaFor(typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "array", "items": items}, nil
+	case reflect.Map:
+		values, err := s.schemaFor(typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "object", "additionalProperties": values}, nil
+	case reflect.Struct:
+		if _, ok := s[typ.Name()]; ok {
+			return schemaRef(typ.Name()), nil
+		}
+		s[typ.Name()] = nil // placeholder for recursive types
+		properties := make(map[string]interface{})
+		var required []string
+		if err := s.addProperties(typ, properties, &required); err != nil {
+			return nil, err
+		}
+		schema := map[string]interface{}{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		s[typ.Name()] = schema
+		return schemaRef(typ.Name()), nil
+	}
+	return nil, fmt.Errorf("no schema for %s", typ)
+}
+
+// partialDocumentSchema returns a document schema without its required fields, for documents
+// that are completed by the server such as those of an application with a shared author
+func (s openAPISchemas) partialDocumentSchema(typ reflect.Type) (map[string]interface{}, error) {
+	document, ok := documentComponents[typ]
+	if !ok {
+		return nil, fmt.Errorf("%s is not a document", typ)
+	}
+	name := "Partial" + document.Component
+	if _, ok := s[name]; !ok {
+		raw, err := model.Schema(document.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s schema: %w", document.Schema, err)
+		}
+		var schema map[string]interface{}
+		if err := json.Unmarshal(raw, &schema); err != nil {
+			return nil, fmt.Errorf("failed to parse %s schema: %w", document.Schema, err)
+		}
+		delete(schema, "required")
+		delete(schema, "allOf")
+		// Each schema resource needs its own $id; references to $defs resolve against it
+		schema["$id"] = strings.Replace(schema["$id"].(string), ".schema.json", ".partial.schema.json", 1)
+		schema["title"] = fmt.Sprintf("%s (partial)", schema["title"])
+		s[name] = schema
+	}
+	return schemaRef(name), nil
+}
+
+// addProperties adds the JSON fields of a struct, including those of embedded structs.
+// Fields without omitempty are required.
+func (s openAPISchemas) addProperties(typ reflect.Type, properties map[string]interface{}, required *[]string) error {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			if err := s.addProperties(field.Type, properties, required); err != nil {
+				return err
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		var schema map[string]interface{}
+		var err error
+		if field.Tag.Get("openapi") == "partial" {
+			schema, err = s.partialDocumentSchema(field.Type)
+		} else {
+			schema, err = s.schemaFor(field.Type)
+		}
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", typ.Name(), field.Name, err)
+		}
+		properties[name] = schema
+		if !strings.Contains(options, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+	return nil
+}
+
+// operation returns the OpenAPI operation object of a route, with its security requirements
+// when authentication is enabled
+func (s openAPISchemas) operation(route apiRoute, authEnabled bool) (map[string]interface{}, error) {
+	var parameters []interface{}
+	if i := strings.Index(route.Path, "{"); i >= 0 {
+		parameters = append(parameters, map[string]interface{}{
+			"name": strings.Trim(route.Path[i:], "{}"), "in": "path", "required": true,
+			"schema": map[string]interface{}{"type": "string"},
+		})
+	}
+	for _, param := range route.Query {
+		schema := map[string]interface{}{"type": param.Type}
+		if len(param.Enum) > 0 {
+			schema["enum"] = param.Enum
+		}
+		parameter := map[string]interface{}{"name": param.Name, "in": "query", "schema": schema}
+		if param.Description != "" {
+			parameter["description"] = param.Description
+		}
+		parameters = append(parameters, parameter)
+	}
+
+	success := map[string]interface{}{"description": "Success"}
+	if route.Response != nil {
+		schema, err := s.schemaFor(reflect.TypeOf(route.Response))
+		if err != nil {
+			return nil, err
+		}
+		success["content"] = map[string]interface{}{"application/json": mediaType(schema, route.ResponseExample)}
+	}
+	if len(route.Files) > 0 {
+		content := make(map[string]interface{})
+		for _, contentType := range route.Files {
+			content[contentType] = map[string]interface{}{"schema": map[string]interface{}{"type": "string", "format": "binary"}}
+		}
+		success["content"] = content
+	}
+	headerNames := route.Headers
+	limited, _ := limitedPath(route.Path)
+	if limited {
+		headerNames = append(headerNames[:len(headerNames):len(headerNames)], rateLimitHeaders...)
+	}
+	if len(headerNames) > 0 {
+		success["headers"] = describeHeaders(headerNames)
+	}
+	errorBody := route.Error
+	if errorBody == nil {
+		errorBody = ErrorResponse{}
+	}
+	errorSchema, err := s.schemaFor(reflect.TypeOf(errorBody))
+	if err != nil {
+		return nil, err
+	}
+
+	operation := map[string]interface{}{
+		"operationId": route.OperationID,
+		"summary":     route.Summary,
+		"responses": map[string]interface{}{
+			"200": success,
+			"default": map[string]interface{}{
+				"description": "Error",
+				"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": errorSchema}},
+			},
+		},
+	}
+	if len(parameters) > 0 {
+		operation["parameters"] = parameters
+	}
+	if limited {
+		operation["responses"].(map[string]interface{})["429"] = map[string]interface{}{
+			"description": "Rate limit or daily render quota exceeded",
+			"headers":     describeHeaders(append([]string{"Retry-After"}, rateLimitHeaders...)),
+			"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": errorSchema}},
+		}
+	}
+	if authEnabled {
+		switch scope, public := scopeFor(route.Path); {
+		case public:
+			operation["security"] = []interface{}{}
+		case scope != "":
+			operation["description"] = fmt.Sprintf("Requires the %s or %s scope.", scope, auth.ScopeAdmin)
+		}
+	}
+	if route.Request != nil {
+		requests, ok := route.Request.([]interface{})
+		if !ok {
+			requests = []interface{}{route.Request}
+		}
+		var schemas []interface{}
+		for _, request := range requests {
+			schema, err := s.schemaFor(reflect.TypeOf(request))
+			if err != nil {
+				return nil, err
+			}
+			schemas = append(schemas, schema)
+		}
+		var schema interface{} = schemas[0]
+		if len(schemas) > 1 {
+			schema = map[string]interface{}{"oneOf": schemas}
+		}
+		operation["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  map[string]interface{}{"application/json": mediaType(schema, route.RequestExample)},
+		}
+	}
+	return operation, nil
+}
+
+// describeHeaders returns the OpenAPI headers object of response headers
+func describeHeaders(names []string) map[string]interface{} {
+	headers := make(map[string]interface{})
+	for _, header := range names {
+		headers[header] = map[string]interface{}{"description": responseHeaders[header], "schema": map[string]interface{}{"type": "string"}}
+	}
+	return headers
+}
+
+// mediaType returns an OpenAPI media type object with an optional example
+func mediaType(schema interface{}, example interface{}) map[string]interface{} {
+	media := map[string]interface{}{"schema": schema}
+	if example != nil {
+		media["example"] = example
+	}
+	return media
+}
+
+// buildOpenAPI generates the OpenAPI document of the given routes from their request and response types.
+// The credentials and scopes the routes require are described only when authentication is enabled.
+func buildOpenAPI(routes []apiRoute, authEnabled bool) (map[string]interface{}, error) {
+	schemas := openAPISchemas{}
+	paths := make(map[string]interface{})
+	for _, route := range routes {
+		operation, err := schemas.operation(route, authEnabled)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe %s %s: %w", route.Method, route.Path, err)
+		}
+		item, ok := paths[route.Path].(map[string]interface{})
+		if !ok {
+			item = make(map[string]interface{})
+			paths[route.Path] = item
+		}
+		item[strings.ToLower(route.Method)] = operation
+	}
+	components := map[string]interface{}{"schemas": schemas}
+	document := map[string]interface{}{
+		"openapi": openAPIVersion,
+		"info": map[string]interface{}{
+			"title":       "cvcl-render",
+			"version":     strings.TrimPrefix(apiV1Prefix, "/"),
+			"description": "Renders cover letters and resumes with Typst. Failed requests return an ErrorResponse.",
+		},
+		"paths":      paths,
+		"components": components,
+	}
+	if authEnabled {
+		components["securitySchemes"] = securitySchemes
+		// Either scheme is accepted; health endpoints override this with no requirement
+		document["security"] = []interface{}{
+			map[string]interface{}{"apiKey": []string{}},
+			map[string]interface{}{"bearer": []string{}},
+		}
+	}
+	return document, nil
+}
+
+// ParseResumeResult is the response body of the unversioned /parse-resume endpoint
+type ParseResumeResult struct {
+	Success      bool              `json:"success"`
+	Resume       typstparse.Resume `json:"resume"`
+	Class        string            `json:"class,omitempty"`
+	Unrecognized []string          `json:"unrecognized,omitempty"`
+}
+
+// securitySchemes describes the credentials accepted when authentication is enabled
+var securitySchemes = map[string]interface{}{
+	"apiKey": map[string]interface{}{"type": "apiKey", "in": "header", "name": "X-API-Key", "description": "API key; may also be sent as a bearer token"},
+	"bearer": map[string]interface{}{"type": "http", "scheme": "bearer", "bearerFormat": "JWT", "description": "JWT signed with a key of the server's JWKS, with scopes in the scope or scp claim"},
+}
+
+// legacyRoutes describes the unversioned endpoints for the OpenAPI document.
+// They are registered in NewMux and answer errors with a RenderResponse.
+func legacyRoutes() []apiRoute {
+	documentParams := []apiParam{strictParam, languageParam}
+	return []apiRoute{
+		{
+			Method: http.MethodPost, Path: "/render", OperationID: "legacyRenderCoverLetter",
+			Summary:        "Render a cover letter to PDF or DOCX (unversioned)",
+			Query:          append([]apiParam{{Name: "format", Type: "string", Enum: []string{"pdf", "docx"}}}, documentParams...),
+			Request:        model.CoverLetterData{},
+			RequestExample: json.RawMessage(examples.CoverLetter),
+			Files:          []string{"application/pdf", render.DOCXContentType, compile.TypstContentType},
+			Headers:        []string{"X-Warnings"},
+			Error:          RenderResponse{},
+		},
+		{
+			Method: http.MethodPost, Path: "/render-resume", OperationID: "legacyRenderResume",
+			Summary:        "Render a resume to PDF or DOCX (unversioned)",
+			Query:          append([]apiParam{{Name: "format", Type: "string", Enum: []string{"pdf", "docx"}}}, documentParams...),
+			Request:        model.ResumeData{},
+			RequestExample: json.RawMessage(examples.Resume),
+			Files:          []string{"application/pdf", render.DOCXContentType, compile.TypstContentType},
+			Headers:        []string{"X-Warnings", "X-Fit-Report"},
+			Error:          RenderResponse{},
+		},
+		{
+			Method: http.MethodPost, Path: "/parse-resume", OperationID: "legacyParseResume",
+			Summary: "Parse a Typst resume or import a LaTeX one (unversioned)",
+			Query: []apiParam{
+				{Name: "file_path", Type: "string", Description: "File in the output directory, used when the body is not JSON"},
+				{Name: "source_format", Type: "string", Enum: []string{"typst", "latex", latex.ClassModernCV, latex.ClassAwesomeCV}},
+			},
+			Request:         ParseResumeRequest{},
+			RequestExample:  ParseResumeRequest{FilePath: "resume.typ"},
+			Response:        ParseResumeResult{},
+			ResponseExample: map[string]interface{}{"success": true, "resume": json.RawMessage(examples.Resume)},
+			Error:           RenderResponse{},
+		},
+		{
+			Method: http.MethodGet, Path: "/health", OperationID: "legacyHealth",
+			Summary:         "Health check (unversioned)",
+			Response:        HealthResponse{},
+			ResponseExample: HealthResponse{Status: "ok"},
+			Error:           RenderResponse{},
+		},
+		{
+			Method: http.MethodGet, Path: "/health/live", OperationID: "liveness",
+			Summary:         "Liveness probe: the process is serving requests",
+			Response:        HealthResponse{},
+			ResponseExample: HealthResponse{Status: "ok"},
+			Error:           RenderResponse{},
+		},
+		{
+			Method: http.MethodGet, Path: "/health/ready", OperationID: "readiness",
+			Summary:  "Readiness probe: fails with 503 while the server drains or a check of typst, its packages and fonts fails",
+			Response: HealthResponse{},
+			ResponseExample: HealthResponse{Status: "ok", CheckedAt: "2024-05-01T12:00:00Z", Checks: []CheckResult{
+				{Name: "typst", Status: CheckOK, Detail: "typst 0.11.0", DurationMS: 12},
+				{Name: "package @local/modern-cv:0.9.0", Status: CheckOK, Detail: "/root/.local/share/typst/packages/local/modern-cv/0.9.0"},
+				{Name: "fonts", Status: CheckOK, Detail: "42 families available", DurationMS: 85},
+			}},
+			Error: HealthResponse{},
+		},
+	}
+}
+
+// handleOpenAPI handles the /openapi.json GET endpoint
+func handleOpenAPI(document []byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(document)
+	}
+}
+
+// handleDocs handles the /docs GET endpoint, serving a self-contained page that renders /openapi.json
+func handleDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(embeddedDocs)
+}
@@ -0,0 +1,493 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"cvcl-render/compile"
+	"cvcl-render/draft"
+	"cvcl-render/latex"
+	"cvcl-render/model"
+	"cvcl-render/render"
+	"cvcl-render/typstparse"
+)
+
+// The operations below are shared by the unversioned and the v1 endpoints. They take decoded
+// request bodies and fail with a *requestError, which each API answers in its own error format.
+
+// requestError is a failed request with the HTTP status and v1 error code it is answered with
+type requestError struct {
+	Status   int
+	Code     string
+	Message  string
+	Details  model.ValidationErrors
+	Warnings []string
+}
+
+func (e *requestError) Error() string {
+	return e.Message
+}
+
+// newRequestError returns a requestError with a formatted message
+func newRequestError(status int, code, format string, args ...interface{}) *requestError {
+	return &requestError{Status: status, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// asRequestError returns err as a *requestError, treating other errors as internal ones
+func asRequestError(err error) *requestError {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		return reqErr
+	}
+	return newRequestError(http.StatusInternalServerError, CodeInternal, "%v", err)
+}
+
+// decodeRequest decodes and validates the JSON body of a request in the decode mode of the
+// strict query parameter, falling back to the server's mode. It returns the decode warnings.
+func decodeRequest(r *http.Request, v interface{ Validate() error }, decodeMode model.DecodeMode) ([]string, error) {
+	mode, err := requestDecodeMode(r, decodeMode)
+	if err != nil {
+		return nil, newRequestError(http.StatusBadRequest, CodeInvalidParameter, "%v", err)
+	}
+
+	warnings, err := model.DecodeLocalizedJSON(r.Body, v, mode, r.URL.Query().Get("language"))
+	if err := bodyTooLarge(err); err != nil {
+		return nil, err
+	}
+	if err == nil {
+		err = v.Validate()
+	}
+	return warnings, invalidRequest(err, warnings)
+}
+
+// invalidRequest maps an error decoding or validating a request body to 422 when it lists
+// invalid fields and to 400 otherwise
+func invalidRequest(err error, warnings []string) error {
+	if err == nil {
+		return nil
+	}
+	var validationErrs model.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return &requestError{Status: http.StatusUnprocessableEntity, Code: CodeValidationFailed,
+			Message: "Invalid request body", Details: validationErrs, Warnings: warnings}
+	}
+	return &requestError{Status: http.StatusBadRequest, Code: CodeInvalidJSON,
+		Message: fmt.Sprintf("Invalid JSON: %v", err), Warnings: warnings}
+}
+
+// bodyTooLarge returns a 413 error when err comes from reading a request body cut off by the
+// size limit, and nil otherwise
+func bodyTooLarge(err error) *requestError {
+	var tooLarge *http.MaxBytesError
+	if !errors.As(err, &tooLarge) {
+		return nil
+	}
+	return newRequestError(http.StatusRequestEntityTooLarge, CodeBodyTooLarge, "Request body exceeds %d bytes", tooLarge.Limit)
+}
+
+// requestDecodeMode returns the decode mode for a request.
+// The strict query parameter overrides the server default.
+func requestDecodeMode(r *http.Request, serverMode model.DecodeMode) (model.DecodeMode, error) {
+	value := r.URL.Query().Get("strict")
+	if value == "" {
+		return serverMode, nil
+	}
+	strict, err := strconv.ParseBool(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid strict parameter: %s", value)
+	}
+	if strict {
+		return model.DecodeStrict, nil
+	}
+	return model.DecodeLenient, nil
+}
+
+// fileResult is a file answered by the render and preview endpoints
+type fileResult struct {
+	// Name is sent as the attachment file name; without it the content is sent inline
+	Name        string
+	ContentType string
+	Content     []byte
+	FitReport   *render.FitReport
+	// PageCount is reported in X-Page-Count when set
+	PageCount int
+}
+
+// writeFileResult sends a file with the decode warnings and the fit report in headers
+func writeFileResult(w http.ResponseWriter, file *fileResult, warnings []string) {
+	if file.PageCount > 0 {
+		w.Header().Set("X-Page-Count", strconv.Itoa(file.PageCount))
+	}
+	setWarningsHeader(w, warnings)
+	setFitHeaders(w, file.FitReport)
+	if file.Name == "" {
+		w.Header().Set("Content-Type", file.ContentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(file.Content)))
+		w.WriteHeader(http.StatusOK)
+		w.Write(file.Content)
+		return
+	}
+	writeFileResponse(w, file.Name, file.ContentType, file.Content)
+}
+
+// writeFileResponse sends content as a file attachment
+func writeFileResponse(w http.ResponseWriter, fileName string, contentType string, content []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(content)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(content)
+}
+
+// setWarningsHeader reports decode warnings on file responses as a JSON array
+func setWarningsHeader(w http.ResponseWriter, warnings []string) {
+	if len(warnings) == 0 {
+		return
+	}
+	encoded, err := json.Marshal(warnings)
+	if err == nil {
+		w.Header().Set("X-Warnings", string(encoded))
+	}
+}
+
+// setFitHeaders reports the fitting result on file responses
+func setFitHeaders(w http.ResponseWriter, report *render.FitReport) {
+	if report == nil {
+		return
+	}
+	encoded, err := json.Marshal(report)
+	if err == nil {
+		w.Header().Set("X-Fit-Report", string(encoded))
+	}
+}
+
+// documentFormat reads the format query parameter of the render endpoints: pdf (default) or docx
+func documentFormat(query url.Values) (string, error) {
+	switch format := query.Get("format"); format {
+	case "", "pdf":
+		return "pdf", nil
+	case "docx":
+		return format, nil
+	default:
+		return "", newRequestError(http.StatusBadRequest, CodeInvalidParameter, "unsupported format: %s", format)
+	}
+}
+
+// renderFailed wraps a rendering error
+func renderFailed(err error) *requestError {
+	return newRequestError(http.StatusInternalServerError, CodeRenderFailed, "Rendering failed: %v", err)
+}
+
+// templateFailed wraps an error reading a template
+func templateFailed(err error) *requestError {
+	return newRequestError(http.StatusInternalServerError, CodeInternal, "Failed to read template: %v", err)
+}
+
+// renderedFile reads the compiled PDF, or the Typst source when PDF compilation is skipped
+func renderedFile(typstFile, pdfFile string, skipPDF bool) (*fileResult, error) {
+	path, contentType := pdfFile, "application/pdf"
+	if skipPDF {
+		path, contentType = typstFile, compile.TypstContentType
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, CodeInternal, "Failed to read rendered file: %v", err)
+	}
+	return &fileResult{Name: filepath.Base(path), ContentType: contentType, Content: content}, nil
+}
+
+// renderCoverLetter renders a cover letter as pdf, or Typst when PDFs are skipped, or docx
+func renderCoverLetter(cfg Config, data model.CoverLetterData, format string) (*fileResult, error) {
+	if format == "docx" {
+		content, err := render.CoverLetterDOCX(data)
+		if err != nil {
+			return nil, renderFailed(err)
+		}
+		return &fileResult{Name: render.CoverLetterBaseName(data) + ".docx", ContentType: render.DOCXContentType, Content: content}, nil
+	}
+
+	templateContent, err := render.CoverLetterTemplate(cfg.TemplatePath)
+	if err != nil {
+		return nil, templateFailed(err)
+	}
+	typstFile, pdfFile, err := render.CompileCoverLetter(templateContent, data, cfg.OutputDir, cfg.SkipPDF)
+	if err != nil {
+		return nil, renderFailed(err)
+	}
+	return renderedFile(typstFile, pdfFile, cfg.SkipPDF)
+}
+
+// renderResume renders a resume as pdf, or Typst when PDFs are skipped, or docx
+func renderResume(cfg Config, data model.ResumeData, format string) (*fileResult, error) {
+	if format == "docx" {
+		content, err := render.ResumeDOCX(data)
+		if err != nil {
+			return nil, renderFailed(err)
+		}
+		return &fileResult{Name: render.ResumeBaseName(data) + ".docx", ContentType: render.DOCXContentType, Content: content}, nil
+	}
+
+	templateContent, err := render.ResumeTemplate(cfg.ResumeTemplatePath)
+	if err != nil {
+		return nil, templateFailed(err)
+	}
+	typstFile, pdfFile, report, err := render.CompileResume(templateContent, data, cfg.OutputDir, cfg.SkipPDF)
+	if err != nil {
+		return nil, renderFailed(err)
+	}
+	file, err := renderedFile(typstFile, pdfFile, cfg.SkipPDF)
+	if err != nil {
+		return nil, err
+	}
+	file.FitReport = report
+	return file, nil
+}
+
+// applicationParams reads the format (zip or multipart) and merge query parameters of an application request
+func applicationParams(query url.Values) (string, bool, error) {
+	format := query.Get("format")
+	if format != "" && format != "zip" && format != "multipart" {
+		return "", false, newRequestError(http.StatusBadRequest, CodeInvalidParameter, "unsupported format: %s", format)
+	}
+	merge := false
+	if value := query.Get("merge"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", false, newRequestError(http.StatusBadRequest, CodeInvalidParameter, "invalid merge parameter: %s", value)
+		}
+		merge = parsed
+	}
+	return format, merge, nil
+}
+
+// renderApplication renders a cover letter and a resume with a shared author into a zip
+// archive, or an inline multipart body
+func renderApplication(cfg Config, req model.ApplicationRequest, format string, merge bool) (*fileResult, error) {
+	coverLetterTemplate, err := render.CoverLetterTemplate(cfg.TemplatePath)
+	if err != nil {
+		return nil, templateFailed(err)
+	}
+	resumeTemplate, err := render.ResumeTemplate(cfg.ResumeTemplatePath)
+	if err != nil {
+		return nil, templateFailed(err)
+	}
+	bundle, err := render.Application(coverLetterTemplate, resumeTemplate, req, merge, cfg.SkipPDF)
+	if errors.Is(err, compile.ErrUnsupportedVersion) {
+		return nil, newRequestError(http.StatusNotImplemented, CodeNotImplemented, "%v", err)
+	}
+	if err != nil {
+		return nil, renderFailed(err)
+	}
+	buf, contentType, err := writeBundle(bundle, format)
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, CodeInternal, "Failed to package documents: %v", err)
+	}
+
+	file := &fileResult{ContentType: contentType, Content: buf.Bytes(), FitReport: bundle.FitReport}
+	if format != "multipart" {
+		file.Name = bundle.BaseName + "_Application.zip"
+	}
+	return file, nil
+}
+
+// writeBundle encodes the bundle as "zip" or "multipart" into a buffer and returns its content type
+func writeBundle(bundle *render.ApplicationBundle, format string) (*bytes.Buffer, string, error) {
+	var buf bytes.Buffer
+	switch format {
+	case "", "zip":
+		if err := bundle.WriteZip(&buf); err != nil {
+			return nil, "", err
+		}
+		return &buf, "application/zip", nil
+	case "multipart":
+		contentType, err := bundle.WriteMultipart(&buf)
+		if err != nil {
+			return nil, "", err
+		}
+		return &buf, contentType, nil
+	}
+	return nil, "", fmt.Errorf("unsupported bundle format: %s", format)
+}
+
+// previewParams reads the format, ppi and page query parameters of a preview request.
+// The page is 0 when all pages are requested.
+func previewParams(query url.Values) (compile.Options, int, error) {
+	opts := compile.Options{Format: compile.FormatPNG}
+	if value := query.Get("format"); value != "" {
+		format, err := compile.ParseOutputFormat(value)
+		if err != nil || format == compile.FormatPDF {
+			return opts, 0, newRequestError(http.StatusBadRequest, CodeInvalidParameter, "unsupported preview format: %s", value)
+		}
+		opts.Format = format
+	}
+	if value := query.Get("ppi"); value != "" {
+		ppi, err := strconv.Atoi(value)
+		if err != nil || ppi <= 0 {
+			return opts, 0, newRequestError(http.StatusBadRequest, CodeInvalidParameter, "invalid ppi parameter: %s", value)
+		}
+		opts.PPI = ppi
+	}
+	page := 0
+	if value := query.Get("page"); value != "" {
+		var err error
+		page, err = strconv.Atoi(value)
+		if err != nil || page <= 0 {
+			return opts, 0, newRequestError(http.StatusBadRequest, CodeInvalidParameter, "invalid page parameter: %s", value)
+		}
+	}
+	return opts, page, nil
+}
+
+// previewDocument returns the request body to decode for the document query parameter of a
+// preview request: a *model.CoverLetterData (default) or a *model.ResumeData
+func previewDocument(query url.Values) (interface{ Validate() error }, error) {
+	switch document := query.Get("document"); document {
+	case "", "coverletter":
+		return &model.CoverLetterData{}, nil
+	case "resume":
+		return &model.ResumeData{}, nil
+	default:
+		return nil, newRequestError(http.StatusBadRequest, CodeInvalidParameter, "unknown document type: %s", document)
+	}
+}
+
+// previewPages renders page images of a document returned by previewDocument: the given
+// page, or all pages as a zip archive when page is 0
+func previewPages(cfg Config, document interface{}, opts compile.Options, page int) (*fileResult, error) {
+	var result *compile.Result
+	var report *render.FitReport
+	var baseName string
+	var err error
+	switch data := document.(type) {
+	case *model.CoverLetterData:
+		var templateContent string
+		if templateContent, err = render.CoverLetterTemplate(cfg.TemplatePath); err != nil {
+			return nil, templateFailed(err)
+		}
+		baseName = render.CoverLetterBaseName(*data)
+		result, err = render.PreviewCoverLetter(templateContent, *data, opts)
+	case *model.ResumeData:
+		var templateContent string
+		if templateContent, err = render.ResumeTemplate(cfg.ResumeTemplatePath); err != nil {
+			return nil, templateFailed(err)
+		}
+		baseName = render.ResumeBaseName(*data)
+		result, report, err = render.PreviewResume(templateContent, *data, opts)
+	default:
+		return nil, fmt.Errorf("unsupported preview document %T", document)
+	}
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, CodeRenderFailed, "Preview failed: %v", err)
+	}
+	if page > len(result.Pages) {
+		return nil, newRequestError(http.StatusNotFound, CodeNotFound, "Page %d out of range (document has %d pages)", page, result.PageCount)
+	}
+
+	file := &fileResult{FitReport: report, PageCount: result.PageCount}
+	if page > 0 {
+		file.Name = fmt.Sprintf("%s-%d.%s", baseName, page, result.Format)
+		file.ContentType, file.Content = result.Format.ContentType(), result.Pages[page-1]
+		return file, nil
+	}
+	content, err := zipPages(result, baseName)
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, CodeInternal, "Failed to package pages: %v", err)
+	}
+	file.Name, file.ContentType, file.Content = baseName+"-preview.zip", "application/zip", content
+	return file, nil
+}
+
+// zipPages packages the pages of a preview as baseName-1.png, baseName-2.png, ...
+func zipPages(result *compile.Result, baseName string) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i, content := range result.Pages {
+		f, err := zw.Create(fmt.Sprintf("%s-%d.%s", baseName, i+1, result.Format))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Write(content); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// parseSource returns the document of a parse request: its source, or the file it names in the
+// output directory
+func parseSource(cfg Config, source, filePath string) (string, error) {
+	if filePath == "" {
+		return source, nil
+	}
+	content, err := readOutputFile(cfg.OutputDir, filePath)
+	if err != nil {
+		return "", newRequestError(http.StatusUnprocessableEntity, CodeUnreadableFile, "Failed to read file: %v", err)
+	}
+	return string(content), nil
+}
+
+// parseCoverLetter parses a rendered Typst cover letter
+func parseCoverLetter(cfg Config, req ParseCoverLetterRequest) (*model.CoverLetterData, error) {
+	content, err := parseSource(cfg, req.Source, req.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	coverLetter, err := typstparse.ParseCoverLetter(content)
+	if err != nil {
+		return nil, newRequestError(http.StatusUnprocessableEntity, CodeParseFailed, "Failed to parse cover letter: %v", err)
+	}
+	return coverLetter, nil
+}
+
+// parsedResume is a parsed Typst resume, or a LaTeX resume import
+type parsedResume struct {
+	Typst    *typstparse.Resume
+	Imported *latex.ImportResult
+}
+
+// parseResume parses a Typst resume or imports a LaTeX one
+func parseResume(cfg Config, req ParseResumeRequest) (*parsedResume, error) {
+	content, err := parseSource(cfg, req.Source, req.FilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.SourceFormat == "" || req.SourceFormat == "typst" {
+		resume, err := typstparse.ParseResume(content)
+		if err != nil {
+			return nil, newRequestError(http.StatusUnprocessableEntity, CodeParseFailed, "Failed to parse resume: %v", err)
+		}
+		return &parsedResume{Typst: resume}, nil
+	}
+
+	class := req.SourceFormat
+	if class == "latex" {
+		class = ""
+	}
+	result, err := latex.ImportResume(content, class)
+	if err != nil {
+		return nil, newRequestError(http.StatusUnprocessableEntity, CodeParseFailed, "Failed to import resume: %v", err)
+	}
+	return &parsedResume{Imported: result}, nil
+}
+
+// draftCoverLetter drafts a cover letter with the generator, falling back to the template draft
+func draftCoverLetter(ctx context.Context, generator draft.Generator, req model.DraftRequest) (model.CoverLetterData, string, []string, error) {
+	letter, generatorName, warnings, err := draft.CoverLetter(ctx, generator, req)
+	if err != nil {
+		return model.CoverLetterData{}, "", nil, newRequestError(http.StatusInternalServerError, CodeRenderFailed, "Drafting failed: %v", err)
+	}
+	return letter, generatorName, warnings, nil
+}
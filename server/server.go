@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"cvcl-render/compile"
+)
+
+// NewMux returns a handler serving the unversioned endpoints, the /v1 API,
+// /openapi.json and /docs
+func NewMux(cfg Config) (http.Handler, error) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/render", handleRender(cfg))
+	mux.HandleFunc("/render-resume", handleRenderResume(cfg))
+	mux.HandleFunc("/health", handleHealth)
+	mux.HandleFunc("/health/live", handleHealth)
+	mux.HandleFunc("/health/ready", handleReady(cfg.Health))
+	mux.HandleFunc("/schema/", handleSchema)
+	mux.HandleFunc("/parse-resume", handleParseResume(cfg))
+	mux.HandleFunc("/parse-coverletter", handleParseCoverLetter(cfg))
+	mux.HandleFunc("/preview", handlePreview(cfg))
+	mux.HandleFunc("/match-keywords", handleMatchKeywords(cfg))
+	mux.HandleFunc("/draft-coverletter", handleDraftCoverLetter(cfg))
+	mux.HandleFunc("/render-application", handleRenderApplication(cfg))
+	routes := v1Routes(cfg)
+	registerV1Routes(mux, routes)
+
+	// Describe the API
+	document, err := buildOpenAPI(append(legacyRoutes(), routes...), cfg.Authenticator != nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build OpenAPI document: %w", err)
+	}
+	encoded, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAPI document: %w", err)
+	}
+	mux.HandleFunc("/openapi.json", handleOpenAPI(encoded))
+	mux.HandleFunc("/docs", handleDocs)
+
+	var handler http.Handler = mux
+	// Limits apply per principal, so they run after authentication
+	if cfg.RateLimiter != nil || cfg.Quotas != nil {
+		handler = limitUsage(handler, cfg.RateLimiter, cfg.Quotas)
+	}
+	if cfg.Authenticator != nil {
+		handler = requireAuth(handler, cfg.Authenticator, cfg.AuditLog)
+	}
+	if cfg.MaxBodyBytes > 0 {
+		handler = limitBody(handler, cfg.MaxBodyBytes)
+	}
+	return handler, nil
+}
+
+// limitBody rejects request bodies larger than max bytes with 413 Request Entity Too Large.
+// Bodies of unknown length are cut off at max bytes, which fails their decoding.
+func limitBody(next http.Handler, max int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > max {
+			writeMiddlewareError(w, r, http.StatusRequestEntityTooLarge, CodeBodyTooLarge, fmt.Sprintf("Request body exceeds %d bytes", max))
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, max)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Server serves the API over HTTP and drains gracefully on shutdown
+type Server struct {
+	httpServer *http.Server
+	health     *Health
+}
+
+// New returns a Server listening on addr
+func New(addr string, cfg Config) (*Server, error) {
+	if cfg.Health == nil {
+		cfg.Health = &Health{}
+	}
+	handler, err := NewMux(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{
+		httpServer: &http.Server{
+			Addr:              addr,
+			Handler:           handler,
+			ReadHeaderTimeout: cfg.ReadTimeout,
+			ReadTimeout:       cfg.ReadTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+		},
+		health: cfg.Health,
+	}, nil
+}
+
+// ListenAndServe serves requests until Shutdown is called, then returns nil
+func (s *Server) ListenAndServe() error {
+	err := s.httpServer.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown marks the server as draining and keeps serving for drainDelay, so that load
+// balancers see /health/ready fail. It then stops accepting connections and waits for
+// in-flight requests and typst processes until ctx ends.
+func (s *Server) Shutdown(ctx context.Context, drainDelay time.Duration) error {
+	s.health.SetDraining()
+	select {
+	case <-time.After(drainDelay):
+	case <-ctx.Done():
+	}
+	err := s.httpServer.Shutdown(ctx)
+	if waitErr := compile.Wait(ctx); err == nil {
+		err = waitErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to drain server: %w", err)
+	}
+	return nil
+}
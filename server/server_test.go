@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"cvcl-render/model"
+)
+
+func TestBodyLimit(t *testing.T) {
+	handler, err := NewMux(Config{
+		TemplatePath: "templates/coverletter.typ.template",
+		OutputDir:    t.TempDir(),
+		SkipPDF:      true,
+		DecodeMode:   model.DecodeLenient,
+		MaxBodyBytes: 64,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := `{"position": "` + strings.Repeat("x", 100) + `"}`
+
+	for _, path := range []string{"/v1/render", "/render"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		if rec.Code != http.StatusRequestEntityTooLarge || !strings.Contains(rec.Body.String(), "exceeds 64 bytes") {
+			t.Errorf("%s: expected 413, got %d: %s", path, rec.Code, rec.Body)
+		}
+	}
+
+	// Without a Content-Length the body is cut off while decoding
+	req := httptest.NewRequest(http.MethodPost, "/v1/render", strings.NewReader(body))
+	req.ContentLength = -1
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	var resp ErrorResponse
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if rec.Code != http.StatusRequestEntityTooLarge || resp.Error.Code != CodeBodyTooLarge {
+		t.Errorf("Expected 413 %s for a streamed body, got %d: %s", CodeBodyTooLarge, rec.Code, rec.Body)
+	}
+	for _, path := range []string{"/render", "/render-resume", "/match-keywords", "/draft-coverletter", "/render-application", "/preview", "/parse-resume", "/parse-coverletter"} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.ContentLength = -1
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusRequestEntityTooLarge || !strings.Contains(rec.Body.String(), "exceeds 64 bytes") {
+			t.Errorf("%s: expected 413 for a streamed body, got %d: %s", path, rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestServerShutdown(t *testing.T) {
+	srv, err := New("127.0.0.1:0", Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- srv.ListenAndServe() }()
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx, 10*time.Millisecond); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if !srv.health.Draining() {
+		t.Error("Expected the server to be draining")
+	}
+	if err := <-served; err != nil {
+		t.Errorf("ListenAndServe returned %v", err)
+	}
+}
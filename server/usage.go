@@ -0,0 +1,185 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cvcl-render/auth"
+	"cvcl-render/model"
+	"cvcl-render/usage"
+)
+
+// clientID identifies the client of a request for rate limits and quotas: its principal when
+// authenticated, e.g. "api_key:ci-bot", and otherwise its IP address, e.g. "ip:10.0.0.7"
+func clientID(r *http.Request) string {
+	if principal := auth.FromContext(r.Context()); principal != nil {
+		return principal.Method + ":" + principal.ID
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// limitedPath reports whether requests to a path are rate limited, which applies to the render
+// and parse endpoints, and whether they count against the daily render quota
+func limitedPath(path string) (limited, render bool) {
+	scope, _ := scopeFor(path)
+	path = strings.TrimPrefix(path, apiV1Prefix)
+	render = strings.HasPrefix(path, "/render") || path == "/preview"
+	return scope == auth.ScopeRender || scope == auth.ScopeParse, render
+}
+
+// limitUsage refuses requests beyond a client's rate limit or daily render quota with
+// 429 Too Many Requests and Retry-After. Responses carry the RateLimit-* headers of the
+// limit closest to exhaustion. Renders that fail do not count against the quota.
+func limitUsage(next http.Handler, limiter *usage.Limiter, quotas *usage.Quotas) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limited, render := limitedPath(r.URL.Path)
+		if !limited {
+			next.ServeHTTP(w, r)
+			return
+		}
+		client := clientID(r)
+		header := w.Header()
+		var policies []string
+
+		remaining := math.MaxInt
+		if limiter != nil {
+			decision := limiter.Allow(client)
+			window := time.Duration(limiter.Burst) * time.Minute / time.Duration(limiter.PerMinute)
+			policies = append(policies, fmt.Sprintf("%d;w=%d", limiter.Burst, seconds(window)))
+			setRateLimit(header, decision.Limit, decision.Remaining, decision.Reset)
+			remaining = decision.Remaining
+			if !decision.Allowed {
+				header.Set("RateLimit-Policy", strings.Join(policies, ", "))
+				header.Set("Retry-After", strconv.Itoa(seconds(decision.RetryAfter)))
+				writeMiddlewareError(w, r, http.StatusTooManyRequests, CodeRateLimited,
+					fmt.Sprintf("Rate limit of %d requests a minute exceeded", limiter.PerMinute))
+				return
+			}
+		}
+		if !render || quotas == nil {
+			if len(policies) > 0 {
+				header.Set("RateLimit-Policy", strings.Join(policies, ", "))
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		status, ok, err := quotas.Reserve(client)
+		if err != nil {
+			// Keep serving when the counts cannot be saved; they are still kept in memory
+			log.Printf("Failed to save render quotas: %v", err)
+		}
+		if status.Remaining != nil {
+			policies = append(policies, fmt.Sprintf("%d;w=%d", quotas.Limit, seconds(24*time.Hour)))
+			if *status.Remaining <= remaining {
+				setRateLimit(header, quotas.Limit, *status.Remaining, time.Until(status.Reset))
+			}
+		}
+		if len(policies) > 0 {
+			header.Set("RateLimit-Policy", strings.Join(policies, ", "))
+		}
+		if !ok {
+			header.Set("Retry-After", strconv.Itoa(seconds(time.Until(status.Reset))))
+			writeMiddlewareError(w, r, http.StatusTooManyRequests, CodeQuotaExceeded,
+				fmt.Sprintf("Daily quota of %d renders used up; it resets at %s", quotas.Limit, status.Reset.Format(time.RFC3339)))
+			return
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+		if recorder.status >= http.StatusBadRequest {
+			if err := quotas.Release(client); err != nil {
+				log.Printf("Failed to save render quotas: %v", err)
+			}
+		}
+	})
+}
+
+// setRateLimit sets the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers
+func setRateLimit(header http.Header, limit, remaining int, reset time.Duration) {
+	header.Set("RateLimit-Limit", strconv.Itoa(limit))
+	header.Set("RateLimit-Remaining", strconv.Itoa(remaining))
+	header.Set("RateLimit-Reset", strconv.Itoa(seconds(reset)))
+}
+
+// seconds rounds a duration up to whole seconds
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// clientUsage returns the usage of a client
+func clientUsage(cfg Config, client string) ClientUsage {
+	result := ClientUsage{Client: client}
+	if cfg.RateLimiter != nil {
+		tokens := int(cfg.RateLimiter.Tokens(client))
+		result.Tokens = &tokens
+	}
+	if cfg.Quotas != nil {
+		status := cfg.Quotas.Status(client)
+		result.Renders, result.RemainingRenders = status.Used, status.Remaining
+	}
+	return result
+}
+
+// handleV1Usage handles the /v1/admin/usage GET endpoint, listing the clients that rendered today
+// or whose rate limit is in use, or only the client given by ?client=
+func handleV1Usage(cfg Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := UsageResponse{Clients: []ClientUsage{}}
+		if cfg.RateLimiter != nil {
+			resp.RequestsPerMinute, resp.Burst = cfg.RateLimiter.PerMinute, cfg.RateLimiter.Burst
+		}
+		var clients []string
+		if cfg.Quotas != nil {
+			resp.DailyRenders = cfg.Quotas.Limit
+			resp.QuotaReset = cfg.Quotas.Status("").Reset.Format(time.RFC3339)
+			clients = cfg.Quotas.Clients()
+		}
+		if cfg.RateLimiter != nil {
+			clients = append(clients, cfg.RateLimiter.Clients()...)
+		}
+		if client := r.URL.Query().Get("client"); client != "" {
+			clients = []string{client}
+		}
+
+		sort.Strings(clients)
+		for i, client := range clients {
+			if i == 0 || client != clients[i-1] {
+				resp.Clients = append(resp.Clients, clientUsage(cfg, client))
+			}
+		}
+		writeAPIJSON(w, http.StatusOK, resp)
+	}
+}
+
+// handleV1ResetUsage handles the /v1/admin/usage/reset POST endpoint, which clears a client's
+// render count for today and refills its rate limit
+func handleV1ResetUsage(cfg Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ResetUsageRequest
+		if _, ok := decodeAPIRequest(w, r, &req, model.DecodeStrict); !ok {
+			return
+		}
+		if cfg.RateLimiter != nil {
+			cfg.RateLimiter.Reset(req.Client)
+		}
+		if cfg.Quotas != nil {
+			if err := cfg.Quotas.Reset(req.Client); err != nil {
+				writeAPIError(w, http.StatusInternalServerError, CodeInternal, err.Error())
+				return
+			}
+		}
+		writeAPIJSON(w, http.StatusOK, clientUsage(cfg, req.Client))
+	}
+}
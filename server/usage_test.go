@@ -0,0 +1,122 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cvcl-render/auth"
+	"cvcl-render/examples"
+	"cvcl-render/model"
+	"cvcl-render/usage"
+)
+
+func TestRateLimit(t *testing.T) {
+	handler, err := NewMux(Config{
+		TemplatePath: "templates/coverletter.typ.template",
+		OutputDir:    t.TempDir(),
+		SkipPDF:      true,
+		DecodeMode:   model.DecodeLenient,
+		RateLimiter:  usage.NewLimiter(6, 2),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := func(path, remote string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(examples.CoverLetter))
+		req.RemoteAddr = remote
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, remaining := range []string{"1", "0"} {
+		rec := request("/v1/render", "10.0.0.1:1234")
+		if rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Remaining") != remaining {
+			t.Fatalf("Expected %s remaining requests, got status %d, headers %v", remaining, rec.Code, rec.Header())
+		}
+	}
+	rec := request("/v1/parse-coverletter", "10.0.0.1:5678")
+	var resp ErrorResponse
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if rec.Code != http.StatusTooManyRequests || resp.Error.Code != CodeRateLimited || rec.Header().Get("Retry-After") != "10" {
+		t.Errorf("Expected 429 %s with Retry-After 10, got %d %v: %s", CodeRateLimited, rec.Code, rec.Header(), rec.Body)
+	}
+	if policy := rec.Header().Get("RateLimit-Policy"); policy != "2;w=20" {
+		t.Errorf("Unexpected policy %q", policy)
+	}
+
+	// Other clients and endpoints are not limited
+	if rec := request("/v1/render", "10.0.0.2:1234"); rec.Code != http.StatusOK {
+		t.Errorf("Another client was limited: %d", rec.Code)
+	}
+	if rec := request("/v1/schema/resume.json", "10.0.0.1:1234"); rec.Code == http.StatusTooManyRequests || rec.Header().Get("RateLimit-Limit") != "" {
+		t.Errorf("Schema requests were limited: %d %v", rec.Code, rec.Header())
+	}
+}
+
+func TestRenderQuota(t *testing.T) {
+	keys, err := auth.ParseAPIKeys("ci " + auth.HashKey("ci-key") + " render\nops " + auth.HashKey("admin-key") + " admin\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	quotas, err := usage.OpenQuotas(filepath.Join(t.TempDir(), "usage.json"), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler, err := NewMux(Config{
+		TemplatePath:  "templates/coverletter.typ.template",
+		OutputDir:     t.TempDir(),
+		SkipPDF:       true,
+		DecodeMode:    model.DecodeLenient,
+		Authenticator: keys,
+		Quotas:        quotas,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := func(method, path, key string, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Failed renders do not count
+	if rec := request(http.MethodPost, "/v1/render", "ci-key", []byte("{")); rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for invalid JSON, got %d", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/v1/render", "ci-key", examples.CoverLetter); rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("Expected the first render to succeed, got %d %v", rec.Code, rec.Header())
+	}
+	rec := request(http.MethodPost, "/v1/render", "ci-key", examples.CoverLetter)
+	var errResp ErrorResponse
+	json.Unmarshal(rec.Body.Bytes(), &errResp)
+	if rec.Code != http.StatusTooManyRequests || errResp.Error.Code != CodeQuotaExceeded || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected 429 %s, got %d: %s", CodeQuotaExceeded, rec.Code, rec.Body)
+	}
+
+	// Admins inspect and reset the usage
+	if rec := request(http.MethodGet, "/v1/admin/usage", "ci-key", nil); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected usage to need the admin scope, got %d", rec.Code)
+	}
+	rec = request(http.MethodGet, "/v1/admin/usage", "admin-key", nil)
+	var usageResp UsageResponse
+	json.Unmarshal(rec.Body.Bytes(), &usageResp)
+	if rec.Code != http.StatusOK || usageResp.DailyRenders != 1 || len(usageResp.Clients) != 1 ||
+		usageResp.Clients[0].Client != "api_key:ci" || usageResp.Clients[0].Renders != 1 || *usageResp.Clients[0].RemainingRenders != 0 {
+		t.Fatalf("Unexpected usage %d: %s", rec.Code, rec.Body)
+	}
+	rec = request(http.MethodPost, "/v1/admin/usage/reset", "admin-key", []byte(`{"client": "api_key:ci"}`))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"renders":0`) {
+		t.Fatalf("Unexpected reset response %d: %s", rec.Code, rec.Body)
+	}
+	if rec := request(http.MethodPost, "/v1/render", "ci-key", examples.CoverLetter); rec.Code != http.StatusOK {
+		t.Errorf("Expected a render after the reset, got %d: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,143 @@
+package typstparse
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"cvcl-render/i18n"
+	"cvcl-render/model"
+)
+
+// ParseCoverLetter parses a Typst cover letter, as produced by render.CoverLetter, back into model.CoverLetterData
+func ParseCoverLetter(content string) (*model.CoverLetterData, error) {
+	data := &model.CoverLetterData{}
+
+	// Parse the author tuple
+	author := findTypstCall(content, "author: (", '(', ')')
+	data.Profile = parseTypstProfile(author)
+
+	// Parse the letter heading
+	heading := parseTypstStringFields(findTypstCall(content, "#letter-heading(", '(', ')'))
+	data.Position = heading["job-position"]
+	data.Addressee = heading["addressee"]
+
+	// Only a non-default language is recorded, so that English letters parse back without options
+	if language := parseTypstStringFields(findTypstCall(content, "#show: coverletter.with(", '(', ')'))["language"]; language != "" && language != i18n.DefaultLanguage {
+		data.Options = &model.RenderOptions{Language: language}
+	}
+
+	data.Date = strings.TrimSpace(findTypstCall(content, "#letter-date[", '[', ']'))
+	data.Salutation = strings.TrimSpace(findTypstCall(content, "#letter-salutation[", '[', ']'))
+	data.Closing = strings.TrimSpace(findTypstCall(content, "#letter-closing[", '[', ']'))
+	data.Signature = strings.TrimSpace(findTypstCall(content, "#letter-signature[", '[', ']'))
+	var addressLines []string
+	for _, line := range strings.Split(findTypstCall(content, "#recipient-address[", '[', ']'), "\n") {
+		line = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line), `\`))
+		if line != "" {
+			addressLines = append(addressLines, line)
+		}
+	}
+	data.RecipientAddress = strings.Join(addressLines, "\n")
+
+	// Parse the content paragraphs in order
+	rest := content
+	for {
+		idx := strings.Index(rest, "#coverletter-content[")
+		if idx == -1 {
+			break
+		}
+		start := idx + len("#coverletter-content")
+		body, end, ok := readTypstGroup(rest, start, '[', ']')
+		if !ok {
+			return nil, fmt.Errorf("unterminated #coverletter-content block")
+		}
+		data.Paragraphs = append(data.Paragraphs, parseCoverLetterParagraph(body))
+		rest = rest[end:]
+	}
+
+	if author == "" && len(data.Paragraphs) == 0 {
+		return nil, fmt.Errorf("no cover letter content found")
+	}
+
+	return data, nil
+}
+
+// parseCoverLetterParagraph splits a #coverletter-content body into its optional heading and text
+func parseCoverLetterParagraph(body string) model.Paragraph {
+	body = strings.TrimSpace(body)
+	marker := "#paragraph-heading"
+	if !strings.HasPrefix(body, marker+"[") {
+		return model.Paragraph{Text: body}
+	}
+	heading, end, ok := readTypstGroup(body, len(marker), '[', ']')
+	if !ok {
+		return model.Paragraph{Text: body}
+	}
+	text := strings.TrimSpace(body[end:])
+	text = strings.TrimSpace(strings.TrimPrefix(text, `\`))
+	return model.Paragraph{Heading: strings.TrimSpace(heading), Text: text}
+}
+
+// findTypstCall returns the arguments of the first call starting with marker, without the delimiters
+func findTypstCall(content string, marker string, open, close byte) string {
+	idx := strings.Index(content, marker)
+	if idx == -1 {
+		return ""
+	}
+	body, _, ok := readTypstGroup(content, idx+len(marker)-1, open, close)
+	if !ok {
+		return ""
+	}
+	return body
+}
+
+// readTypstGroup reads a balanced group starting at src[p] == open, skipping string literals.
+// It returns the group content and the position after the closing delimiter.
+func readTypstGroup(src string, p int, open, close byte) (string, int, bool) {
+	depth := 0
+	inString := false
+	for i := p; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == '\\':
+			i++
+		case inString:
+			if c == '"' {
+				inString = false
+			}
+		case c == '"' && open == '(':
+			// Strings only delimit literals in code mode, not in content blocks
+			inString = true
+		case c == open:
+			depth++
+		case c == close:
+			depth--
+			if depth == 0 {
+				return src[p+1 : i], i + 1, true
+			}
+		}
+	}
+	return "", p, false
+}
+
+var typstStringFieldPattern = regexp.MustCompile(`([\w-]+):\s*"((?:[^"\\]|\\.)*)"`)
+
+// typstStringUnescaper reverses the escaping of quotes and backslashes done by the templates
+var typstStringUnescaper = strings.NewReplacer(`\\`, `\`, `\"`, `"`)
+
+// unescapeTypstString returns the value of the body of a quoted Typst string
+func unescapeTypstString(s string) string {
+	return typstStringUnescaper.Replace(s)
+}
+
+// parseTypstStringFields extracts the name: "value" pairs of a Typst dictionary or argument list
+func parseTypstStringFields(content string) map[string]string {
+	fields := make(map[string]string)
+	for _, m := range typstStringFieldPattern.FindAllStringSubmatch(content, -1) {
+		if _, exists := fields[m[1]]; !exists {
+			fields[m[1]] = unescapeTypstString(m[2])
+		}
+	}
+	return fields
+}
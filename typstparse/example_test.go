@@ -0,0 +1,24 @@
+package typstparse_test
+
+import (
+	"fmt"
+
+	"cvcl-render/typstparse"
+)
+
+func ExampleInline() {
+	for _, run := range typstparse.Inline(`Studied at #link("https://www.chalmers.se/")[Chalmers], *with honours*`) {
+		fmt.Printf("%q %q bold=%v\n", run.Text, run.URL, run.Bold)
+	}
+	// Output:
+	// "Studied at " "" bold=false
+	// "Chalmers" "https://www.chalmers.se/" bold=false
+	// ", " "" bold=false
+	// "with honours" "" bold=true
+}
+
+func ExampleStripInline() {
+	fmt.Println(typstparse.StripInline(`Maintainer of #github-link("jdoe/cvcl") and *fast* services`))
+	// Output:
+	// Maintainer of jdoe/cvcl and fast services
+}
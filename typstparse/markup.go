@@ -0,0 +1,74 @@
+package typstparse
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Run is a piece of inline text with its formatting
+type Run struct {
+	Text   string
+	URL    string
+	Bold   bool
+	Italic bool
+}
+
+// typstInlinePattern matches the inline markup written by the templates: links, GitHub links and strong text
+var typstInlinePattern = regexp.MustCompile(`#link\("([^"]*)"\)(?:\[([^\]]*)\])?|#?github-link\("([^"]*)"\)|\*([^*]+)\*`)
+
+// Inline converts a line of Typst markup into formatted runs.
+// Links, github-link and *strong* are recognised; everything else is kept verbatim.
+func Inline(text string) []Run {
+	var runs []Run
+	pos := 0
+	for _, m := range typstInlinePattern.FindAllStringSubmatchIndex(text, -1) {
+		if m[0] > pos {
+			runs = append(runs, Run{Text: Unescape(text[pos:m[0]])})
+		}
+		switch {
+		case m[2] != -1:
+			url := text[m[2]:m[3]]
+			label := url
+			if m[4] != -1 {
+				label = text[m[4]:m[5]]
+			}
+			runs = append(runs, Run{Text: Unescape(label), URL: url})
+		case m[6] != -1:
+			repo := text[m[6]:m[7]]
+			runs = append(runs, Run{Text: repo, URL: "https://github.com/" + repo})
+		case m[8] != -1:
+			runs = append(runs, Run{Text: Unescape(text[m[8]:m[9]]), Bold: true})
+		}
+		pos = m[1]
+	}
+	if pos < len(text) {
+		runs = append(runs, Run{Text: Unescape(text[pos:])})
+	}
+	return runs
+}
+
+// Unescape removes Typst escape backslashes
+func Unescape(text string) string {
+	var result strings.Builder
+	escaped := false
+	for _, r := range text {
+		if r == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+		escaped = false
+		result.WriteRune(r)
+	}
+	return result.String()
+}
+
+// StripInline replaces the links and strong text of Typst markup with their plain text
+func StripInline(text string) string {
+	return typstInlinePattern.ReplaceAllStringFunc(text, func(m string) string {
+		var result strings.Builder
+		for _, run := range Inline(m) {
+			result.WriteString(run.Text)
+		}
+		return result.String()
+	})
+}
@@ -0,0 +1,23 @@
+package typstparse
+
+import "testing"
+
+func TestInline(t *testing.T) {
+	runs := Inline(`Worked at #link("https://example.com/")[Example] on *zero* cost, see github-link("a/b")`)
+	expected := []Run{
+		{Text: "Worked at "},
+		{Text: "Example", URL: "https://example.com/"},
+		{Text: " on "},
+		{Text: "zero", Bold: true},
+		{Text: " cost, see "},
+		{Text: "a/b", URL: "https://github.com/a/b"},
+	}
+	if len(runs) != len(expected) {
+		t.Fatalf("Expected %d runs, got %d: %+v", len(expected), len(runs), runs)
+	}
+	for i := range expected {
+		if runs[i] != expected[i] {
+			t.Errorf("Run %d: expected %+v, got %+v", i, expected[i], runs[i])
+		}
+	}
+}
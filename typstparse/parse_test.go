@@ -1,21 +1,27 @@
-package main
+package typstparse_test
 
 import (
 	"encoding/json"
 	"os"
+	"reflect"
 	"testing"
+
+	"cvcl-render/examples"
+	"cvcl-render/model"
+	"cvcl-render/render"
+	"cvcl-render/typstparse"
 )
 
-func TestParseResumeTypst(t *testing.T) {
+func TestParseResume(t *testing.T) {
 	// Read the resume file
-	content, err := os.ReadFile("templates/resume.typ")
+	content, err := os.ReadFile("testdata/resume.typ")
 	print(content)
 	if err != nil {
 		t.Fatalf("Failed to read resume file: %v", err)
 	}
 
 	// Parse it
-	resume, err := ParseResumeTypst(string(content))
+	resume, err := typstparse.ParseResume(string(content))
 	if err != nil {
 		t.Fatalf("Failed to parse resume: %v", err)
 	}
@@ -55,7 +61,7 @@ func TestParseResumeTypst(t *testing.T) {
 }
 
 // func TestParsePositions(t *testing.T) {
-// 	content, _ := os.ReadFile("templates/resume.typ")
+// 	content, _ := os.ReadFile("testdata/resume.typ")
 // 	positions := parsePositions(string(content))
 
 // 	expectedPositions := []string{
@@ -82,7 +88,7 @@ func TestParseResumeTypst(t *testing.T) {
 // }
 
 // func TestParseSummary(t *testing.T) {
-// 	content, _ := os.ReadFile("templates/resume.typ")
+// 	content, _ := os.ReadFile("testdata/resume.typ")
 // 	summary := parseSummary(string(content))
 
 // 	if summary == "" {
@@ -95,7 +101,7 @@ func TestParseResumeTypst(t *testing.T) {
 // }
 
 // func TestParseEducation(t *testing.T) {
-// 	content, _ := os.ReadFile("templates/resume.typ")
+// 	content, _ := os.ReadFile("testdata/resume.typ")
 // 	education := parseSection(string(content), "Education")
 
 // 	if len(education) < 2 {
@@ -115,7 +121,7 @@ func TestParseResumeTypst(t *testing.T) {
 // }
 
 // func TestParseSkills(t *testing.T) {
-// 	content, _ := os.ReadFile("templates/resume.typ")
+// 	content, _ := os.ReadFile("testdata/resume.typ")
 // 	skills := parseSkills(string(content))
 
 // 	if len(skills) == 0 {
@@ -139,7 +145,7 @@ func TestParseResumeTypst(t *testing.T) {
 // }
 
 // func TestParseInterests(t *testing.T) {
-// 	content, _ := os.ReadFile("templates/resume.typ")
+// 	content, _ := os.ReadFile("testdata/resume.typ")
 // 	interests := parseInterests(string(content))
 
 // 	if len(interests) == 0 {
@@ -175,3 +181,66 @@ func TestParseResumeTypst(t *testing.T) {
 // 	}
 // 	return false
 // }
+
+// assertCoverLetterRoundTrip renders data with the cover letter template and checks that parsing restores it
+func assertCoverLetterRoundTrip(t *testing.T, templateContent string, original model.CoverLetterData) {
+	t.Helper()
+	rendered, err := render.CoverLetter(templateContent, original)
+	if err != nil {
+		t.Fatalf("Failed to render cover letter: %v", err)
+	}
+
+	parsed, err := typstparse.ParseCoverLetter(rendered)
+	if err != nil {
+		t.Fatalf("Failed to parse cover letter: %v", err)
+	}
+
+	// Legacy paragraph fields come back as Paragraphs
+	expected := original
+	expected.Paragraphs = original.ContentParagraphs()
+	expected.Opening, expected.AboutMe, expected.WhyMe, expected.WhyCompany = "", "", "", ""
+	if !reflect.DeepEqual(*parsed, expected) {
+		t.Errorf("Round trip mismatch:\nexpected %+v\ngot      %+v", expected, *parsed)
+	}
+}
+
+func TestParseCoverLetterRoundTrip(t *testing.T) {
+	templateContent, err := render.CoverLetterTemplate("templates/coverletter.typ.template")
+	if err != nil {
+		t.Fatalf("Failed to read template: %v", err)
+	}
+
+	var original model.CoverLetterData
+	if err := json.Unmarshal(examples.CoverLetter, &original); err != nil {
+		t.Fatalf("Failed to parse example data: %v", err)
+	}
+	original.Opening = `I found the role via #link("https://example.com/jobs")[your careers page].`
+	assertCoverLetterRoundTrip(t, templateContent, original)
+
+	// Optional fields left out of the template must stay empty
+	original.Homepage = ""
+	original.GitHub = ""
+	original.WhyMe = ""
+	assertCoverLetterRoundTrip(t, templateContent, original)
+
+	// Variable paragraphs with headings and the optional letter parts
+	original.Paragraphs = []model.Paragraph{
+		{Text: "First paragraph."},
+		{Heading: "Why me", Text: "Second paragraph."},
+		{Text: "Third paragraph."},
+		{Heading: "Why you", Text: "Fourth paragraph."},
+		{Text: "Fifth paragraph."},
+	}
+	original.Date = "October 18, 2026"
+	original.RecipientAddress = "ACME Inc.\nMain Street 1\n12345 Springfield"
+	original.Salutation = "Dear Ms. Smith,"
+	original.Closing = "Kind regards,"
+	original.Signature = "John Doe"
+	assertCoverLetterRoundTrip(t, templateContent, original)
+}
+
+func TestParseCoverLetterRejectsOtherDocuments(t *testing.T) {
+	if _, err := typstparse.ParseCoverLetter("= Just a heading"); err == nil {
+		t.Error("Expected error for document without cover letter content")
+	}
+}
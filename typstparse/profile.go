@@ -0,0 +1,46 @@
+package typstparse
+
+import (
+	"regexp"
+	"strings"
+
+	"cvcl-render/model"
+)
+
+// typstCustomEntryPattern matches an entry of the custom author field written by the templates
+var typstCustomEntryPattern = regexp.MustCompile(`\(\s*text:\s*"((?:[^"\\]|\\.)*)"\s*,\s*icon:\s*"((?:[^"\\]|\\.)*)"(?:\s*,\s*link:\s*"((?:[^"\\]|\\.)*)")?\s*\)`)
+
+// parseTypstProfile parses the author tuple of a rendered resume or cover letter
+func parseTypstProfile(author string) model.Profile {
+	custom := findTypstCall(author, "custom: (", '(', ')')
+	fields := parseTypstStringFields(strings.Replace(author, custom, "", 1))
+	p := model.Profile{
+		FirstName: fields["firstname"],
+		LastName:  fields["lastname"],
+		Email:     fields["email"],
+		Homepage:  fields["homepage"],
+		Phone:     fields["phone"],
+		GitHub:    fields["github"],
+		LinkedIn:  fields["linkedin"],
+		Twitter:   fields["twitter"],
+		Birth:     fields["birth"],
+		Address:   fields["address"],
+	}
+
+	for _, m := range typstCustomEntryPattern.FindAllStringSubmatch(custom, -1) {
+		text, icon, url := unescapeTypstString(m[1]), unescapeTypstString(m[2]), unescapeTypstString(m[3])
+		switch {
+		case icon == "flag" && url == "":
+			p.Nationality = text
+		case icon == "passport" && url == "":
+			p.VisaStatus = text
+		default:
+			link := model.ProfileLink{Type: model.ProfileLinkType(icon), URL: url, Label: text}
+			if link.Label == (model.ProfileLink{URL: url}).Text() {
+				link.Label = ""
+			}
+			p.Links = append(p.Links, link)
+		}
+	}
+	return p
+}
@@ -1,82 +1,77 @@
-package main
+// Package typstparse parses rendered Typst resumes and cover letters back into their JSON documents.
+package typstparse
 
 import (
-	"embed"
-	"encoding/json"
-	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
 	"regexp"
 	"strings"
-	"text/template"
-)
 
-//go:embed templates/resume.typ.template
-var embeddedResumeTemplates embed.FS
+	"cvcl-render/i18n"
+	"cvcl-render/model"
+)
 
-// Resume represents the complete resume structure
+// Resume represents the complete resume structure parsed from Typst
 type Resume struct {
-	Positions      []string        `json:"positions"`
-	Summary        string          `json:"summary"`
-	Education      []ResumeEntry   `json:"education"`
-	WorkExperience []ResumeEntry   `json:"work_experience"`
-	Projects       []ResumeEntry   `json:"projects"`
-	Skills         []SkillCategory `json:"skills"`
-	Interests      []InterestItem  `json:"interests"`
-}
-
-// ResumeEntry represents a single entry with title, location, date, and content
-type ResumeEntry struct {
-	Title       string `json:"title"`
-	Location    string `json:"location,omitempty"`
-	Date        string `json:"date,omitempty"`
-	Description string `json:"description,omitempty"`
-	Content     string `json:"content,omitempty"`
+	// Language is the catalog language whose section headings were recognised
+	Language       string                `json:"language,omitempty"`
+	Author         model.Profile         `json:"author"`
+	Positions      []string              `json:"positions"`
+	Summary        string                `json:"summary"`
+	Education      []model.ResumeEntry   `json:"education"`
+	WorkExperience []model.ResumeEntry   `json:"work_experience"`
+	Projects       []model.ResumeEntry   `json:"projects"`
+	Skills         []model.SkillCategory `json:"skills"`
+	Interests      []model.InterestItem  `json:"interests"`
 }
 
-// SkillCategory represents a category of skills with a name and list of skills
-type SkillCategory struct {
-	Name   string      `json:"name"`
-	Skills []SkillItem `json:"skills"`
+// Data converts a parsed resume into render input, keeping the recognised language as an option
+func (r Resume) Data() model.ResumeData {
+	data := model.ResumeData{
+		Author:         r.Author,
+		Positions:      r.Positions,
+		Summary:        r.Summary,
+		Education:      r.Education,
+		WorkExperience: r.WorkExperience,
+		Projects:       r.Projects,
+		Skills:         r.Skills,
+		Interests:      r.Interests,
+	}
+	if r.Language != "" {
+		data.Options = &model.RenderOptions{Language: r.Language}
+	}
+	return data
 }
 
-// SkillItem represents a single skill with its name and whether it's strong/emphasized
-type SkillItem struct {
-	Name   string `json:"name"`
-	Strong bool   `json:"strong"`
-}
+// ParseResume parses a Typst resume file and returns a Resume struct
+func ParseResume(content string) (*Resume, error) {
+	resume := &Resume{}
 
-// InterestItem represents a single interest with a category and description
-type InterestItem struct {
-	Category    string `json:"category"`
-	Description string `json:"description"`
-}
+	// Recognise the section headings of any catalog language
+	messages := i18n.DetectResume(content)
+	resume.Language = messages.Language
 
-// ParseResumeTypst parses a Typst resume file and returns a Resume struct
-func ParseResumeTypst(content string) (*Resume, error) {
-	resume := &Resume{}
+	// Parse the author tuple
+	resume.Author = parseTypstProfile(findTypstCall(content, "author: (", '(', ')'))
 
 	// Parse positions
 	resume.Positions = parsePositions(content)
 
 	// Parse summary
-	resume.Summary = parseSummary(content)
+	resume.Summary = parseSummary(content, messages.Summary)
 
 	// Parse education
-	resume.Education = parseSection(content, "Education")
+	resume.Education = parseSection(content, messages.Education)
 
 	// Parse working experience
-	resume.WorkExperience = parseSection(content, "Working Experience")
+	resume.WorkExperience = parseSection(content, messages.WorkExperience)
 
 	// Parse projects
-	resume.Projects = parseSection(content, "Projects")
+	resume.Projects = parseSection(content, messages.Projects)
 
 	// Parse skills
-	resume.Skills = parseSkills(content)
+	resume.Skills = parseSkills(content, messages.Skills, messages.Interests)
 
 	// Parse interests
-	resume.Interests = parseInterests(content)
+	resume.Interests = parseInterests(content, messages.Interests)
 
 	return resume, nil
 }
@@ -94,39 +89,41 @@ func parsePositions(content string) []string {
 
 	positionsText := match[1]
 	// Extract quoted strings
-	stringPattern := regexp.MustCompile(`"([^"]*)"`)
+	stringPattern := regexp.MustCompile(`"((?:[^"\\]|\\.)*)"`)
 	for _, m := range stringPattern.FindAllStringSubmatch(positionsText, -1) {
 		if len(m) > 1 {
-			positions = append(positions, m[1])
+			positions = append(positions, unescapeTypstString(m[1]))
 		}
 	}
 
 	return positions
 }
 
-// parseSummary extracts the summary text
-func parseSummary(content string) string {
-	// Find the summary section by looking for "= Summary" and "= Education"
-	startIdx := strings.Index(content, "= Summary")
+// parseSummary extracts the summary text under the given heading
+func parseSummary(content string, heading string) string {
+	// Find the summary section by looking for its header
+	marker := "= " + heading
+	startIdx := strings.Index(content, marker)
 	if startIdx == -1 {
 		return ""
 	}
+	startIdx += len(marker)
 
 	// Find the end of summary (next section header)
-	endIdx := strings.Index(content[startIdx+9:], "=")
+	endIdx := strings.Index(content[startIdx:], "=")
 	if endIdx == -1 {
 		return ""
 	}
 
-	summary := content[startIdx+9 : startIdx+9+endIdx]
+	summary := content[startIdx : startIdx+endIdx]
 	summary = strings.TrimSpace(summary)
 
 	return summary
 }
 
 // parseSection extracts all resume entries for a given section (Education, Working Experience, Projects)
-func parseSection(content string, sectionName string) []ResumeEntry {
-	var entries []ResumeEntry
+func parseSection(content string, sectionName string) []model.ResumeEntry {
+	var entries []model.ResumeEntry
 
 	// Find the section by its header
 	sectionMarker := "= " + sectionName
@@ -137,7 +134,7 @@ func parseSection(content string, sectionName string) []ResumeEntry {
 
 	// Find the next section marker (line starting with "=")
 	nextSectionIdx := startIdx + len(sectionMarker)
-	sectionLinePattern := regexp.MustCompile(`\n=\s+\w+`)
+	sectionLinePattern := regexp.MustCompile(`\n=\s+\S`)
 	match := sectionLinePattern.FindStringIndex(content[nextSectionIdx:])
 	var endIdx int
 	if match == nil {
@@ -214,8 +211,8 @@ func parseSection(content string, sectionName string) []ResumeEntry {
 }
 
 // parseResumeEntry extracts fields from a resume-entry block
-func parseResumeEntry(content string) ResumeEntry {
-	entry := ResumeEntry{}
+func parseResumeEntry(content string) model.ResumeEntry {
+	entry := model.ResumeEntry{}
 
 	// Extract title - can be either [bracketed] or "quoted"
 	// First try bracketed format
@@ -225,21 +222,21 @@ func parseResumeEntry(content string) ResumeEntry {
 		entry.Title = strings.TrimSpace(title)
 	} else {
 		// Try quoted format
-		titleQuotePattern := regexp.MustCompile(`title:\s*"([^"]*)"`)
+		titleQuotePattern := regexp.MustCompile(`title:\s*"((?:[^"\\]|\\.)*)"`)
 		if titleMatch := titleQuotePattern.FindStringSubmatch(content); len(titleMatch) > 1 {
-			entry.Title = titleMatch[1]
+			entry.Title = unescapeTypstString(titleMatch[1])
 		}
 	}
 
 	// Extract location
-	locationPattern := regexp.MustCompile(`location:\s*"([^"]*)"`)
+	locationPattern := regexp.MustCompile(`location:\s*"((?:[^"\\]|\\.)*)"`)
 	if locationMatch := locationPattern.FindStringSubmatch(content); len(locationMatch) > 1 {
-		entry.Location = locationMatch[1]
+		entry.Location = unescapeTypstString(locationMatch[1])
 	} else {
 		// Try matching github-link or other function patterns like: location: github-link("org/repo")
-		githubLinkPattern := regexp.MustCompile(`location:\s*(?:\[?\s*#)?github-link\("([^"]+)"\)`)
+		githubLinkPattern := regexp.MustCompile(`location:\s*(?:\[?\s*#)?github-link\("((?:[^"\\]|\\.)+)"\)`)
 		if githubMatch := githubLinkPattern.FindStringSubmatch(content); len(githubMatch) > 1 {
-			entry.Location = githubMatch[1]
+			entry.Location = unescapeTypstString(githubMatch[1])
 		} else {
 			// Try matching bracketed location like: location: [something]
 			bracketPattern := regexp.MustCompile(`location:\s*\[([^\]]+)\]`)
@@ -250,9 +247,9 @@ func parseResumeEntry(content string) ResumeEntry {
 	}
 
 	// Extract date
-	datePattern := regexp.MustCompile(`date:\s*"([^"]*)"`)
+	datePattern := regexp.MustCompile(`date:\s*"((?:[^"\\]|\\.)*)"`)
 	if dateMatch := datePattern.FindStringSubmatch(content); len(dateMatch) > 1 {
-		entry.Date = dateMatch[1]
+		entry.Date = unescapeTypstString(dateMatch[1])
 	}
 
 	// Extract description - can be either [bracketed] or "quoted"
@@ -263,9 +260,9 @@ func parseResumeEntry(content string) ResumeEntry {
 		entry.Description = strings.TrimSpace(description)
 	} else {
 		// Try quoted format
-		descQuotePattern := regexp.MustCompile(`description:\s*"([^"]*)"`)
+		descQuotePattern := regexp.MustCompile(`description:\s*"((?:[^"\\]|\\.)*)"`)
 		if descMatch := descQuotePattern.FindStringSubmatch(content); len(descMatch) > 1 {
-			entry.Description = descMatch[1]
+			entry.Description = unescapeTypstString(descMatch[1])
 		}
 	}
 
@@ -292,19 +289,19 @@ func parseResumeItems(content string) string {
 	return strings.Join(cleanedLines, "\n")
 }
 
-// parseSkills extracts skill categories and items
-func parseSkills(content string) []SkillCategory {
-	var skills []SkillCategory
+// parseSkills extracts skill categories and items, ending at the interests heading
+func parseSkills(content string, heading string, interestsHeading string) []model.SkillCategory {
+	var skills []model.SkillCategory
 
 	// Find skills section by string search
-	skillsMarker := "= Skills"
+	skillsMarker := "= " + heading
 	startIdx := strings.Index(content, skillsMarker)
 	if startIdx == -1 {
 		return skills
 	}
 
 	// Find the Interests section or end of content
-	endMarker := "= Interests"
+	endMarker := "= " + interestsHeading
 	endIdx := strings.Index(content[startIdx:], endMarker)
 	if endIdx == -1 {
 		endIdx = len(content)
@@ -315,7 +312,7 @@ func parseSkills(content string) []SkillCategory {
 	skillsContent := content[startIdx+len(skillsMarker) : endIdx]
 
 	// Find all resume-skill-item blocks
-	skillItemPattern := regexp.MustCompile(`#resume-skill-item\(\s*"([^"]*)"\s*,\s*\(([\s\S]*?)\)\s*\)`)
+	skillItemPattern := regexp.MustCompile(`#resume-skill-item\(\s*"((?:[^"\\]|\\.)*)"\s*,\s*\(([\s\S]*?)\)\s*,?\s*\)`)
 	skillMatches := skillItemPattern.FindAllStringSubmatch(skillsContent, -1)
 
 	for _, skillMatch := range skillMatches {
@@ -323,8 +320,8 @@ func parseSkills(content string) []SkillCategory {
 			continue
 		}
 
-		category := SkillCategory{
-			Name: skillMatch[1],
+		category := model.SkillCategory{
+			Name: unescapeTypstString(skillMatch[1]),
 		}
 
 		// Parse skill items
@@ -338,12 +335,12 @@ func parseSkills(content string) []SkillCategory {
 }
 
 // parseSkillItems extracts individual skills from a skill category
-func parseSkillItems(content string) []SkillItem {
-	var items []SkillItem
+func parseSkillItems(content string) []model.SkillItem {
+	var items []model.SkillItem
 
 	// Match both strong("...") and plain "..." patterns
-	strongPattern := regexp.MustCompile(`strong\("([^"]*)"\)`)
-	plainStringPattern := regexp.MustCompile(`"([^"]*)"`)
+	strongPattern := regexp.MustCompile(`strong\("((?:[^"\\]|\\.)*)"\)`)
+	plainStringPattern := regexp.MustCompile(`"((?:[^"\\]|\\.)*)"`)
 
 	// Create map of strong items for later lookup
 	strongMatches := strongPattern.FindAllStringSubmatchIndex(content, -1)
@@ -352,7 +349,7 @@ func parseSkillItems(content string) []SkillItem {
 	strongItems := make(map[string]bool)
 	for _, match := range strongMatches {
 		if len(match) >= 4 {
-			skillName := content[match[2]:match[3]]
+			skillName := unescapeTypstString(content[match[2]:match[3]])
 			strongItems[skillName] = true
 		}
 	}
@@ -361,9 +358,9 @@ func parseSkillItems(content string) []SkillItem {
 	stringMatches := plainStringPattern.FindAllStringSubmatch(content, -1)
 	for _, match := range stringMatches {
 		if len(match) > 1 {
-			skillName := match[1]
+			skillName := unescapeTypstString(match[1])
 			isStrong := strongItems[skillName]
-			items = append(items, SkillItem{
+			items = append(items, model.SkillItem{
 				Name:   skillName,
 				Strong: isStrong,
 			})
@@ -373,12 +370,26 @@ func parseSkillItems(content string) []SkillItem {
 	return items
 }
 
-// parseInterests extracts interest items
-func parseInterests(content string) []InterestItem {
-	var interests []InterestItem
+// closingQuote returns the index of the quote ending a Typst string whose body starts at start,
+// skipping escaped characters, or -1
+func closingQuote(s string, start int) int {
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// parseInterests extracts interest items under the given heading
+func parseInterests(content string, heading string) []model.InterestItem {
+	var interests []model.InterestItem
 
-	// Find interests section by looking for "= Interests"
-	startIdx := strings.Index(content, "= Interests")
+	// Find interests section by looking for its header
+	startIdx := strings.Index(content, "= "+heading)
 	if startIdx == -1 {
 		return interests
 	}
@@ -404,14 +415,13 @@ func parseInterests(content string) []InterestItem {
 		}
 		categoryStart += itemIdx + len(itemMarker)
 
-		categoryEnd := strings.Index(interestsContent[categoryStart+1:], "\"")
+		categoryEnd := closingQuote(interestsContent, categoryStart+1)
 		if categoryEnd == -1 {
 			pos = itemIdx + len(itemMarker)
 			continue
 		}
-		categoryEnd += categoryStart + 1
 
-		category := interestsContent[categoryStart+1 : categoryEnd]
+		category := unescapeTypstString(interestsContent[categoryStart+1 : categoryEnd])
 
 		// Find the next #resume-skill-item( or end of file
 		nextItemIdx := strings.Index(interestsContent[itemIdx+len(itemMarker):], itemMarker)
@@ -432,7 +442,7 @@ func parseInterests(content string) []InterestItem {
 			descriptionRaw := blockContent[descStart+1 : descEnd]
 			description := strings.TrimSpace(descriptionRaw)
 
-			interests = append(interests, InterestItem{
+			interests = append(interests, model.InterestItem{
 				Category:    category,
 				Description: description,
 			})
@@ -443,116 +453,3 @@ func parseInterests(content string) []InterestItem {
 
 	return interests
 }
-
-// Author represents the author information for the resume
-
-// Author represents the author information for the resume
-type Author struct {
-	Firstname string `json:"firstname"`
-	Lastname  string `json:"lastname"`
-	Email     string `json:"email"`
-	Homepage  string `json:"homepage,omitempty"`
-	Phone     string `json:"phone,omitempty"`
-	Github    string `json:"github,omitempty"`
-	Twitter   string `json:"twitter,omitempty"`
-	Birth     string `json:"birth,omitempty"`
-	Linkedin  string `json:"linkedin,omitempty"`
-}
-
-// ResumeData represents the complete data structure for rendering a resume
-type ResumeData struct {
-	Author         Author          `json:"author"`
-	Positions      []string        `json:"positions"`
-	Summary        string          `json:"summary"`
-	Education      []ResumeEntry   `json:"education"`
-	WorkExperience []ResumeEntry   `json:"work_experience"`
-	Projects       []ResumeEntry   `json:"projects"`
-	Skills         []SkillCategory `json:"skills"`
-	Interests      []InterestItem  `json:"interests"`
-}
-
-// getResumeTemplateContent reads resume template from embedded files or filesystem
-func getResumeTemplateContent(templatePath string) (string, error) {
-	// Try to read from embedded templates first
-	if strings.HasSuffix(templatePath, "resume.typ.template") || templatePath == "templates/resume.typ.template" {
-		data, err := embeddedResumeTemplates.ReadFile("templates/resume.typ.template")
-		if err == nil {
-			return string(data), nil
-		}
-	}
-
-	// Fall back to filesystem
-	templateContent, err := os.ReadFile(templatePath)
-	if err != nil {
-		return "", fmt.Errorf("failed to read template file: %w", err)
-	}
-	return string(templateContent), nil
-}
-
-// RenderResume renders the resume template with the provided data
-func RenderResume(templateContent string, data ResumeData) (string, error) {
-	// Create template with custom functions
-	funcMap := template.FuncMap{
-		"contains": strings.Contains,
-	}
-
-	// Parse template
-	tmpl, err := template.New("resume").Funcs(funcMap).Parse(templateContent)
-	if err != nil {
-		return "", fmt.Errorf("failed to parse template: %w", err)
-	}
-
-	// Execute template
-	var result strings.Builder
-	err = tmpl.Execute(&result, data)
-	if err != nil {
-		return "", fmt.Errorf("failed to execute template: %w", err)
-	}
-
-	return result.String(), nil
-}
-
-// RenderResumeFromJSON renders the template using JSON data
-func RenderResumeFromJSON(templateContent string, jsonData string) (string, error) {
-	var data ResumeData
-	err := json.Unmarshal([]byte(jsonData), &data)
-	if err != nil {
-		return "", fmt.Errorf("failed to parse JSON data: %w", err)
-	}
-
-	return RenderResume(templateContent, data)
-}
-
-// RenderAndCompileResume renders the resume template and optionally compiles to PDF
-func RenderAndCompileResume(templateContent string, data ResumeData, outputDir string, skipPDF bool) (typstFile string, pdfFile string, err error) {
-	// Render template
-	result, err := RenderResume(templateContent, data)
-	if err != nil {
-		return "", "", err
-	}
-
-	// Generate output filenames
-	typstFileName := fmt.Sprintf("Resume_%s_%s.typ", data.Author.Firstname, data.Author.Lastname)
-	pdfFileName := fmt.Sprintf("Resume_%s_%s.pdf", data.Author.Firstname, data.Author.Lastname)
-
-	typstFilePath := filepath.Join(outputDir, typstFileName)
-	pdfFilePath := filepath.Join(outputDir, pdfFileName)
-
-	// Write the rendered Typst file
-	err = os.WriteFile(typstFilePath, []byte(result), 0644)
-	if err != nil {
-		return "", "", fmt.Errorf("failed to write Typst file: %w", err)
-	}
-
-	// Compile to PDF if not skipped
-	if !skipPDF {
-		cmd := exec.Command("typst", "compile", typstFilePath, pdfFilePath)
-		stdoutStderr, err := cmd.CombinedOutput()
-		if err != nil {
-			fmt.Printf("typst compile failed: %s\nOutput:\n%s\n", err, string(stdoutStderr))
-			return "", "", fmt.Errorf("failed to compile Typst file: %w", err)
-		}
-	}
-
-	return typstFilePath, pdfFilePath, nil
-}
@@ -0,0 +1,230 @@
+package typstparse_test
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"cvcl-render/examples"
+	"cvcl-render/i18n"
+	"cvcl-render/model"
+	"cvcl-render/render"
+	"cvcl-render/typstparse"
+)
+
+func TestProfileRoundTrip(t *testing.T) {
+	templateContent, err := render.CoverLetterTemplate("templates/coverletter.typ.template")
+	if err != nil {
+		t.Fatalf("Failed to read template: %v", err)
+	}
+	profile := model.Profile{
+		FirstName:   "Jane",
+		LastName:    "Doe",
+		Email:       "jane@example.com",
+		Twitter:     "janedoe",
+		Address:     "Gothenburg, Sweden",
+		Nationality: "Swedish",
+		VisaStatus:  "EU work permit",
+		Links: []model.ProfileLink{
+			{Type: "gitlab", URL: "https://gitlab.com/jane"},
+			{Type: "orcid", URL: "https://orcid.org/0000-0002-1825-0097", Label: "ORCID"},
+			{Type: "link", URL: "https://example.com/blog"},
+		},
+	}
+	data := model.CoverLetterData{Profile: profile, Position: "Engineer", Addressee: "Acme", Opening: "Hello."}
+
+	typst, err := render.CoverLetter(templateContent, data)
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+	for _, expected := range []string{
+		`address: "Gothenburg, Sweden"`,
+		`(text: "Swedish", icon: "flag")`,
+		`(text: "gitlab.com/jane", icon: "gitlab", link: "https://gitlab.com/jane")`,
+		`(text: "ORCID", icon: "orcid", link: "https://orcid.org/0000-0002-1825-0097")`,
+	} {
+		if !strings.Contains(typst, expected) {
+			t.Errorf("Rendered letter is missing %s", expected)
+		}
+	}
+
+	parsed, err := typstparse.ParseCoverLetter(typst)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if !reflect.DeepEqual(parsed.Profile, profile) {
+		t.Errorf("Profile did not round-trip:\n got %+v\nwant %+v", parsed.Profile, profile)
+	}
+}
+
+func TestParseDateRangesCoversResumeTemplate(t *testing.T) {
+	content, err := os.ReadFile("testdata/resume.typ")
+	if err != nil {
+		t.Fatalf("Failed to read resume: %v", err)
+	}
+	resume, err := typstparse.ParseResume(string(content))
+	if err != nil {
+		t.Fatalf("Failed to parse resume: %v", err)
+	}
+	for _, section := range [][]model.ResumeEntry{resume.Education, resume.WorkExperience, resume.Projects} {
+		for _, entry := range section {
+			if entry.Date == "" {
+				continue
+			}
+			if _, err := model.ParseDateRanges(entry.Date); err != nil {
+				t.Errorf("Date of %q is not understood: %v", entry.Title, err)
+			}
+		}
+	}
+}
+
+func TestLocalizedResumeRoundTrip(t *testing.T) {
+	templateContent, err := render.ResumeTemplate("templates/resume.typ.template")
+	if err != nil {
+		t.Fatalf("Failed to read template: %v", err)
+	}
+	var data model.ResumeData
+	if err := json.Unmarshal(examples.Resume, &data); err != nil {
+		t.Fatalf("Failed to parse example: %v", err)
+	}
+
+	for _, language := range []string{"en", "sv", "de", "zh"} {
+		localized := data
+		localized.Options = &model.RenderOptions{Language: language}
+		typst, err := render.Resume(templateContent, localized)
+		if err != nil {
+			t.Fatalf("Failed to render %s resume: %v", language, err)
+		}
+		messages := i18n.Get(language)
+		if !strings.Contains(typst, "= "+messages.WorkExperience+"\n") || !strings.Contains(typst, `language: "`+language+`"`) {
+			t.Errorf("%s resume is missing localized headings", language)
+		}
+
+		parsed, err := typstparse.ParseResume(typst)
+		if err != nil {
+			t.Fatalf("Failed to parse %s resume: %v", language, err)
+		}
+		if parsed.Language != language {
+			t.Errorf("Detected language %q, want %q", parsed.Language, language)
+		}
+		if parsed.Summary != data.Summary {
+			t.Errorf("%s summary not recovered: %q", language, parsed.Summary)
+		}
+		if len(parsed.Education) != len(data.Education) || len(parsed.WorkExperience) != len(data.WorkExperience) ||
+			len(parsed.Projects) != len(data.Projects) || !reflect.DeepEqual(parsed.Skills, data.Skills) ||
+			len(parsed.Interests) != len(data.Interests) {
+			t.Errorf("%s sections not recovered: %+v", language, parsed)
+		}
+	}
+}
+
+func TestLocalizedCoverLetter(t *testing.T) {
+	templateContent, err := render.CoverLetterTemplate("templates/coverletter.typ.template")
+	if err != nil {
+		t.Fatalf("Failed to read template: %v", err)
+	}
+	data := model.CoverLetterData{
+		Profile:    model.Profile{FirstName: "Anna", LastName: "Schmidt", Email: "anna@example.com"},
+		Position:   "Softwareentwicklerin",
+		Addressee:  "Beispiel GmbH",
+		Paragraphs: []model.Paragraph{{Text: "Hiermit bewerbe ich mich."}},
+		Options:    &model.RenderOptions{Language: "de"},
+	}
+
+	typst, err := render.CoverLetter(templateContent, data)
+	if err != nil {
+		t.Fatalf("Failed to render cover letter: %v", err)
+	}
+	for _, want := range []string{`language: "de"`, "#letter-salutation[Sehr geehrte Damen und Herren,]", "#letter-closing[Mit freundlichen Grüßen]"} {
+		if !strings.Contains(typst, want) {
+			t.Errorf("Rendered letter is missing %q", want)
+		}
+	}
+
+	parsed, err := typstparse.ParseCoverLetter(typst)
+	if err != nil {
+		t.Fatalf("Failed to parse cover letter: %v", err)
+	}
+	if parsed.Options == nil || parsed.Options.Language != "de" {
+		t.Errorf("Language not recovered: %+v", parsed.Options)
+	}
+	if parsed.Salutation != "Sehr geehrte Damen und Herren," {
+		t.Errorf("Salutation not recovered: %q", parsed.Salutation)
+	}
+
+	// An explicit salutation wins over the catalog default
+	data.Options = &model.RenderOptions{Language: "sv"}
+	data.Salutation = "Hej!"
+	typst, err = render.CoverLetter(templateContent, data)
+	if err != nil {
+		t.Fatalf("Failed to render cover letter: %v", err)
+	}
+	if !strings.Contains(typst, "#letter-salutation[Hej!]") || !strings.Contains(typst, "#letter-closing[Med vänliga hälsningar,]") {
+		t.Errorf("Unexpected Swedish letter:\n%s", typst)
+	}
+}
+
+func TestQuotedStringsRoundTrip(t *testing.T) {
+	quoted := `Jane "JD" Doe \ Co`
+	coverTemplate, err := render.CoverLetterTemplate("templates/coverletter.typ.template")
+	if err != nil {
+		t.Fatalf("Failed to read template: %v", err)
+	}
+	profile := model.Profile{
+		FirstName: "Jane",
+		LastName:  `O"Brien`,
+		Email:     "jane@example.com",
+		Address:   quoted,
+		Links:     []model.ProfileLink{{Type: "link", URL: `https://example.com/?q="x"`, Label: quoted}},
+	}
+	letter := model.CoverLetterData{Profile: profile, Position: `"Senior" Engineer`, Addressee: `Acme \ Sons`, Opening: "Hello."}
+	typst, err := render.CoverLetter(coverTemplate, letter)
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+	if !strings.Contains(typst, `address: "Jane \"JD\" Doe \\ Co"`) {
+		t.Errorf("Address is not escaped:\n%s", typst)
+	}
+	parsed, err := typstparse.ParseCoverLetter(typst)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if !reflect.DeepEqual(parsed.Profile, profile) || parsed.Position != letter.Position || parsed.Addressee != letter.Addressee {
+		t.Errorf("Cover letter did not round-trip:\n got %+v %q %q\nwant %+v", parsed.Profile, parsed.Position, parsed.Addressee, profile)
+	}
+
+	resumeTemplate, err := render.ResumeTemplate("templates/resume.typ.template")
+	if err != nil {
+		t.Fatalf("Failed to read template: %v", err)
+	}
+	resume := model.ResumeData{
+		Author:         profile,
+		Positions:      []string{`"Lead" Developer`},
+		WorkExperience: []model.ResumeEntry{{Title: quoted, Location: `C:\Users`, Date: "2020 - 2021"}},
+		Skills:         []model.SkillCategory{{Name: `C "family"`, Skills: []model.SkillItem{{Name: `C\C++`, Strong: true}, {Name: `"Go"`}}}},
+		Interests:      []model.InterestItem{{Category: `Music "live"`, Description: "Guitar"}},
+	}
+	typst, err = render.Resume(resumeTemplate, resume)
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+	parsedResume, err := typstparse.ParseResume(typst)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	entries := parsedResume.WorkExperience
+	if len(entries) != 1 || entries[0].Title != quoted || entries[0].Location != `C:\Users` {
+		t.Errorf("Entry did not round-trip: %+v", entries)
+	}
+	if !reflect.DeepEqual(parsedResume.Skills, resume.Skills) || !reflect.DeepEqual(parsedResume.Positions, resume.Positions) {
+		t.Errorf("Skills or positions did not round-trip: %+v %q", parsedResume.Skills, parsedResume.Positions)
+	}
+	if len(parsedResume.Interests) != 1 || parsedResume.Interests[0].Category != resume.Interests[0].Category {
+		t.Errorf("Interests did not round-trip: %+v", parsedResume.Interests)
+	}
+	if parsedResume.Author.LastName != profile.LastName || parsedResume.Author.Address != quoted {
+		t.Errorf("Author did not round-trip: %+v", parsedResume.Author)
+	}
+}
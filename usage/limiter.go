@@ -0,0 +1,133 @@
+// Package usage limits how much API clients use the server: a token bucket per client bounds
+// its request rate, and daily quotas bound its renders, with counts kept on disk across restarts.
+package usage
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxIdleBuckets is the number of buckets kept before full ones are dropped
+const maxIdleBuckets = 10000
+
+// Limiter keeps a token bucket per client. Each request takes a token; tokens are refilled
+// at PerMinute per minute up to Burst.
+type Limiter struct {
+	PerMinute int
+	Burst     int
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// Decision is the outcome of Limiter.Allow
+type Decision struct {
+	Allowed bool
+	// Limit is the burst size and Remaining the whole tokens left after the request
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is the time until the next token when the request was refused
+	RetryAfter time.Duration
+}
+
+// NewLimiter returns a limiter allowing perMinute requests a minute in bursts of up to burst;
+// a burst below one defaults to perMinute
+func NewLimiter(perMinute, burst int) *Limiter {
+	if burst < 1 {
+		burst = perMinute
+	}
+	return &Limiter{PerMinute: perMinute, Burst: burst, buckets: make(map[string]*bucket), now: time.Now}
+}
+
+// Allow takes a token from the client's bucket if one is left
+func (l *Limiter) Allow(client string) Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := l.refill(client)
+	decision := Decision{Limit: l.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = l.duration(1 - b.tokens)
+	}
+	decision.Remaining = int(b.tokens)
+	decision.Reset = l.duration(float64(l.Burst) - b.tokens)
+	return decision
+}
+
+// Tokens returns the tokens left in the client's bucket without taking one
+func (l *Limiter) Tokens(client string) float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.buckets[client]; !ok {
+		return float64(l.Burst)
+	}
+	return l.refill(client).tokens
+}
+
+// Clients returns the clients whose buckets are not full, sorted
+func (l *Limiter) Clients() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var clients []string
+	for client := range l.buckets {
+		if l.refill(client).tokens < float64(l.Burst) {
+			clients = append(clients, client)
+		}
+	}
+	sort.Strings(clients)
+	return clients
+}
+
+// Reset fills the client's bucket
+func (l *Limiter) Reset(client string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.buckets, client)
+}
+
+// refill returns the client's bucket with the tokens earned since its last update
+func (l *Limiter) refill(client string) *bucket {
+	now := l.now()
+	b, ok := l.buckets[client]
+	if !ok {
+		if len(l.buckets) >= maxIdleBuckets {
+			l.dropFull(now)
+		}
+		b = &bucket{tokens: float64(l.Burst), updated: now}
+		l.buckets[client] = b
+		return b
+	}
+	earned := now.Sub(b.updated).Minutes() * float64(l.PerMinute)
+	b.tokens = math.Min(float64(l.Burst), b.tokens+earned)
+	b.updated = now
+	return b
+}
+
+// dropFull forgets the buckets that have refilled, which behave like new ones
+func (l *Limiter) dropFull(now time.Time) {
+	for client, b := range l.buckets {
+		if b.tokens+now.Sub(b.updated).Minutes()*float64(l.PerMinute) >= float64(l.Burst) {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// duration returns the time needed to earn tokens
+func (l *Limiter) duration(tokens float64) time.Duration {
+	if tokens <= 0 || l.PerMinute <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / float64(l.PerMinute) * float64(time.Minute))
+}
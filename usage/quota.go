@@ -0,0 +1,176 @@
+package usage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// dayFormat names the UTC day counts belong to
+const dayFormat = "2006-01-02"
+
+// Quotas counts the renders of each client per UTC day and refuses them beyond Limit.
+// The counts are saved to a JSON file after every change, so restarts do not reset them.
+type Quotas struct {
+	// Limit is the number of renders a client may make a day; zero only counts them
+	Limit int
+
+	path   string
+	mu     sync.Mutex
+	day    string
+	counts map[string]int
+	now    func() time.Time
+}
+
+// QuotaStatus is the usage of a client's daily quota
+type QuotaStatus struct {
+	Client string `json:"client"`
+	Used   int    `json:"used"`
+	// Remaining is omitted when renders are not limited
+	Remaining *int `json:"remaining,omitempty"`
+	// Reset is when the counts start over, at the next UTC midnight
+	Reset time.Time `json:"reset"`
+}
+
+// quotaFile is the JSON file of the counts
+type quotaFile struct {
+	Day     string         `json:"day"`
+	Renders map[string]int `json:"renders"`
+}
+
+// OpenQuotas reads the counts saved at path, if any, and creates its directory
+func OpenQuotas(path string, limit int) (*Quotas, error) {
+	q := &Quotas{Limit: limit, path: path, counts: make(map[string]int), now: time.Now}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create usage directory: %w", err)
+	}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		q.day = q.today()
+		return q, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage file: %w", err)
+	}
+	var saved quotaFile
+	if err := json.Unmarshal(content, &saved); err != nil {
+		return nil, fmt.Errorf("failed to parse usage file %s: %w", path, err)
+	}
+	q.day = saved.Day
+	for client, n := range saved.Renders {
+		q.counts[client] = n
+	}
+	return q, nil
+}
+
+// Reserve counts a render of the client unless its quota is used up. A reserved render
+// that fails should be handed back with Release.
+func (q *Quotas) Reserve(client string) (QuotaStatus, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rollover()
+	if q.Limit > 0 && q.counts[client] >= q.Limit {
+		return q.status(client), false, nil
+	}
+	q.counts[client]++
+	return q.status(client), true, q.save()
+}
+
+// Release hands back a render counted by Reserve
+func (q *Quotas) Release(client string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rollover()
+	if q.counts[client] == 0 {
+		return nil
+	}
+	q.counts[client]--
+	if q.counts[client] == 0 {
+		delete(q.counts, client)
+	}
+	return q.save()
+}
+
+// Status returns the client's usage today
+func (q *Quotas) Status(client string) QuotaStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rollover()
+	return q.status(client)
+}
+
+// Clients returns the clients that rendered today, sorted
+func (q *Quotas) Clients() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rollover()
+	clients := make([]string, 0, len(q.counts))
+	for client := range q.counts {
+		clients = append(clients, client)
+	}
+	sort.Strings(clients)
+	return clients
+}
+
+// Reset clears the client's count for today
+func (q *Quotas) Reset(client string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rollover()
+	if _, ok := q.counts[client]; !ok {
+		return nil
+	}
+	delete(q.counts, client)
+	return q.save()
+}
+
+func (q *Quotas) status(client string) QuotaStatus {
+	status := QuotaStatus{Client: client, Used: q.counts[client], Reset: q.nextDay()}
+	if q.Limit > 0 {
+		remaining := q.Limit - status.Used
+		if remaining < 0 {
+			remaining = 0
+		}
+		status.Remaining = &remaining
+	}
+	return status
+}
+
+// rollover starts new counts on a new UTC day
+func (q *Quotas) rollover() {
+	if today := q.today(); q.day != today {
+		q.day = today
+		q.counts = make(map[string]int)
+	}
+}
+
+func (q *Quotas) today() string {
+	return q.now().UTC().Format(dayFormat)
+}
+
+func (q *Quotas) nextDay() time.Time {
+	now := q.now().UTC()
+	return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+}
+
+// save writes the counts to a temporary file renamed over the old one, so a crash
+// never leaves a truncated file
+func (q *Quotas) save() error {
+	content, err := json.MarshalIndent(quotaFile{Day: q.day, Renders: q.counts}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode usage: %w", err)
+	}
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return fmt.Errorf("failed to write usage file: %w", err)
+	}
+	if err := os.Rename(tmp, q.path); err != nil {
+		return fmt.Errorf("failed to write usage file: %w", err)
+	}
+	return nil
+}
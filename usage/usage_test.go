@@ -0,0 +1,84 @@
+package usage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	l := NewLimiter(60, 3)
+	l.now = func() time.Time { return now }
+
+	for i := 2; i >= 0; i-- {
+		if d := l.Allow("a"); !d.Allowed || d.Remaining != i || d.Limit != 3 {
+			t.Fatalf("Request %d: unexpected decision %+v", 3-i, d)
+		}
+	}
+	d := l.Allow("a")
+	if d.Allowed || d.RetryAfter != time.Second || d.Reset != 3*time.Second {
+		t.Errorf("Expected a refusal for a second, got %+v", d)
+	}
+	if !l.Allow("b").Allowed {
+		t.Error("Buckets are not per client")
+	}
+
+	now = now.Add(1500 * time.Millisecond)
+	if d := l.Allow("a"); !d.Allowed || d.Remaining != 0 {
+		t.Errorf("Expected a refilled token, got %+v", d)
+	}
+	l.Reset("a")
+	if tokens := l.Tokens("a"); tokens != 3 {
+		t.Errorf("Expected a full bucket after a reset, got %v tokens", tokens)
+	}
+}
+
+func TestQuotas(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage", "usage.json")
+	now := time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC)
+	open := func() *Quotas {
+		q, err := OpenQuotas(path, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q.now = func() time.Time { return now }
+		return q
+	}
+
+	q := open()
+	for i := 0; i < 2; i++ {
+		if _, ok, err := q.Reserve("a"); !ok || err != nil {
+			t.Fatalf("Render %d refused: %v", i+1, err)
+		}
+	}
+	status, ok, _ := q.Reserve("a")
+	if ok || status.Used != 2 || *status.Remaining != 0 || !status.Reset.Equal(time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the quota to be used up, got %+v", status)
+	}
+	if err := q.Release("a"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Counts survive a restart
+	q = open()
+	if status := q.Status("a"); status.Used != 1 {
+		t.Errorf("Expected 1 render after reopening, got %+v", status)
+	}
+	if clients := q.Clients(); len(clients) != 1 || clients[0] != "a" {
+		t.Errorf("Unexpected clients %v", clients)
+	}
+	if err := q.Reset("a"); err != nil {
+		t.Fatal(err)
+	}
+	if status := open().Status("a"); status.Used != 0 {
+		t.Errorf("Expected a saved reset, got %+v", status)
+	}
+
+	// Counts start over on the next UTC day
+	q.Reserve("b")
+	now = now.Add(2 * time.Hour)
+	if status := q.Status("b"); status.Used != 0 {
+		t.Errorf("Expected new counts on a new day, got %+v", status)
+	}
+}